@@ -33,6 +33,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -301,13 +302,16 @@ func analyzeCPEDictionary(d CPEDict) (ProductToRepo VendorProductToRepoMap, Desc
 			Logger.Infof("Failed to parse %q", c.CPE23.Name)
 			continue
 		}
-		if CPE.Part != "a" {
+		if !CPE.IsApplication() {
 			// Not interested in hardware or operating systems.
 			continue
 		}
 		for _, r := range c.References {
 			DescriptionFrequency[r.Description] += 1
 			repo, err := cves.Repo(r.URL)
+			if errors.Is(err, cves.ErrGist) || errors.Is(err, cves.ErrNotRepo) {
+				continue
+			}
 			if err != nil {
 				Logger.Infof("Disregarding %q for %q/%q (%s) because %v", r.URL, CPE.Vendor, CPE.Product, r.Description, err)
 				continue
@@ -316,7 +316,7 @@ func main() {
 				Logger.Warnf("[%s]: Failed to parse CPE %q: %+v", cve.CVE.CVEDataMeta.ID, CPEstr, err)
 				continue
 			}
-			if CPE.Part == "a" {
+			if CPE.IsApplication() {
 				appCPECount += 1
 			}
 			if _, ok := VPRepoCache[VendorProduct{CPE.Vendor, CPE.Product}]; ok {
@@ -342,7 +342,7 @@ func main() {
 					continue
 				}
 				// Continue to only focus on application CPEs.
-				if CPE.Part != "a" {
+				if !CPE.IsApplication() {
 					continue
 				}
 				repos := ReposForCPE(cve.CVE.CVEDataMeta.ID, VPRepoCache, VendorProduct{CPE.Vendor, CPE.Product}, refs, RefTagDenyList)
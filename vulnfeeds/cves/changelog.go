@@ -0,0 +1,105 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// The names (without extension, case-insensitively) of changelog files.
+var changelogNames = []string{"changelog", "changes", "news", "history", "release_notes", "release-notes", "releasenotes"}
+
+// ChangelogRawURL returns the URL of the raw content of a changelog (e.g. CHANGELOG.md or NEWS)
+// referenced on GitHub or GitLab, e.g.
+// https://github.com/curl/curl/blob/master/CHANGES becomes https://raw.githubusercontent.com/curl/curl/master/CHANGES
+// https://gitlab.com/libtiff/libtiff/-/blob/master/ChangeLog becomes https://gitlab.com/libtiff/libtiff/-/raw/master/ChangeLog
+// URLs already of raw content are returned as is.
+func ChangelogRawURL(u string) (string, bool) {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return "", false
+	}
+	name := strings.ToLower(path.Base(parsedURL.Path))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if !slices.Contains(changelogNames, name) {
+		return "", false
+	}
+	switch {
+	case parsedURL.Hostname() == "raw.githubusercontent.com":
+		return u, true
+	case IsGitHubHost(parsedURL.Hostname()):
+		pathParts := strings.SplitN(strings.Trim(parsedURL.Path, "/"), "/", 4)
+		if len(pathParts) < 4 || (pathParts[2] != "blob" && pathParts[2] != "raw") {
+			return "", false
+		}
+		if parsedURL.Hostname() != "github.com" {
+			// GitHub Enterprise serves raw content from the same host.
+			return fmt.Sprintf("https://%s/%s/%s/raw/%s", parsedURL.Hostname(), pathParts[0], pathParts[1], pathParts[3]), true
+		}
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", pathParts[0], pathParts[1], pathParts[3]), true
+	case IsGitLabHost(parsedURL.Hostname()):
+		if strings.Contains(parsedURL.Path, "/-/raw/") {
+			return u, true
+		}
+		if !strings.Contains(parsedURL.Path, "/-/blob/") {
+			return "", false
+		}
+		return fmt.Sprintf("https://%s%s", parsedURL.Host, strings.Replace(parsedURL.Path, "/-/blob/", "/-/raw/", 1)), true
+	}
+	return "", false
+}
+
+// changelogHeadingPattern matches a line of a changelog heading a release, e.g.
+// "## [1.2.3] - 2023-01-01", "Version 1.2.3 (2023-01-01)", "v1.2.3", "=== 1.2.3 ===" or "* 1.2.3:",
+// capturing the version.
+var changelogHeadingPattern = regexp.MustCompile(`(?i)^\s*(?:[#=*\-]+\s*)?\[?(?:version\s+|release\s+|v)?(\d+(?:\.\d+)+(?:[\-+][0-9A-Za-z.\-]+)?)\]?(?:[\s:,(\-=]|$)`)
+
+// ExtractChangelogVersion finds the release of a changelog that mentions cveID, and so fixed it:
+// the version of the nearest release heading preceding the first mention of cveID.
+// Returns false (with a note) when cveID isn't mentioned, or isn't under a release heading.
+func ExtractChangelogVersion(content, cveID string, validVersions []string) (string, []string, bool) {
+	if cveID == "" {
+		return "", []string{"No CVE ID to find in the changelog"}, false
+	}
+	lines := strings.Split(content, "\n")
+	mention := -1
+	for i, line := range lines {
+		if strings.Contains(strings.ToUpper(line), strings.ToUpper(cveID)) {
+			mention = i
+			break
+		}
+	}
+	if mention == -1 {
+		return "", []string{fmt.Sprintf("%s is not mentioned in the changelog", cveID)}, false
+	}
+	for i := mention; i >= 0; i-- {
+		match := changelogHeadingPattern.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		var notes []string
+		if !hasVersion(validVersions, match[1]) {
+			notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", match[1]))
+		}
+		return match[1], notes, true
+	}
+	return "", []string{fmt.Sprintf("%s is not mentioned under a release in the changelog", cveID)}, false
+}
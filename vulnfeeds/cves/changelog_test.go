@@ -0,0 +1,168 @@
+package cves
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChangelogRawURL(t *testing.T) {
+	tests := []struct {
+		description    string
+		inputURL       string
+		expectedRawURL string
+		expectedOk     bool
+	}{
+		{
+			description:    "GitHub blob",
+			inputURL:       "https://github.com/curl/curl/blob/master/CHANGES",
+			expectedRawURL: "https://raw.githubusercontent.com/curl/curl/master/CHANGES",
+			expectedOk:     true,
+		},
+		{
+			description:    "GitHub blob of a markdown changelog in a subdirectory",
+			inputURL:       "https://github.com/foo/bar/blob/v1.2.3/packages/baz/CHANGELOG.md",
+			expectedRawURL: "https://raw.githubusercontent.com/foo/bar/v1.2.3/packages/baz/CHANGELOG.md",
+			expectedOk:     true,
+		},
+		{
+			description:    "Raw GitHub content",
+			inputURL:       "https://raw.githubusercontent.com/foo/bar/main/NEWS",
+			expectedRawURL: "https://raw.githubusercontent.com/foo/bar/main/NEWS",
+			expectedOk:     true,
+		},
+		{
+			description:    "GitLab blob",
+			inputURL:       "https://gitlab.com/libtiff/libtiff/-/blob/master/ChangeLog",
+			expectedRawURL: "https://gitlab.com/libtiff/libtiff/-/raw/master/ChangeLog",
+			expectedOk:     true,
+		},
+		{
+			description: "Not a changelog",
+			inputURL:    "https://github.com/foo/bar/blob/main/README.md",
+			expectedOk:  false,
+		},
+		{
+			description: "Changelog on an unsupported host",
+			inputURL:    "https://www.example.com/CHANGELOG.md",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, gotOk := ChangelogRawURL(tc.inputURL)
+		if got != tc.expectedRawURL || gotOk != tc.expectedOk {
+			t.Errorf("test %q: ChangelogRawURL(%q) was incorrect, got: %q, %t, expected: %q, %t", tc.description, tc.inputURL, got, gotOk, tc.expectedRawURL, tc.expectedOk)
+		}
+	}
+}
+
+func TestExtractChangelogVersion(t *testing.T) {
+	tests := []struct {
+		description        string
+		inputChangelog     string
+		inputValidVersions []string
+		expectedVersion    string
+		expectedNotes      []string
+		expectedOk         bool
+	}{
+		{
+			description: "Keep a Changelog",
+			inputChangelog: `# Changelog
+
+## [1.2.4] - 2023-02-01
+### Fixed
+- Crash on empty input.
+
+## [1.2.3] - 2023-01-01
+### Security
+- Fix heap overflow in the parser (CVE-2023-1234).
+
+## [1.2.2] - 2022-12-01
+- Initial release.
+`,
+			inputValidVersions: []string{"1.2.2", "1.2.3", "1.2.4"},
+			expectedVersion:    "1.2.3",
+			expectedOk:         true,
+		},
+		{
+			description: "NEWS file",
+			inputChangelog: `Version 2.0.1 (2023-01-01)
+  * Fix for cve-2023-1234, a heap overflow.
+
+Version 2.0.0 (2022-12-01)
+  * New API.
+`,
+			expectedVersion: "2.0.1",
+			expectedOk:      true,
+		},
+		{
+			description: "Unknown version",
+			inputChangelog: `v3.1.0:
+  - CVE-2023-1234: heap overflow.
+`,
+			inputValidVersions: []string{"3.0.0"},
+			expectedVersion:    "3.1.0",
+			expectedNotes:      []string{"Extracted version 3.1.0 is not a valid version"},
+			expectedOk:         true,
+		},
+		{
+			description:    "CVE not mentioned",
+			inputChangelog: "## 1.2.3\n- Fix heap overflow.\n",
+			expectedNotes:  []string{"CVE-2023-1234 is not mentioned in the changelog"},
+			expectedOk:     false,
+		},
+		{
+			description:    "CVE not under a release",
+			inputChangelog: "Unreleased\n- Fix CVE-2023-1234.\n",
+			expectedNotes:  []string{"CVE-2023-1234 is not mentioned under a release in the changelog"},
+			expectedOk:     false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, gotNotes, gotOk := ExtractChangelogVersion(tc.inputChangelog, "CVE-2023-1234", tc.inputValidVersions)
+		if got != tc.expectedVersion || gotOk != tc.expectedOk {
+			t.Errorf("test %q: ExtractChangelogVersion() was incorrect, got: %q, %t, expected: %q, %t", tc.description, got, gotOk, tc.expectedVersion, tc.expectedOk)
+		}
+		if diff := cmp.Diff(tc.expectedNotes, gotNotes); diff != "" {
+			t.Errorf("test %q: ExtractChangelogVersion() notes were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoChangelogFetcher(t *testing.T) {
+	fetcher := func(u string) (string, error) {
+		if u == "https://raw.githubusercontent.com/foo/bar/main/CHANGELOG.md" {
+			return "## 1.2.3\n- Fix CVE-2023-1234.\n", nil
+		}
+		return "", errors.New("not found")
+	}
+	tests := []struct {
+		description      string
+		inputCVEJSON     string
+		expectedVersions []AffectedVersion
+	}{
+		{
+			description: "Changelog mentioning the CVE",
+			inputCVEJSON: `{"cve": {"CVE_data_meta": {"ID": "CVE-2023-1234"}, "references": {"reference_data": [
+				{"url": "https://github.com/foo/bar/blob/main/CHANGELOG.md"}]}}}`,
+			expectedVersions: []AffectedVersion{{Fixed: "1.2.3", Source: SourceChangelog}},
+		},
+		{
+			description: "Unavailable changelog falls back to the description",
+			inputCVEJSON: `{"cve": {"CVE_data_meta": {"ID": "CVE-2023-1234"}, "references": {"reference_data": [
+				{"url": "https://github.com/foo/baz/blob/main/CHANGELOG.md"}]},
+				"description": {"description_data": [{"lang": "en", "value": "Foo before 1.2.3 has a heap overflow."}]}}}`,
+			expectedVersions: []AffectedVersion{{Fixed: "1.2.3", Source: SourceDescription}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfoWithOptions(cveItemFromJSON(t, tc.inputCVEJSON), nil, ExtractOptions{ChangelogFetcher: fetcher})
+		if diff := cmp.Diff(tc.expectedVersions, gotVersionInfo.AffectedVersions); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/knqyf263/go-cpe/naming"
+)
+
+type CPE struct {
+	CPEVersion string
+	Part       string
+	Vendor     string
+	Product    string
+	Version    string
+	Update     string
+	Edition    string
+	Language   string
+	SWEdition  string
+	TargetSW   string
+	TargetHW   string
+	Other      string
+}
+
+// IsApplication reports whether the CPE describes an application (part "a"),
+// as opposed to an operating system (part "o") or hardware (part "h").
+func (c *CPE) IsApplication() bool {
+	return c.Part == "a"
+}
+
+// DictionaryKey returns the identity of the product the CPE describes, as "part:vendor:product"
+// in lowercase, e.g. "a:apache:http_server", ignoring the version and other attributes. CPEs of
+// the same product share a key, whatever their version. Colons in the vendor or product are quoted.
+func (c *CPE) DictionaryKey() string {
+	var fields []string
+	for _, field := range []string{c.Part, c.Vendor, c.Product} {
+		fields = append(fields, strings.ReplaceAll(strings.ToLower(field), ":", "\\:"))
+	}
+	return strings.Join(fields, ":")
+}
+
+// configurationNodes returns the configuration nodes of cve, from the legacy configurations
+// or, in their absence, the cpeApplicability.
+func configurationNodes(cve CVEItem) []Node {
+	if len(cve.Configurations.Nodes) > 0 {
+		return cve.Configurations.Nodes
+	}
+	var nodes []Node
+	for _, applicability := range cve.CPEApplicability {
+		nodes = append(nodes, applicability.LegacyNodes()...)
+	}
+	return nodes
+}
+
+// CPEs returns the CPEs of cve's configurations, in order. CPEs that are the same once
+// normalized by NormalizeCPE are only returned once, as first seen.
+func CPEs(cve CVEItem) []string {
+	var cpes []string
+	seen := make(map[string]bool)
+	for _, node := range configurationNodes(cve) {
+		for _, match := range node.CPEMatch {
+			normalized := NormalizeCPE(match.CPE23URI)
+			if seen[normalized] {
+				continue
+			}
+			seen[normalized] = true
+			cpes = append(cpes, match.CPE23URI)
+		}
+	}
+
+	return cpes
+}
+
+// NormalizeCPE returns a canonical form of a formatted string CPE, so that CPEs differing only
+// in case or in the (unnecessary) quoting of characters that needn't be quoted, e.g. 1\.2 and 1.2,
+// compare as equal. Necessary quoting, e.g. of a literal \: or \*, is retained.
+func NormalizeCPE(s string) string {
+	var normalized strings.Builder
+	escaped := false
+	for _, r := range strings.ToLower(s) {
+		if escaped {
+			escaped = false
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_') {
+				normalized.WriteRune(r)
+				continue
+			}
+			normalized.WriteRune('\\')
+		} else if r == '\\' {
+			escaped = true
+			continue
+		}
+		normalized.WriteRune(r)
+	}
+	if escaped {
+		normalized.WriteRune('\\')
+	}
+	return normalized.String()
+}
+
+// There are some weird and wonderful rules about quoting with strings in CPEs
+// See 5.3.2 of NISTIR 7695 for more details
+// https://nvlpubs.nist.gov/nistpubs/Legacy/IR/nistir7695.pdf
+func RemoveQuoting(s string) (result string) {
+	return strings.Replace(s, "\\", "", -1)
+}
+
+// UnquoteWFN faithfully removes the quoting from a WFN attribute value, replacing each
+// backslash-escaped character with the character itself, e.g. foo\\bar becomes foo\bar
+// (whereas RemoveQuoting would drop the backslash entirely).
+func UnquoteWFN(s string) string {
+	var unquoted strings.Builder
+	escaped := false
+	for _, r := range s {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		unquoted.WriteRune(r)
+	}
+	return unquoted.String()
+}
+
+// Parse a well-formed CPE string into a struct.
+func ParseCPE(formattedString string) (*CPE, error) {
+	return parseCPE(formattedString, RemoveQuoting)
+}
+
+// ParseCPERaw parses a well-formed CPE string into a struct like ParseCPE, but retains the WFN quoting
+// of the vendor, product and version, e.g. c\+\+_lib, so that escaped special characters can be told
+// apart from unescaped ones (e.g. a literal \* from the * wildcard). Use UnquoteWFN to unquote them.
+func ParseCPERaw(formattedString string) (*CPE, error) {
+	return parseCPE(formattedString, func(s string) string { return s })
+}
+
+func parseCPE(formattedString string, unquote func(string) string) (*CPE, error) {
+	if !strings.HasPrefix(formattedString, "cpe:") {
+		return nil, fmt.Errorf("%q does not have expected 'cpe:' prefix", formattedString)
+	}
+
+	wfn, err := naming.UnbindFS(formattedString)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &CPE{
+		CPEVersion: strings.Split(formattedString, ":")[1],
+		Part:       wfn.GetString("part"),
+		Vendor:     unquote(wfn.GetString("vendor")),
+		Product:    unquote(wfn.GetString("product")),
+		Version:    unquote(wfn.GetString("version")),
+		Update:     wfn.GetString("update"),
+		Edition:    wfn.GetString("edition"),
+		Language:   wfn.GetString("language"),
+		SWEdition:  wfn.GetString("sw_edition"),
+		TargetSW:   wfn.GetString("target_sw"),
+		TargetHW:   wfn.GetString("target_hw"),
+		Other:      wfn.GetString("other")}, nil
+}
+
+// cpeTargetSW returns the target software of cpe, lowercased and unquoted,
+// or "" when it is ANY or NA.
+func cpeTargetSW(cpe *CPE) string {
+	switch cpe.TargetSW {
+	case "", "ANY", "NA", "*", "-":
+		return ""
+	}
+	return strings.ToLower(UnquoteWFN(cpe.TargetSW))
+}
+
+// cpeEdition returns the edition, sw_edition and other attributes of cpe that aren't ANY or NA,
+// lowercased, unquoted and joined by ":", or "" when there are none.
+func cpeEdition(cpe *CPE) string {
+	var edition []string
+	for _, attribute := range []string{cpe.Edition, cpe.SWEdition, cpe.Other} {
+		switch attribute {
+		case "", "ANY", "NA", "*", "-":
+			continue
+		}
+		edition = append(edition, strings.ToLower(UnquoteWFN(attribute)))
+	}
+	return strings.Join(edition, ":")
+}
+
+// cpeAttributeMatches reports whether a CPE attribute value matches want, following the
+// NISTIR 7695 name matching rules for the logical values ANY ("*") and NA ("-"):
+// ANY matches any value, whereas NA only matches NA (or ANY).
+func cpeAttributeMatches(value, want string) bool {
+	logicalValue := func(s string) string {
+		switch s {
+		case "*", "":
+			return "ANY"
+		case "-":
+			return "NA"
+		}
+		return s
+	}
+	value, want = logicalValue(value), logicalValue(want)
+	if value == "ANY" || want == "ANY" {
+		return true
+	}
+	if value == "NA" || want == "NA" {
+		return value == want
+	}
+	return strings.EqualFold(value, want)
+}
+
+// CPEMatchesProduct reports whether the vendor and product of cpe match vendor and product,
+// treating "*" (ANY) as a wildcard and "-" (NA) as not applicable on either side.
+func CPEMatchesProduct(cpe *CPE, vendor, product string) bool {
+	if cpe == nil {
+		return false
+	}
+	return cpeAttributeMatches(cpe.Vendor, vendor) && cpeAttributeMatches(cpe.Product, product)
+}
+
+// CPE target_sw values that identify an OSV ecosystem.
+var targetSWEcosystems = map[string]string{
+	"android":    "Android",
+	"dart":       "Pub",
+	"elixir":     "Hex",
+	"erlang":     "Hex",
+	"go":         "Go",
+	"golang":     "Go",
+	"haskell":    "Hackage",
+	"jenkins":    "Maven",
+	"maven":      "Maven",
+	".net":       "NuGet",
+	"nuget":      "NuGet",
+	"node.js":    "npm",
+	"nodejs":     "npm",
+	"npm":        "npm",
+	"packagist":  "Packagist",
+	"pip":        "PyPI",
+	"pypi":       "PyPI",
+	"python":     "PyPI",
+	"r":          "CRAN",
+	"ruby":       "RubyGems",
+	"rubygems":   "RubyGems",
+	"rust":       "crates.io",
+	"rust_crate": "crates.io",
+	"swift":      "SwiftURL",
+}
+
+// CPE vendor/product pairs of operating systems that identify an OSV ecosystem.
+var osEcosystems = map[string]string{
+	"alpinelinux/alpine_linux": "Alpine",
+	"canonical/ubuntu_linux":   "Ubuntu",
+	"debian/debian_linux":      "Debian",
+	"google/android":           "Android",
+	"linux/linux_kernel":       "Linux",
+	"redhat/enterprise_linux":  "Red Hat",
+}
+
+// EcosystemFromCPE infers the OSV ecosystem of the software a CPE describes,
+// from its target software or, for operating systems, its vendor and product.
+// Returns false if the ecosystem can't be determined.
+func EcosystemFromCPE(cpe *CPE) (string, bool) {
+	if cpe == nil {
+		return "", false
+	}
+	if ecosystem, ok := targetSWEcosystems[strings.ToLower(RemoveQuoting(cpe.TargetSW))]; ok {
+		return ecosystem, true
+	}
+	if cpe.Part == "o" {
+		if ecosystem, ok := osEcosystems[strings.ToLower(cpe.Vendor+"/"+cpe.Product)]; ok {
+			return ecosystem, true
+		}
+	}
+	return "", false
+}
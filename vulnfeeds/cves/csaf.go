@@ -0,0 +1,155 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// IsCSAFURL reports whether u references a CSAF (or CSAF VEX) JSON document, as published by
+// e.g. Red Hat and SUSE, e.g.
+// https://access.redhat.com/security/data/csaf/v2/vex/2023/cve-2023-0286.json
+// https://ftp.suse.com/pub/projects/security/csaf-vex/cve-2023-0286.json
+func IsCSAFURL(u string) bool {
+	parsedURL, err := url.Parse(u)
+	if err != nil || !strings.HasSuffix(strings.ToLower(parsedURL.Path), ".json") {
+		return false
+	}
+	return slices.IndexFunc(strings.Split(strings.ToLower(parsedURL.Path), "/"), func(segment string) bool {
+		return segment == "csaf" || strings.HasPrefix(segment, "csaf-")
+	}) != -1
+}
+
+// csafDocument is the subset of a CSAF 2.0 document needed to extract affected versions.
+type csafDocument struct {
+	ProductTree struct {
+		Branches      []csafBranch `json:"branches"`
+		Relationships []struct {
+			ProductReference string      `json:"product_reference"`
+			FullProductName  csafProduct `json:"full_product_name"`
+		} `json:"relationships"`
+	} `json:"product_tree"`
+	Vulnerabilities []struct {
+		CVE           string              `json:"cve"`
+		ProductStatus map[string][]string `json:"product_status"`
+	} `json:"vulnerabilities"`
+}
+
+type csafBranch struct {
+	Category string       `json:"category"`
+	Name     string       `json:"name"`
+	Product  *csafProduct `json:"product"`
+	Branches []csafBranch `json:"branches"`
+}
+
+type csafProduct struct {
+	Name      string `json:"name"`
+	ProductID string `json:"product_id"`
+}
+
+// csafVersions maps the product IDs of the product_version and product_version_range branches
+// of branches to the branch's category and name.
+func csafVersions(branches []csafBranch, versions map[string]csafBranch) {
+	for _, branch := range branches {
+		if branch.Product != nil && (branch.Category == "product_version" || branch.Category == "product_version_range") {
+			versions[branch.Product.ProductID] = branch
+		}
+		csafVersions(branch.Branches, versions)
+	}
+}
+
+// ExtractCSAFVersions extracts the affected versions of cveID from the product_status of a CSAF document.
+// Products with a product_version are fixed in, first or last affected in, or (as the only affected version)
+// affected in that version, according to their status. Known affected products with a product_version_range
+// (a vers, e.g. "vers:generic/>=1.0|<1.2", or constraint expression) are affected in that range.
+// Products without a version are skipped.
+func ExtractCSAFVersions(content []byte, cveID string, validVersions []string) ([]AffectedVersion, []string, error) {
+	var document csafDocument
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, nil, err
+	}
+	productVersions := make(map[string]csafBranch)
+	csafVersions(document.ProductTree.Branches, productVersions)
+	// Products combined with a platform (e.g. a package in a distribution release) are versioned as the product.
+	for _, relationship := range document.ProductTree.Relationships {
+		if version, ok := productVersions[relationship.ProductReference]; ok {
+			productVersions[relationship.FullProductName.ProductID] = version
+		}
+	}
+
+	var notes []string
+	var versions []AffectedVersion
+	add := func(version AffectedVersion) {
+		for _, v := range []string{version.Introduced, version.Fixed, version.LastAffected} {
+			if v != "" && !hasVersion(validVersions, v) {
+				notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", v))
+			}
+		}
+		if !containsAffectedVersion(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+	foundCVE := false
+	for _, vulnerability := range document.Vulnerabilities {
+		if !strings.EqualFold(vulnerability.CVE, cveID) {
+			continue
+		}
+		foundCVE = true
+		for _, status := range []string{"first_affected", "known_affected", "last_affected", "first_fixed", "fixed"} {
+			for _, productID := range vulnerability.ProductStatus[status] {
+				branch, ok := productVersions[productID]
+				if !ok {
+					continue
+				}
+				if branch.Category == "product_version_range" {
+					if status != "known_affected" {
+						continue
+					}
+					constraint := branch.Name
+					if strings.HasPrefix(constraint, "vers:") {
+						_, constraint, _ = strings.Cut(constraint, "/")
+						constraint = strings.ReplaceAll(constraint, "|", ",")
+					}
+					if version, ok := parseVersionConstraint(constraint); ok {
+						add(version)
+					} else {
+						notes = append(notes, fmt.Sprintf("Unable to parse the version range %q of %s", branch.Name, productID))
+					}
+					continue
+				}
+				version := CleanVersion(branch.Name)
+				switch status {
+				case "first_affected":
+					add(AffectedVersion{Introduced: version})
+				case "known_affected":
+					add(AffectedVersion{Introduced: version, LastAffected: version})
+				case "last_affected":
+					add(AffectedVersion{LastAffected: version})
+				default:
+					add(AffectedVersion{Fixed: version})
+				}
+			}
+		}
+	}
+	if !foundCVE {
+		notes = append(notes, fmt.Sprintf("%s is not among the vulnerabilities of the CSAF document", cveID))
+	}
+	return versions, notes, nil
+}
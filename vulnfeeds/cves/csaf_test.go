@@ -0,0 +1,133 @@
+package cves
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// A CSAF VEX document in the style of Red Hat's, trimmed to the parts ExtractCSAFVersions reads.
+const testCSAFDocument = `{
+	"product_tree": {
+		"branches": [{"category": "vendor", "name": "Example", "branches": [
+			{"category": "product_name", "name": "foo", "branches": [
+				{"category": "product_version", "name": "1.2.3", "product": {"name": "foo 1.2.3", "product_id": "foo-1.2.3"}},
+				{"category": "product_version", "name": "1.1.0", "product": {"name": "foo 1.1.0", "product_id": "foo-1.1.0"}},
+				{"category": "product_version_range", "name": "vers:generic/>=2.0|<2.1.4", "product": {"name": "foo 2.x", "product_id": "foo-2"}}
+			]},
+			{"category": "product_name", "name": "Example Linux 9", "product": {"name": "Example Linux 9", "product_id": "EL9"}}
+		]}],
+		"relationships": [
+			{"category": "default_component_of", "product_reference": "foo-1.2.3", "relates_to_product_reference": "EL9",
+			 "full_product_name": {"name": "foo 1.2.3 as a component of Example Linux 9", "product_id": "EL9:foo-1.2.3"}}
+		]
+	},
+	"vulnerabilities": [
+		{"cve": "CVE-2023-0001", "product_status": {"fixed": ["EL9:foo-1.2.3"], "first_affected": ["foo-1.1.0"], "known_affected": ["foo-2", "EL9"]}},
+		{"cve": "CVE-2023-0002", "product_status": {"fixed": ["foo-1.1.0"]}}
+	]
+}`
+
+func TestIsCSAFURL(t *testing.T) {
+	tests := []struct {
+		description string
+		inputURL    string
+		expectedOk  bool
+	}{
+		{
+			description: "Red Hat VEX",
+			inputURL:    "https://access.redhat.com/security/data/csaf/v2/vex/2023/cve-2023-0286.json",
+			expectedOk:  true,
+		},
+		{
+			description: "SUSE VEX",
+			inputURL:    "https://ftp.suse.com/pub/projects/security/csaf-vex/cve-2023-0286.json",
+			expectedOk:  true,
+		},
+		{
+			description: "CSAF advisory in HTML",
+			inputURL:    "https://access.redhat.com/security/data/csaf/v2/vex/2023/cve-2023-0286.html",
+			expectedOk:  false,
+		},
+		{
+			description: "Unrelated JSON",
+			inputURL:    "https://gitlab.com/gitlab-org/cves/-/blob/master/2022/CVE-2022-2501.json",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		if got := IsCSAFURL(tc.inputURL); got != tc.expectedOk {
+			t.Errorf("test %q: IsCSAFURL(%q) was incorrect, got: %t, expected: %t", tc.description, tc.inputURL, got, tc.expectedOk)
+		}
+	}
+}
+
+func TestExtractCSAFVersions(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputCVEID       string
+		expectedVersions []AffectedVersion
+		expectedNotes    []string
+	}{
+		{
+			description: "Fixed through a relationship, first affected, and a known affected range",
+			inputCVEID:  "CVE-2023-0001",
+			expectedVersions: []AffectedVersion{
+				{Introduced: "1.1.0"},
+				{Introduced: "2.0", Fixed: "2.1.4"},
+				{Fixed: "1.2.3"},
+			},
+		},
+		{
+			description:      "Another vulnerability of the document",
+			inputCVEID:       "CVE-2023-0002",
+			expectedVersions: []AffectedVersion{{Fixed: "1.1.0"}},
+		},
+		{
+			description:   "CVE not in the document",
+			inputCVEID:    "CVE-2023-0003",
+			expectedNotes: []string{"CVE-2023-0003 is not among the vulnerabilities of the CSAF document"},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersions, gotNotes, err := ExtractCSAFVersions([]byte(testCSAFDocument), tc.inputCVEID, nil)
+		if err != nil {
+			t.Fatalf("test %q: ExtractCSAFVersions() unexpectedly failed: %v", tc.description, err)
+		}
+		if diff := cmp.Diff(tc.expectedVersions, gotVersions); diff != "" {
+			t.Errorf("test %q: ExtractCSAFVersions() was incorrect: %s", tc.description, diff)
+		}
+		if diff := cmp.Diff(tc.expectedNotes, gotNotes); diff != "" {
+			t.Errorf("test %q: ExtractCSAFVersions() notes were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoCSAFFetcher(t *testing.T) {
+	fetcher := func(u string) (string, error) {
+		if u == "https://access.redhat.com/security/data/csaf/v2/vex/2023/cve-2023-0002.json" {
+			return testCSAFDocument, nil
+		}
+		return "", errors.New("not found")
+	}
+	cve := cveItemFromJSON(t, `{"cve": {"CVE_data_meta": {"ID": "CVE-2023-0002"}, "references": {"reference_data": [
+		{"url": "https://access.redhat.com/security/data/csaf/v2/vex/2023/cve-2023-0002.json"}]}},
+		"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+			{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:example:foo:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndExcluding": "1.0.5"}]}]}}`)
+	expectedVersions := []AffectedVersion{
+		{Introduced: "1.0", Fixed: "1.0.5", Source: SourceCPE},
+		{Fixed: "1.1.0", Source: SourceCSAF},
+	}
+
+	var diag ExtractDiagnostics
+	gotVersionInfo, _ := ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{CSAFFetcher: fetcher, Diagnostics: &diag})
+	if diff := cmp.Diff(expectedVersions, gotVersionInfo.AffectedVersions); diff != "" {
+		t.Errorf("AffectedVersions were incorrect: %s", diff)
+	}
+	if diag.CSAFVersions != 1 {
+		t.Errorf("CSAFVersions was incorrect, got: %d, expected: 1", diag.CSAFVersions)
+	}
+}
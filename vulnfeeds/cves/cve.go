@@ -47,20 +47,138 @@ type CVEReferences struct {
 	ReferenceData []CVEReferenceData `json:"reference_data"`
 }
 
+type CPEMatch struct {
+	Vulnerable            bool   `json:"vulnerable"`
+	CPE23URI              string `json:"cpe23Uri"`
+	VersionStartExcluding string `json:"versionStartExcluding"`
+	VersionStartIncluding string `json:"versionStartIncluding"`
+	VersionEndExcluding   string `json:"versionEndExcluding"`
+	VersionEndIncluding   string `json:"versionEndIncluding"`
+}
+
+// Node is a configuration node, combining its CPE matches (or those of its children) by Operator.
+type Node struct {
+	Operator string     `json:"operator"`
+	Children []Node     `json:"children,omitempty"`
+	CPEMatch []CPEMatch `json:"cpe_match"`
+}
+
+// CPEApplicabilityMatch is a CPE match of the CVE JSON 5.x cpeApplicability and NVD 2.0 API
+// configurations, which names the CPE "criteria" rather than "cpe23Uri".
+type CPEApplicabilityMatch struct {
+	Vulnerable            bool   `json:"vulnerable"`
+	Criteria              string `json:"criteria"`
+	VersionStartExcluding string `json:"versionStartExcluding,omitempty"`
+	VersionStartIncluding string `json:"versionStartIncluding,omitempty"`
+	VersionEndExcluding   string `json:"versionEndExcluding,omitempty"`
+	VersionEndIncluding   string `json:"versionEndIncluding,omitempty"`
+}
+
+// CPEApplicabilityNode combines its CPE matches by Operator, with Negate inverting the result.
+type CPEApplicabilityNode struct {
+	Operator string                  `json:"operator"`
+	Negate   bool                    `json:"negate,omitempty"`
+	CPEMatch []CPEApplicabilityMatch `json:"cpeMatch"`
+}
+
+// CPEApplicability is a configuration of the CVE JSON 5.x cpeApplicability and NVD 2.0 API, combining
+// its nodes by Operator (absent when there is a single node). Unlike the legacy configuration nodes,
+// nodes aren't nested.
+type CPEApplicability struct {
+	Operator string                 `json:"operator,omitempty"`
+	Negate   bool                   `json:"negate,omitempty"`
+	Nodes    []CPEApplicabilityNode `json:"nodes"`
+}
+
+// LegacyNodes returns the equivalent of c as legacy configuration nodes.
+// Negated nodes can't be represented, and are omitted.
+func (c CPEApplicability) LegacyNodes() []Node {
+	var nodes []Node
+	for _, node := range c.Nodes {
+		if node.Negate {
+			continue
+		}
+		legacyNode := Node{Operator: node.Operator}
+		for _, match := range node.CPEMatch {
+			legacyNode.CPEMatch = append(legacyNode.CPEMatch, CPEMatch{
+				Vulnerable:            match.Vulnerable,
+				CPE23URI:              match.Criteria,
+				VersionStartExcluding: match.VersionStartExcluding,
+				VersionStartIncluding: match.VersionStartIncluding,
+				VersionEndExcluding:   match.VersionEndExcluding,
+				VersionEndIncluding:   match.VersionEndIncluding,
+			})
+		}
+		nodes = append(nodes, legacyNode)
+	}
+	if c.Negate || len(nodes) == 0 {
+		return nil
+	}
+	// Nodes combined with AND become the children of an AND node, as in the legacy configurations.
+	if c.Operator == "AND" {
+		return []Node{{Operator: "AND", Children: nodes}}
+	}
+	return nodes
+}
+
+// CVE5Version is an entry of the versions array of a CVE JSON 5.x affected product.
+// See https://github.com/CVEProject/cve-schema/blob/master/schema/v5.0/CVE_JSON_5.0_schema.json
+type CVE5Version struct {
+	Version         string `json:"version"`
+	Status          string `json:"status"`
+	VersionType     string `json:"versionType,omitempty"`
+	LessThan        string `json:"lessThan,omitempty"`
+	LessThanOrEqual string `json:"lessThanOrEqual,omitempty"`
+}
+
+// CVE5Affected is an affected product of a CVE JSON 5.x record.
+type CVE5Affected struct {
+	Vendor        string        `json:"vendor"`
+	Product       string        `json:"product"`
+	DefaultStatus string        `json:"defaultStatus,omitempty"`
+	Versions      []CVE5Version `json:"versions"`
+}
+
+// CVE5 is the subset of a CVE JSON 5.x record of interest.
+type CVE5 struct {
+	Containers struct {
+		CNA struct {
+			Affected []CVE5Affected `json:"affected"`
+		} `json:"cna"`
+	} `json:"containers"`
+}
+
+// CVE4Affects is the affects section of a CVE JSON 4.0 record, as still used by e.g. GitLab's advisories.
+// Version values are constraint expressions, e.g. ">=15.0, <15.0.5".
+type CVE4Affects struct {
+	Affects struct {
+		Vendor struct {
+			VendorData []struct {
+				VendorName string `json:"vendor_name"`
+				Product    struct {
+					ProductData []struct {
+						ProductName string `json:"product_name"`
+						Version     struct {
+							VersionData []struct {
+								VersionValue string `json:"version_value"`
+							} `json:"version_data"`
+						} `json:"version"`
+					} `json:"product_data"`
+				} `json:"product"`
+			} `json:"vendor_data"`
+		} `json:"vendor"`
+	} `json:"affects"`
+}
+
 type CVEItem struct {
-	CVE            CVE `json:"cve"`
-	Configurations struct {
-		Nodes []struct {
-			Operator string `json:"operator"`
-			CPEMatch []struct {
-				Vulnerable            bool   `json:"vulnerable"`
-				CPE23URI              string `json:"cpe23Uri"`
-				VersionStartExcluding string `json:"versionStartExcluding"`
-				VersionStartIncluding string `json:"versionStartIncluding"`
-				VersionEndExcluding   string `json:"versionEndExcluding"`
-				VersionEndIncluding   string `json:"versionEndIncluding"`
-			} `json:"cpe_match"`
-		} `json:"nodes"`
+	CVE CVE `json:"cve"`
+	// Populated from containers.cna.affected when the CVE JSON 5.x record is available.
+	Affected []CVE5Affected `json:"affected,omitempty"`
+	// Populated from containers.cna.cpeApplicability (or the configurations of the NVD 2.0 API)
+	// when available, and used in the absence of Configurations.Nodes.
+	CPEApplicability []CPEApplicability `json:"cpeApplicability,omitempty"`
+	Configurations   struct {
+		Nodes []Node `json:"nodes"`
 	} `json:"configurations"`
 	Impact struct {
 		BaseMetricV3 struct {
@@ -71,6 +189,26 @@ type CVEItem struct {
 	} `json:"impact"`
 	PublishedDate    string `json:"publishedDate"`
 	LastModifiedDate string `json:"lastModifiedDate"`
+	// Populated from the vulnStatus of the NVD 2.0 API, e.g. "Analyzed" or "Awaiting Analysis".
+	VulnStatus string `json:"vulnStatus,omitempty"`
+}
+
+// The NVD 2.0 API vulnStatus values of CVEs that NVD hasn't (yet) analyzed, or has rejected.
+const (
+	VulnStatusReceived           = "Received"
+	VulnStatusAwaitingAnalysis   = "Awaiting Analysis"
+	VulnStatusUndergoingAnalysis = "Undergoing Analysis"
+	VulnStatusRejected           = "Rejected"
+)
+
+// Unanalyzed reports whether the vulnStatus of cve says that it's yet to be analyzed by NVD,
+// or has been rejected. An empty status, as of the legacy feeds, isn't considered unanalyzed.
+func (cve CVEItem) Unanalyzed() bool {
+	switch cve.VulnStatus {
+	case VulnStatusReceived, VulnStatusAwaitingAnalysis, VulnStatusUndergoingAnalysis, VulnStatusRejected:
+		return true
+	}
+	return false
 }
 
 type NVDCVE struct {
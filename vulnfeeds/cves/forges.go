@@ -0,0 +1,86 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Hosts known to run Gitea (or its fork Forgejo).
+var giteaHosts = []string{"codeberg.org", "gitea.com", "try.gitea.io"}
+
+// isGiteaHost reports whether hostname is known to run Gitea.
+func isGiteaHost(hostname string) bool {
+	return slices.Contains(giteaHosts, hostname)
+}
+
+// Forge is the software a self-hosted repository host runs.
+type Forge string
+
+const (
+	ForgeGitea  Forge = "gitea" // Gitea, or its fork Forgejo.
+	ForgeGitLab Forge = "gitlab"
+	ForgeCGit   Forge = "cgit"
+)
+
+// ForgeProber detects the Forge a host runs (e.g. from its API endpoints or the meta tags of its
+// pages), returning "" if it runs none that is recognized.
+type ForgeProber func(hostname string) (Forge, error)
+
+// Forges records the Forge run by self-hosted repository hosts that aren't otherwise known, keyed by
+// (lowercase) hostname, e.g. as detected by a ForgeProber.
+type Forges map[string]Forge
+
+// isGitea reports whether hostname is known, or recorded in f, to run Gitea.
+func (f Forges) isGitea(hostname string) bool {
+	return isGiteaHost(hostname) || f[strings.ToLower(hostname)] == ForgeGitea
+}
+
+// isGitLab reports whether hostname is one of GitLabHosts, or recorded in f to run GitLab.
+func (f Forges) isGitLab(hostname string) bool {
+	return IsGitLabHost(hostname) || f[strings.ToLower(hostname)] == ForgeGitLab
+}
+
+// isCGit reports whether u is for a page served by cGit, including by a host recorded in f to run it.
+func (f Forges) isCGit(u *url.URL) bool {
+	return isCGit(u) || f[strings.ToLower(u.Hostname())] == ForgeCGit
+}
+
+// probe detects the forge run by the host of u with prober, and records it in f (as "" if it runs
+// none that is recognized), unless the host is already known or recorded. Returns a note if the
+// host couldn't be probed.
+func (f Forges) probe(u string, prober ForgeProber) []string {
+	parsedURL, err := url.Parse(withScheme(scpToHTTPS(u)))
+	if err != nil || parsedURL.Hostname() == "" {
+		return nil
+	}
+	hostname := strings.ToLower(parsedURL.Hostname())
+	if isRepoHost(hostname) || slices.Contains(cgitHosts, hostname) {
+		return nil
+	}
+	if _, ok := f[hostname]; ok {
+		return nil
+	}
+	forge, err := prober(hostname)
+	f[hostname] = forge
+	if err != nil {
+		return []string{fmt.Sprintf("Unable to detect the forge run by %s: %v", hostname, err)}
+	}
+	return nil
+}
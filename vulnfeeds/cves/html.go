@@ -0,0 +1,94 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ExtractVersionsFromHTML scrapes an HTML page (e.g. a vendor security bulletin) for versions labelled as
+// affected, introduced or fixed, found in tables (labelled by their column or row headers), definition
+// lists, or paragraphs and list items such as "<strong>Fixed in:</strong> 1.2.4".
+// The labelled versions are interpreted as by extractVersionsFromReferenceContent, and
+// normalized and checked against validVersions as versions extracted from descriptions are.
+func ExtractVersionsFromHTML(r io.Reader, validVersions []string) ([]AffectedVersion, []string, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	cellText := func(s *goquery.Selection) string {
+		return strings.Join(strings.Fields(s.Text()), " ")
+	}
+
+	var lines []string
+	doc.Find("table").Each(func(_ int, table *goquery.Selection) {
+		var headers []string
+		table.Find("tr").Each(func(_ int, row *goquery.Selection) {
+			cells := row.Find("th, td")
+			// A row of headers labels the columns of the following rows.
+			if cells.Length() > 0 && row.Find("th").Length() == cells.Length() {
+				headers = cells.Map(func(_ int, cell *goquery.Selection) string { return cellText(cell) })
+				return
+			}
+			// A row starting with a header is a labelled field, e.g. "<th>Fixed in</th><td>1.2.4</td>".
+			if cells.First().Is("th") {
+				lines = append(lines, cellText(cells.First())+": "+cellText(cells.Slice(1, goquery.ToEnd)))
+				return
+			}
+			cells.Each(func(i int, cell *goquery.Selection) {
+				if i < len(headers) {
+					lines = append(lines, headers[i]+": "+cellText(cell))
+				}
+			})
+		})
+	})
+	doc.Find("dt").Each(func(_ int, term *goquery.Selection) {
+		lines = append(lines, cellText(term)+": "+cellText(term.NextFiltered("dd")))
+	})
+	doc.Find("p, li").Each(func(_ int, field *goquery.Selection) {
+		lines = append(lines, cellText(field))
+	})
+
+	versions, notes := extractVersionsFromReferenceContent(strings.Join(lines, "\n"))
+	var validated []AffectedVersion
+	for _, version := range versions {
+		version.Introduced = ProcessExtractedVersion(version.Introduced)
+		version.Fixed = ProcessExtractedVersion(version.Fixed)
+		version.LastAffected = ProcessExtractedVersion(version.LastAffected)
+		if version.SameRange(AffectedVersion{}) || containsAffectedVersion(validated, version) {
+			continue
+		}
+		for _, extracted := range []string{version.Introduced, version.Fixed, version.LastAffected} {
+			if extracted != "" && !hasVersion(validVersions, extracted) {
+				notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", extracted))
+			}
+		}
+		validated = append(validated, version)
+	}
+	return validated, notes, nil
+}
+
+// looksLikeHTML reports whether the content of a reference is an HTML page rather than markdown or plain text.
+func looksLikeHTML(content string) bool {
+	start := strings.ToLower(strings.TrimSpace(content))
+	if len(start) > 512 {
+		start = start[:512]
+	}
+	return strings.HasPrefix(start, "<!doctype html") || strings.Contains(start, "<html")
+}
@@ -0,0 +1,87 @@
+package cves
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtractVersionsFromHTML(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputHTML        string
+		expectedVersions []AffectedVersion
+		expectedNotes    []string
+	}{
+		{
+			description: "Table with column headers",
+			inputHTML: `<html><body><table>
+				<tr><th>Product</th><th>Affected versions</th><th>Fixed version</th></tr>
+				<tr><td>Widget Server</td><td>&lt; 4.2.1</td><td>4.2.1</td></tr>
+			</table></body></html>`,
+			expectedVersions: []AffectedVersion{{Fixed: "4.2.1"}},
+		},
+		{
+			description: "Table with row headers",
+			inputHTML: `<html><body><table>
+				<tr><th>Introduced in</th><td>3.0</td></tr>
+				<tr><th>Fixed in</th><td>v3.5.2.</td></tr>
+			</table></body></html>`,
+			expectedVersions: []AffectedVersion{{Introduced: "3.0", Fixed: "3.5.2"}},
+		},
+		{
+			description: "Definition list",
+			inputHTML: `<html><body><dl>
+				<dt>Affected</dt><dd>&gt;= 2.0, &lt;= 2.3.4</dd>
+				<dt>Severity</dt><dd>High</dd>
+			</dl></body></html>`,
+			expectedVersions: []AffectedVersion{{Introduced: "2.0", LastAffected: "2.3.4"}},
+		},
+		{
+			description: "Labelled paragraph not in the valid versions",
+			inputHTML: `<html><body><div class="bulletin">
+				<p><strong>Fixed in:</strong> 1.9.9</p>
+				<p>Upgrading is recommended.</p>
+			</div></body></html>`,
+			expectedVersions: []AffectedVersion{{Fixed: "1.9.9"}},
+			expectedNotes:    []string{"Extracted version 1.9.9 is not a valid version"},
+		},
+		{
+			description: "No labelled versions",
+			inputHTML:   `<html><body><p>Version 1.2.3 is great.</p></body></html>`,
+		},
+	}
+
+	validVersions := []string{"2.0", "2.3.4", "3.0", "3.5.2", "4.2.1"}
+	for _, tc := range tests {
+		gotVersions, gotNotes, err := ExtractVersionsFromHTML(strings.NewReader(tc.inputHTML), validVersions)
+		if err != nil {
+			t.Errorf("test %q: ExtractVersionsFromHTML() unexpectedly failed: %v", tc.description, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.expectedVersions, gotVersions); diff != "" {
+			t.Errorf("test %q: ExtractVersionsFromHTML() versions were incorrect: %s", tc.description, diff)
+		}
+		if diff := cmp.Diff(tc.expectedNotes, gotNotes); diff != "" {
+			t.Errorf("test %q: ExtractVersionsFromHTML() notes were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoScrapeHTMLReferences(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [{"url": "https://example.com/bulletins/2023-01.html"}]}}}`)
+	fetcher := func(url string) (string, error) {
+		return `<!DOCTYPE html><html><body><table><tr><th>Fixed in</th><td>4.2.1</td></tr></table></body></html>`, nil
+	}
+
+	gotVersionInfo, _ := ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{ReferenceFetcher: fetcher, ScrapeHTMLReferences: true})
+	if diff := cmp.Diff([]AffectedVersion{{Fixed: "4.2.1", Source: SourceReferenceContent}}, gotVersionInfo.AffectedVersions); diff != "" {
+		t.Errorf("AffectedVersions with ScrapeHTMLReferences were incorrect: %s", diff)
+	}
+
+	gotVersionInfo, _ = ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{ReferenceFetcher: fetcher})
+	if len(gotVersionInfo.AffectedVersions) != 0 {
+		t.Errorf("AffectedVersions without ScrapeHTMLReferences were unexpectedly found: %+v", gotVersionInfo.AffectedVersions)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+// GitDescription is a version as output by git describe, e.g. "v1.2.3-45-gabcdef1".
+type GitDescription struct {
+	Tag      string // The tag the commit was described relative to, e.g. "v1.2.3".
+	Distance int    // The number of commits since the tag, e.g. 45.
+	Commit   string // The (abbreviated) hash of the commit described, e.g. "abcdef1".
+}
+
+// Matches git describe output: the tag, number of commits since it, and the "g"-prefixed
+// abbreviated commit hash, optionally marked as a dirty working tree.
+var gitDescribePattern = regexp.MustCompile(`^(.+)-(\d+)-g([0-9a-f]{7,40})(?:-dirty)?$`)
+
+// ParseGitDescribe parses a version output by git describe, e.g. "v1.2.3-45-gabcdef1".
+// Returns false if version isn't of that form.
+func ParseGitDescribe(version string) (GitDescription, bool) {
+	match := gitDescribePattern.FindStringSubmatch(version)
+	if match == nil {
+		return GitDescription{}, false
+	}
+	distance, err := strconv.Atoi(match[2])
+	if err != nil {
+		return GitDescription{}, false
+	}
+	return GitDescription{Tag: match[1], Distance: distance, Commit: match[3]}, true
+}
+
+// Normalize version strings found in CVE CPE Match data or Git tags.
+// Use the same logic and behaviour as normalize_tag() osv/bug.py for consistency.
+//
+// Numeric components may be separated by any non-numeric character, so "1_2_3" normalizes the same as "1.2.3".
+// Build metadata following a "+" (e.g. "1.2.3+build5") doesn't distinguish versions, per SemVer, and is stripped.
+// Patch suffixes are kept as a final component: OpenSSL's letter releases ("1.1.1k" normalizes to "1-1-1-k")
+// and FreeBSD's patch levels ("11.2-p3" normalizes to "11-2-p3").
+// Versions output by git describe are normalized as their tag and number of commits since it
+// ("v1.2.3-45-gabcdef1" normalizes to "1-2-3-45"), see ParseGitDescribe.
+//
+// Results are memoized when NormalizeVersionCacheEnabled is set.
+func NormalizeVersion(version string) (normalizedVersion string, e error) {
+	return NormalizeVersionFor(version, "")
+}
+
+// versionNormalizers normalize the versions of each ecosystem supported by NormalizeVersionFor.
+// The empty ecosystem is that of versions of unknown provenance (e.g. from CPEs or Git tags).
+var versionNormalizers = map[string]func(string) (string, error){
+	"":          normalizeVersion,
+	"crates.io": normalizeSemVer,
+	"Go":        normalizeSemVer,
+	"Hex":       normalizeSemVer,
+	"npm":       normalizeSemVer,
+	"Pub":       normalizeSemVer,
+	"Debian":    normalizeDebianVersion,
+	"Ubuntu":    normalizeDebianVersion,
+}
+
+// NormalizeVersionFor normalizes version following the conventions of the OSV ecosystem,
+// so that equivalent versions of that ecosystem normalize the same, e.g. "v1.2" and "1.2.0" for npm.
+// An empty ecosystem normalizes as NormalizeVersion. Unsupported ecosystems are an error.
+//
+// Results are memoized (per ecosystem) when NormalizeVersionCacheEnabled is set.
+func NormalizeVersionFor(version, ecosystem string) (normalizedVersion string, e error) {
+	normalize, ok := versionNormalizers[ecosystem]
+	if !ok {
+		return "", fmt.Errorf("unsupported ecosystem %q", ecosystem)
+	}
+	if !NormalizeVersionCacheEnabled {
+		return normalize(version)
+	}
+	key := normalizeVersionKey{version, ecosystem}
+	if cached, ok := normalizeVersionCache.Load(key); ok {
+		result := cached.(normalizeVersionResult)
+		return result.version, result.err
+	}
+	normalizedVersion, e = normalize(version)
+	if normalizeVersionCacheEntries.Add(1) > int64(MaxNormalizeVersionCacheEntries) {
+		// Rather than track recency, start afresh once full.
+		ClearNormalizeVersionCache()
+	}
+	normalizeVersionCache.Store(key, normalizeVersionResult{normalizedVersion, e})
+	return normalizedVersion, e
+}
+
+var (
+	// NormalizeVersionCacheEnabled enables memoization of NormalizeVersion (and NormalizeVersionFor), which pays off
+	// in long-running processes where many CVEs reference the same versions.
+	NormalizeVersionCacheEnabled = false
+	// MaxNormalizeVersionCacheEntries bounds the size of the NormalizeVersion cache.
+	MaxNormalizeVersionCacheEntries = 100000
+
+	normalizeVersionCache        sync.Map
+	normalizeVersionCacheEntries atomic.Int64
+)
+
+type normalizeVersionKey struct {
+	version   string
+	ecosystem string
+}
+
+type normalizeVersionResult struct {
+	version string
+	err     error
+}
+
+// ClearNormalizeVersionCache empties the NormalizeVersion cache.
+func ClearNormalizeVersionCache() {
+	normalizeVersionCache.Range(func(key, _ any) bool {
+		normalizeVersionCache.Delete(key)
+		return true
+	})
+	normalizeVersionCacheEntries.Store(0)
+}
+
+// Keep in sync with the intent of https://github.com/google/osv.dev/blob/26050deb42785bc5a4dc7d802eac8e7f95135509/osv/bug.py#L31
+var (
+	validVersion     = regexp.MustCompile(`(?i)(\d+|(?:rc|alpha|beta|preview)\d*)`)
+	validVersionText = regexp.MustCompile(`(?i)(?:rc|alpha|beta|preview)\d*`)
+	// A FreeBSD style patch level, e.g. "11.2-p3" or "11.2-RELEASE-p3", or OpenSSH style, e.g. "9.3p1".
+	patchLevelSuffix = regexp.MustCompile(`(?i)^(.*\d)(?:-RELEASE)?[-_.]?p(\d+)$`)
+	// An OpenSSL style letter release, e.g. "1.1.1k", continuing after "z" with "za" to "zz".
+	letterSuffix = regexp.MustCompile(`^(.*\d)([a-z]|z[a-z])$`)
+)
+
+// ReleaseTagPrefixes are the prefixes of release tags (e.g. "release-1.2.3", "ver1.2.3" and "REL_1_2_3")
+// removed by NormalizeVersion before a version is tokenized. They are matched case-insensitively,
+// and only when followed by a digit, so a prefix must precede any shorter prefix of itself.
+// ClearNormalizeVersionCache should be called after changing them.
+var ReleaseTagPrefixes = []string{"release-", "release_", "release", "rel-", "rel_", "rel", "version", "ver", "v"}
+
+// stripReleaseTagPrefix removes the first of ReleaseTagPrefixes that version starts with, converting
+// any underscore-separated numeric components of what remains (e.g. "REL_1_2_3") to dot-separated ones.
+func stripReleaseTagPrefix(version string) string {
+	for _, prefix := range ReleaseTagPrefixes {
+		if len(version) <= len(prefix) || !strings.EqualFold(version[:len(prefix)], prefix) {
+			continue
+		}
+		if rest := version[len(prefix):]; unicode.IsDigit(rune(rest[0])) {
+			return dotUnderscoredVersion(rest)
+		}
+	}
+	return version
+}
+
+func normalizeVersion(version string) (normalizedVersion string, e error) {
+	// A git describe version is normalized as its tag, followed by the number of commits since it.
+	if description, ok := ParseGitDescribe(version); ok {
+		tag, err := normalizeVersion(description.Tag)
+		if err != nil {
+			return "", err
+		}
+		return tag + "-" + strconv.Itoa(description.Distance), nil
+	}
+	version = stripReleaseTagPrefix(strings.SplitN(version, "+", 2)[0])
+	// Patch suffixes, e.g. the letter of OpenSSL's "1.1.1k" and the patch level of FreeBSD's "11.2-p3",
+	// become a component of their own, ordered after the numeric version.
+	var patchSuffix string
+	if match := patchLevelSuffix.FindStringSubmatch(version); match != nil {
+		version, patchSuffix = match[1], "p"+match[2]
+	} else if match := letterSuffix.FindStringSubmatch(version); match != nil {
+		version, patchSuffix = match[1], match[2]
+	}
+	components := validVersion.FindAllString(version, -1)
+	if components == nil {
+		return "", fmt.Errorf("%q is not a supported version", version)
+	}
+	// If the very first component happens to accidentally match the strings we support, remove it.
+	// This is necessary because of the lack of negative lookbehind assertion support in RE2.
+	if validVersionText.MatchString(components[0]) {
+		components = slices.Delete(components, 0, 1)
+	}
+	// Pre-release markers are separated variously, e.g. "1.0.0.rc1", "1.0.0-rc1", "1.0.0rc1" and "1.0.0-rc.1",
+	// so a marker is joined to a number separated from it.
+	var normalizedComponents []string
+	for i := 0; i < len(components); i++ {
+		component := components[i]
+		if validVersionText.MatchString(component) && strings.IndexFunc(component, unicode.IsDigit) == -1 &&
+			i+1 < len(components) && !validVersionText.MatchString(components[i+1]) {
+			component += components[i+1]
+			i++
+		}
+		normalizedComponents = append(normalizedComponents, component)
+	}
+	if patchSuffix != "" {
+		normalizedComponents = append(normalizedComponents, patchSuffix)
+	}
+	normalizedVersion = strings.Join(normalizedComponents, "-")
+	return normalizedVersion, e
+}
+
+var semVerPattern = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.\-]+))?(?:\+[0-9A-Za-z.\-]+)?$`)
+
+// normalizeSemVer normalizes a (possibly incomplete, e.g. "v1.2") SemVer version to
+// MAJOR.MINOR.PATCH, with any pre-release, but without build metadata, which doesn't distinguish versions.
+func normalizeSemVer(version string) (string, error) {
+	match := semVerPattern.FindStringSubmatch(version)
+	if match == nil {
+		return "", fmt.Errorf("%q is not a SemVer version", version)
+	}
+	components := match[1:4]
+	for i, component := range components {
+		if component = strings.TrimLeft(component, "0"); component == "" {
+			component = "0"
+		}
+		components[i] = component
+	}
+	normalizedVersion := strings.Join(components, ".")
+	if match[4] != "" {
+		normalizedVersion += "-" + match[4]
+	}
+	return normalizedVersion, nil
+}
+
+var debianVersionPattern = regexp.MustCompile(`^(?:(\d+):)?(\d[A-Za-z0-9.+~\-]*)$`)
+
+// normalizeDebianVersion normalizes a Debian ([epoch:]upstream_version[-debian_revision]) version,
+// omitting an epoch of zero, which is equivalent to no epoch.
+func normalizeDebianVersion(version string) (string, error) {
+	match := debianVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return "", fmt.Errorf("%q is not a Debian version", version)
+	}
+	if epoch := strings.TrimLeft(match[1], "0"); epoch != "" {
+		return epoch + ":" + match[2], nil
+	}
+	return match[2], nil
+}
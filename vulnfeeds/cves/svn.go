@@ -0,0 +1,144 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// VCS is the version control system of a repository.
+type VCS string
+
+const (
+	VCSGit        VCS = "git"
+	VCSMercurial  VCS = "hg"
+	VCSSubversion VCS = "svn"
+)
+
+// RepoInfo is a repository base URL, as returned by Repo(), along with its version control system.
+type RepoInfo struct {
+	URL string
+	VCS VCS
+}
+
+// RepoInfoFor returns the base repository URL of u, as Repo() does, along with the version control system
+// of the repository, as inferred from its URL, e.g.
+// https://hg.mozilla.org/mozilla-central (Mercurial)
+// https://svn.apache.org/repos/asf/httpd (Subversion)
+// Anything else is assumed to be Git.
+func RepoInfoFor(u string) (RepoInfo, error) {
+	repo, err := Repo(u)
+	if err != nil {
+		return RepoInfo{}, err
+	}
+	return RepoInfo{URL: repo, VCS: repoVCS(repo)}, nil
+}
+
+// repoVCS infers the version control system of the repository base URL repo from its scheme, host and path.
+func repoVCS(repo string) VCS {
+	parsedURL, err := url.Parse(repo)
+	if err != nil {
+		return VCSGit
+	}
+	scheme := strings.ToLower(parsedURL.Scheme)
+	hostname := strings.ToLower(parsedURL.Hostname())
+	// Repositories on the Git forges may be named e.g. "svn", but are still Git repositories.
+	// SourceForge hosts repositories of each kind.
+	if isRepoHost(hostname) && hostname != "sourceforge.net" {
+		return VCSGit
+	}
+	pathParts := strings.Split(strings.ToLower(parsedURL.Path), "/")
+	switch {
+	case scheme == "svn" || strings.HasPrefix(scheme, "svn+"),
+		strings.HasPrefix(hostname, "svn."),
+		strings.HasPrefix(hostname, "websvn."),
+		slices.Contains(pathParts, "websvn"),
+		slices.Contains(pathParts, "svn"),
+		slices.Contains(pathParts, "viewvc"):
+		return VCSSubversion
+	case strings.HasPrefix(hostname, "hg."),
+		slices.Contains(pathParts, "hg"),
+		slices.Contains(pathParts, "hgweb"):
+		return VCSMercurial
+	}
+	return VCSGit
+}
+
+// The repository roots of ViewVC instances serving a single repository from the root of the domain.
+var svnViewVCRoots = map[string]string{
+	"svn.apache.org": "/repos/asf",
+}
+
+// isViewVC returns whether u is for a page served by ViewVC.
+func isViewVC(u *url.URL) bool {
+	return slices.Contains(strings.Split(u.Path, "/"), "viewvc")
+}
+
+// isWebSVN returns whether u is for a page served by WebSVN.
+func isWebSVN(u *url.URL) bool {
+	return strings.HasSuffix(u.Path, ".php") && queryParam(u, "repname") != "" &&
+		(strings.HasPrefix(u.Hostname(), "websvn.") || slices.Contains(strings.Split(u.Path, "/"), "websvn"))
+}
+
+// svnRepo returns the base URL of the Subversion repository of a ViewVC, WebSVN or svn.code.sf.net URL.
+// ViewVC roots are the path following "/viewvc" (or, for the hosts of svnViewVCRoots, the known root).
+// WebSVN doesn't expose the repository's URL, so its listing of the repository is used instead.
+func svnRepo(u *url.URL) (string, bool) {
+	switch {
+	case isViewVC(u):
+		if root, ok := svnViewVCRoots[u.Hostname()]; ok {
+			return fmt.Sprintf("%s://%s%s", u.Scheme, u.Hostname(), root), true
+		}
+		prefix, rest, _ := strings.Cut(u.Path, "/viewvc")
+		root := strings.Split(strings.Trim(rest, "/"), "/")[0]
+		if root == "" {
+			return "", false
+		}
+		return fmt.Sprintf("%s://%s%s/viewvc/%s", u.Scheme, u.Hostname(), prefix, root), true
+	case isWebSVN(u):
+		return fmt.Sprintf("%s://%s%s/listing.php?repname=%s", u.Scheme, u.Hostname(),
+			strings.TrimSuffix(path.Dir(u.Path), "/"), queryParam(u, "repname")), true
+	case u.Hostname() == "svn.code.sf.net":
+		if repo, ok := sourceForgeRepo(u.Path); ok {
+			return fmt.Sprintf("%s://%s%s", u.Scheme, u.Hostname(), repo), true
+		}
+	}
+	return "", false
+}
+
+// svnRevision returns the revision referenced by a ViewVC or WebSVN revision URL, e.g.
+// https://svn.apache.org/viewvc?view=revision&revision=1790000
+// https://websvn.kde.org/revision.php?repname=kde&rev=1234567
+func svnRevision(u *url.URL) (string, bool) {
+	var revision string
+	switch {
+	case isViewVC(u) && (queryParam(u, "view") == "revision" || queryParam(u, "view") == "rev"):
+		revision = queryParam(u, "revision")
+		if revision == "" {
+			revision = queryParam(u, "rev")
+		}
+	case isWebSVN(u) && path.Base(u.Path) == "revision.php":
+		revision = queryParam(u, "rev")
+	}
+	if revision == "" || strings.Trim(revision, "0123456789") != "" {
+		return "", false
+	}
+	return revision, true
+}
@@ -15,26 +15,86 @@
 package cves
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/url"
+	"os"
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/knqyf263/go-cpe/naming"
 	"golang.org/x/exp/slices"
 )
 
 type GitCommit struct {
 	Repo   string
 	Commit string
+	// The directory or file within the repository the commit was referenced at, if any,
+	// e.g. the package of a monorepo a fix applies to.
+	Path string
 }
 
 type AffectedVersion struct {
 	Introduced   string
 	Fixed        string
 	LastAffected string
+	// Where the versions were extracted from, set by ExtractVersionInfo.
+	Source VersionSource
+	// The target software (e.g. "wordpress" or "node.js") of the CPE the versions were extracted from,
+	// a strong hint to the ecosystem of the package.
+	TargetSW string
+	// The edition of the CPE the versions were extracted from (its edition, sw_edition and other
+	// attributes that aren't ANY or NA, joined by ":"), e.g. "enterprise" or "lts", as the versions of
+	// different editions of a product are distinct.
+	Edition string
+	// Remarks on how this range in particular was extracted, e.g. that its fixed version was inferred.
+	Notes []string
+}
+
+// SameRange reports whether a and b describe the same range, from the same source, disregarding their Notes.
+func (a AffectedVersion) SameRange(b AffectedVersion) bool {
+	return a.Introduced == b.Introduced && a.Fixed == b.Fixed && a.LastAffected == b.LastAffected &&
+		a.Source == b.Source && a.TargetSW == b.TargetSW && a.Edition == b.Edition
+}
+
+// containsAffectedVersion reports whether versions has a range that is the same as version (see SameRange).
+func containsAffectedVersion(versions []AffectedVersion, version AffectedVersion) bool {
+	return slices.IndexFunc(versions, version.SameRange) != -1
+}
+
+// VersionSource identifies where an AffectedVersion was extracted from, which indicates
+// how much confidence can be placed in it.
+type VersionSource string
+
+const (
+	SourceCVE5             VersionSource = "CVE5"             // The CVE JSON 5.x affected products.
+	SourceCPE              VersionSource = "CPE"              // The version range of a CPE match.
+	SourceReferenceTag     VersionSource = "ReferenceTag"     // A release tag referenced by the CVE.
+	SourceAdvisory         VersionSource = "Advisory"         // A structured advisory referenced by the CVE.
+	SourceReferenceContent VersionSource = "ReferenceContent" // The content of a page referenced by the CVE.
+	SourceChangelog        VersionSource = "Changelog"        // A changelog referenced by the CVE.
+	SourceCSAF             VersionSource = "CSAF"             // A CSAF document referenced by the CVE.
+	SourceDescription      VersionSource = "Description"      // The free text description of the CVE.
+)
+
+// HighConfidence reports whether versions from s come from structured data, rather
+// than being mined from free text (and so may warrant review).
+func (s VersionSource) HighConfidence() bool {
+	return s == SourceCVE5 || s == SourceCPE || s == SourceAdvisory
+}
+
+// withSource sets the Source of each of versions.
+func withSource(versions []AffectedVersion, source VersionSource) []AffectedVersion {
+	for i := range versions {
+		versions[i].Source = source
+	}
+	return versions
 }
 
 type VersionInfo struct {
@@ -43,21 +103,360 @@ type VersionInfo struct {
 	LimitCommits        []GitCommit
 	LastAffectedCommits []GitCommit
 	AffectedVersions    []AffectedVersion
+	// The files changed by each of the FixCommits, when retrieved (see ExtractOptions.ChangedFilesFetcher).
+	ChangedFiles map[GitCommit][]string
+}
+
+// ByRepo partitions the commits of v by their repository, so each repository's commits can be
+// represented as a separate range. The AffectedVersions, which aren't specific to a repository,
+// are returned under the empty string.
+func (v VersionInfo) ByRepo() map[string]VersionInfo {
+	byRepo := make(map[string]VersionInfo)
+	partition := func(commits []GitCommit, field func(*VersionInfo) *[]GitCommit) {
+		for _, commit := range commits {
+			repoVersionInfo := byRepo[commit.Repo]
+			*field(&repoVersionInfo) = append(*field(&repoVersionInfo), commit)
+			byRepo[commit.Repo] = repoVersionInfo
+		}
+	}
+	partition(v.IntroducedCommits, func(vi *VersionInfo) *[]GitCommit { return &vi.IntroducedCommits })
+	partition(v.FixCommits, func(vi *VersionInfo) *[]GitCommit { return &vi.FixCommits })
+	partition(v.LimitCommits, func(vi *VersionInfo) *[]GitCommit { return &vi.LimitCommits })
+	partition(v.LastAffectedCommits, func(vi *VersionInfo) *[]GitCommit { return &vi.LastAffectedCommits })
+	for commit, files := range v.ChangedFiles {
+		repoVersionInfo := byRepo[commit.Repo]
+		if repoVersionInfo.ChangedFiles == nil {
+			repoVersionInfo.ChangedFiles = make(map[GitCommit][]string)
+		}
+		repoVersionInfo.ChangedFiles[commit] = files
+		byRepo[commit.Repo] = repoVersionInfo
+	}
+	if len(v.AffectedVersions) > 0 {
+		byRepo[""] = VersionInfo{AffectedVersions: v.AffectedVersions}
+	}
+	return byRepo
+}
+
+// EnumerateAffected expands the AffectedVersions of v into the list of affected versions in
+// validVersions, which must be in ascending order. Ranges are inclusive of the introduced and
+// last affected versions, and exclusive of the fixed version. Ranges with a bound that isn't
+// in validVersions are skipped, as are ranges with neither a fixed nor last affected version.
+// The result is in the order of validVersions.
+func (v VersionInfo) EnumerateAffected(validVersions []string) []string {
+	affected := make([]bool, len(validVersions))
+	for _, av := range v.AffectedVersions {
+		start := 0
+		if av.Introduced != "" && av.Introduced != "0" {
+			start = versionIndex(validVersions, av.Introduced)
+		}
+		var end int
+		switch {
+		case av.Fixed != "":
+			end = versionIndex(validVersions, av.Fixed)
+		case av.LastAffected != "":
+			end = versionIndex(validVersions, av.LastAffected)
+			if end != -1 {
+				end++
+			}
+		default:
+			continue
+		}
+		if start == -1 || end == -1 {
+			continue
+		}
+		for i := start; i < end; i++ {
+			affected[i] = true
+		}
+	}
+	var versions []string
+	for i, version := range validVersions {
+		if affected[i] {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// HasFixEvidence reports whether v says where the vulnerability ends, either as a fix or last
+// affected commit, or as an affected version range with a fixed or last affected version.
+func (v VersionInfo) HasFixEvidence() bool {
+	if len(v.FixCommits) > 0 || len(v.LastAffectedCommits) > 0 {
+		return true
+	}
+	for _, av := range v.AffectedVersions {
+		if av.Fixed != "" || av.LastAffected != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyVersionInfo reports whether v holds any commits or affected versions at all.
+func (v VersionInfo) HasAnyVersionInfo() bool {
+	return len(v.IntroducedCommits) > 0 || len(v.FixCommits) > 0 || len(v.LimitCommits) > 0 ||
+		len(v.LastAffectedCommits) > 0 || len(v.AffectedVersions) > 0
+}
+
+// alphanumeric folds a name to its lowercase letters and digits, so that e.g. "http_server" and
+// "HTTP-Server" compare as equal.
+func alphanumeric(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// repoCorrespondsToCPE reports whether the owner or name of repo resembles the vendor or product of cpe,
+// e.g. https://github.com/apache/httpd and cpe:2.3:a:apache:http_server, or https://github.com/torvalds/linux
+// and cpe:2.3:o:linux:linux_kernel.
+func repoCorrespondsToCPE(repo string, cpe *CPE) bool {
+	u, err := url.Parse(repo)
+	if err != nil {
+		return false
+	}
+	segments := strings.Split(strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git"), "/")
+	if len(segments) > 2 {
+		segments = segments[len(segments)-2:]
+	}
+	for _, segment := range segments {
+		segment = alphanumeric(segment)
+		for _, name := range []string{alphanumeric(cpe.Vendor), alphanumeric(cpe.Product)} {
+			if len(segment) < 3 || len(name) < 3 {
+				if segment != "" && segment == name {
+					return true
+				}
+				continue
+			}
+			if strings.Contains(segment, name) || strings.Contains(name, segment) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RepoConsistencyNotes checks that the repositories of the FixCommits of v correspond (see
+// repoCorrespondsToCPE) to a product of cpes, returning a note for each repository that doesn't,
+// as its commits may have been mis-linked by a reference. Nothing is checked without cpes.
+func (v VersionInfo) RepoConsistencyNotes(cpes []string) []string {
+	var parsedCPEs []*CPE
+	var products []string
+	for _, cpe := range cpes {
+		parsedCPE, err := ParseCPE(cpe)
+		if err != nil {
+			continue
+		}
+		parsedCPEs = append(parsedCPEs, parsedCPE)
+		if !slices.Contains(products, parsedCPE.Vendor+":"+parsedCPE.Product) {
+			products = append(products, parsedCPE.Vendor+":"+parsedCPE.Product)
+		}
+	}
+	if len(parsedCPEs) == 0 {
+		return nil
+	}
+	var notes []string
+	var checked []string
+	for _, commit := range v.FixCommits {
+		if slices.Contains(checked, commit.Repo) {
+			continue
+		}
+		checked = append(checked, commit.Repo)
+		if slices.IndexFunc(parsedCPEs, func(cpe *CPE) bool { return repoCorrespondsToCPE(commit.Repo, cpe) }) == -1 {
+			notes = append(notes, fmt.Sprintf("Fix commits are in %s, which does not correspond to any CPE product (%s)", commit.Repo, strings.Join(products, ", ")))
+		}
+	}
+	return notes
+}
+
+// CommitVerifier reports whether a GitCommit exists in its repository.
+type CommitVerifier func(gc GitCommit) (bool, error)
+
+// TagResolver returns the hash of the commit a tag of a repository points to.
+type TagResolver func(repo, tag string) (string, error)
+
+// VersionComparator compares two versions, returning a negative number when a is less than b,
+// zero when they are equal and a positive number when a is greater than b.
+type VersionComparator func(a, b string) int
+
+// MergeRequestResolver returns the hash of the commit a merged GitLab merge request of a repository
+// was merged as, or an error if it hasn't been merged.
+type MergeRequestResolver func(repo string, mergeRequest int) (string, error)
+
+// IntroducedCommitResolver returns a plausible commit introducing the vulnerability fixed by a GitCommit,
+// or "" if it's taken to have been present since the repository's first commit.
+type IntroducedCommitResolver func(fix GitCommit) (string, error)
+
+// ChangedFilesFetcher returns the paths of the files changed by a GitCommit.
+type ChangedFilesFetcher func(gc GitCommit) ([]string, error)
+
+// ReferenceResolver returns the URL a reference finally leads to, after following any redirects.
+type ReferenceResolver func(url string) (string, error)
+
+// ReferenceFetcher retrieves the (markdown or plain text) content of a reference URL.
+type ReferenceFetcher func(url string) (string, error)
+
+// ReferenceScraper extracts affected versions from the content of a reference (e.g. ExtractFixedVersionLists).
+type ReferenceScraper func(content string, validVersions []string) ([]AffectedVersion, []string)
+
+// ExtractOptions controls optional behaviour of ExtractVersionInfoWithOptions.
+// The zero value matches the behaviour of ExtractVersionInfo.
+type ExtractOptions struct {
+	// If set, each commit extracted from the references is checked with
+	// CommitVerifier and dropped (with a note) if it can't be verified.
+	CommitVerifier CommitVerifier
+	// If set, populated with a record of how the extraction proceeded.
+	Diagnostics *ExtractDiagnostics
+	// If set, a CPE match's versionEndIncluding is always used as the last_affected version,
+	// rather than inferring the fixed version as the next of validVersions. This avoids
+	// claiming a fix in a version that may not exist (e.g. when validVersions is incomplete, or
+	// the next release didn't fix the vulnerability), at the cost of not recording a fixed version.
+	PreferLastAffected bool
+	// If set, used to retrieve the content of the (non-commit) references, to be scanned for
+	// labelled "Affected:", "Introduced:" and "Fixed:" versions when no other versions were found,
+	// before resorting to the description. GitLab advisory references are retrieved as JSON,
+	// and their affected versions used.
+	ReferenceFetcher ReferenceFetcher
+	// If set, used to retrieve the raw content of changelogs (e.g. CHANGELOG.md on GitHub or GitLab, see
+	// ChangelogRawURL) referenced by the CVE when no other versions were found, before ReferenceFetcher.
+	// The release under which a changelog mentions the CVE is used as the fixed version.
+	ChangelogFetcher ReferenceFetcher
+	// If set, used to retrieve the CSAF documents (e.g. Red Hat and SUSE VEX, see IsCSAFURL) referenced
+	// by the CVE, whose affected and fixed versions (see ExtractCSAFVersions) are merged with those found
+	// elsewhere.
+	CSAFFetcher ReferenceFetcher
+	// If set, each is also applied to the content of references retrieved by ReferenceFetcher (other than
+	// GitLab advisories), and the versions it extracts used along with those otherwise found in the content,
+	// in place of any with the same fixed version.
+	ReferenceScrapers []ReferenceScraper
+	// If set, references retrieved by ReferenceFetcher that are HTML pages are scraped
+	// with ExtractVersionsFromHTML rather than scanned as text.
+	ScrapeHTMLReferences bool
+	// If set, the commits that fix tags referenced by the CVE point to are resolved with
+	// TagResolver and added to the FixCommits. Tags that can't be resolved are noted and skipped.
+	TagResolver TagResolver
+	// If set, GitLab merge requests referenced by the CVE (without a specific commit) are
+	// resolved with MergeRequestResolver and their merge commits added to the FixCommits.
+	// Merge requests that can't be resolved (e.g. aren't merged) are noted and skipped.
+	MergeRequestResolver MergeRequestResolver
+	// If set, the version that comes after another (e.g. the fixed version of an inclusive range)
+	// is the least of validVersions greater than it according to VersionComparator, rather than
+	// the next in validVersions, so validVersions needn't be sorted.
+	VersionComparator VersionComparator
+	// If set, used to determine the introduced commits of repositories with FixCommits but no
+	// IntroducedCommits (e.g. git.IntroducingCommit, which is expensive as it fetches the history of
+	// the fix). Without it, or when it finds none, such a repository's range is introduced at "0" (its
+	// first commit), per the OSV convention.
+	IntroducedCommitResolver IntroducedCommitResolver
+	// If set, used to retrieve the files changed by each of the FixCommits, into VersionInfo.ChangedFiles.
+	// Commits whose files can't be retrieved are noted and left out.
+	ChangedFilesFetcher ChangedFilesFetcher
+	// If set, references that aren't of a supported repository (see RepoWithForges()) are resolved with
+	// ReferenceResolver, and those that redirect to one (e.g. from a project's vanity domain)
+	// are used as the URL they redirect to. References that can't be resolved are used as they are.
+	ReferenceResolver ReferenceResolver
+	// If set, used to detect the forge run by each host of the references that isn't otherwise
+	// known, so that its URLs are parsed as that forge's (see RepoWithForges()). Each host is
+	// probed once per extraction, and hosts that can't be probed are noted.
+	ForgeProber ForgeProber
+	// If set, an extracted version found not to be valid (e.g. not one of validVersions) aborts the
+	// extraction with an error wrapping ErrInvalidVersion, rather than being noted and skipped or used
+	// regardless. ExtractVersionInfoWithError returns the error, ExtractVersionInfoWithOptions notes it.
+	StrictVersions bool
+}
+
+// ExtractDiagnostics records how ExtractVersionInfoWithOptions arrived at its result,
+// to aid triage of CVEs where few or no versions were detected.
+type ExtractDiagnostics struct {
+	CPEMatches               int  // CPE matches seen across all configuration nodes.
+	SkippedUnsupportedNodes  int  // CPE matches skipped because their node's operator isn't "OR" or "AND".
+	SkippedNotVulnerable     int  // CPE matches skipped because they aren't marked vulnerable.
+	SkippedNoVersionRange    int  // CPE matches skipped because they carry no usable version range.
+	SkippedNonApplication    int  // CPE matches skipped because they describe an operating system or hardware.
+	CVE5Versions             int  // Affected versions contributed by the CVE JSON 5.x affected products.
+	UsedCPEMatches           int  // CPE matches that contributed an affected version.
+	ReferenceTagVersions     int  // Affected versions contributed by tags in references.
+	ReferenceContentVersions int  // Affected versions contributed by the content of references.
+	ChangelogVersions        int  // Affected versions contributed by changelogs.
+	CSAFVersions             int  // Affected versions contributed by CSAF documents.
+	DescriptionFallbackRan   bool // Whether versions were sought in the description.
+	DescriptionVersionsFound int  // Affected versions contributed by the description.
+	// What became of each of the CVE's references, in order.
+	References []ReferenceOutcome
+}
+
+func (d ExtractDiagnostics) String() string {
+	referenceOutcomes := make(map[ReferenceOutcomeKind]int)
+	for _, reference := range d.References {
+		referenceOutcomes[reference.Outcome]++
+	}
+	return fmt.Sprintf("%d CVE 5.x versions, considered %d CPE matches (%d used, %d in unsupported nodes, %d not vulnerable, %d without a version range, %d not applications), %d reference tag versions, %d CSAF versions, %d changelog versions, %d reference content versions, description fallback ran: %t (%d versions), considered %d references (%d commits, %d unverified commits, %d repositories, %d denylisted, %d unsupported)",
+		d.CVE5Versions, d.CPEMatches, d.UsedCPEMatches, d.SkippedUnsupportedNodes, d.SkippedNotVulnerable, d.SkippedNoVersionRange, d.SkippedNonApplication,
+		d.ReferenceTagVersions, d.CSAFVersions, d.ChangelogVersions, d.ReferenceContentVersions, d.DescriptionFallbackRan, d.DescriptionVersionsFound,
+		len(d.References), referenceOutcomes[CommitReference], referenceOutcomes[UnverifiedReference], referenceOutcomes[RepositoryReference],
+		referenceOutcomes[DenylistedReference], referenceOutcomes[UnsupportedReference])
+}
+
+// reason briefly explains why no versions were detected, e.g. "no CPE matches, and none in the description",
+// for the notes. The full record is returned through ExtractOptions.Diagnostics.
+func (d ExtractDiagnostics) reason() string {
+	cpes := "no CPE matches"
+	if d.CPEMatches > 0 {
+		cpes = fmt.Sprintf("no usable version range in %d CPE matches", d.CPEMatches)
+	}
+	if !d.DescriptionFallbackRan {
+		return cpes + ", and the description wasn't searched"
+	}
+	return cpes + ", and none in the description"
+}
+
+// ReferenceOutcomeKind is what became of a reference during extraction.
+type ReferenceOutcomeKind string
+
+const (
+	CommitReference      ReferenceOutcomeKind = "commit"      // A commit was extracted from the reference.
+	UnverifiedReference  ReferenceOutcomeKind = "unverified"  // The commit extracted was dropped by the CommitVerifier.
+	RepositoryReference  ReferenceOutcomeKind = "repository"  // The reference is of a repository, but not a commit.
+	DenylistedReference  ReferenceOutcomeKind = "denylisted"  // The reference is of a denylisted repository (or a Gist).
+	UnsupportedReference ReferenceOutcomeKind = "unsupported" // The reference isn't of a supported repository.
+)
+
+// ReferenceOutcome records what became of a reference during extraction, and why.
+type ReferenceOutcome struct {
+	URL     string
+	Outcome ReferenceOutcomeKind
+	Reason  string // Why the reference wasn't used, if it wasn't.
+}
+
+// resolveReference returns the URL the reference u redirects to, as determined by resolver, if u isn't
+// itself of a supported (or denylisted) repository, including on a host recorded in forges. Whether the
+// URL redirected to is of a supported repository is for the caller to check, once its host is probed.
+func resolveReference(u string, resolver ReferenceResolver, forges Forges) (string, bool) {
+	if _, err := RepoWithForges(u, forges); err == nil || errors.Is(err, ErrDenylistedRepo) || errors.Is(err, ErrGist) || errors.Is(err, ErrNotRepo) {
+		return "", false
+	}
+	resolved, err := resolver(u)
+	if err != nil || resolved == u {
+		return "", false
+	}
+	return resolved, true
 }
 
-type CPE struct {
-	CPEVersion string
-	Part       string
-	Vendor     string
-	Product    string
-	Version    string
-	Update     string
-	Edition    string
-	Language   string
-	SWEdition  string
-	TargetSW   string
-	TargetHW   string
-	Other      string
+// referenceOutcome classifies the reference u, given the commit (if any) extracted from it and the
+// forges run by the hosts of the references.
+func referenceOutcome(u string, commit *GitCommit, forges Forges) ReferenceOutcome {
+	if commit != nil {
+		return ReferenceOutcome{URL: u, Outcome: CommitReference}
+	}
+	_, err := RepoWithForges(u, forges)
+	switch {
+	case err == nil:
+		return ReferenceOutcome{URL: u, Outcome: RepositoryReference}
+	case errors.Is(err, ErrDenylistedRepo) || errors.Is(err, ErrGist):
+		return ReferenceOutcome{URL: u, Outcome: DenylistedReference, Reason: err.Error()}
+	}
+	return ReferenceOutcome{URL: u, Outcome: UnsupportedReference, Reason: err.Error()}
 }
 
 var (
@@ -150,34 +549,423 @@ var (
 		"https://gitlab.com/gitlab-org/release",        // not the source
 	}
 	InvalidRepoRegex = `(?i)/(?:(?:CVEs?)|(?:CVE-\d{4}-\d{4,})|GitHubAssessments/.*)$`
+
+	// Additional patterns of invalid repos, consulted alongside InvalidRepoRegex.
+	invalidRepoRegexes []*regexp.Regexp
 )
 
+// AddInvalidRepoRegex adds pattern to the patterns of repos Repo() disregards.
+func AddInvalidRepoRegex(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid repo regexp %q: %w", pattern, err)
+	}
+	invalidRepoRegexes = append(invalidRepoRegexes, re)
+	return nil
+}
+
+// LoadInvalidRepoRegexes adds the patterns of invalid repos in the file at path (one per line,
+// ignoring blank lines and lines starting with "#") with AddInvalidRepoRegex.
+// No patterns are added if any of them are invalid.
+func LoadInvalidRepoRegexes(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var patterns []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := regexp.Compile(line); err != nil {
+			return fmt.Errorf("%s:%d: invalid repo regexp %q: %w", path, i+1, line, err)
+		}
+		patterns = append(patterns, line)
+	}
+	for _, pattern := range patterns {
+		if err := AddInvalidRepoRegex(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isInvalidRepo reports whether u matches InvalidRepoRegex or any pattern added with AddInvalidRepoRegex.
+func isInvalidRepo(u string) bool {
+	if matched, _ := regexp.MatchString(InvalidRepoRegex, u); matched {
+		return true
+	}
+	for _, re := range invalidRepoRegexes {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// RepoCloneURL returns the URL to clone the repository u refers to (as determined by Repo()).
+// For hosts that conventionally serve repositories with a ".git" suffix, it is appended, otherwise
+// (e.g. cGit, GitWeb and Gitiles) the repository base URL is already the clonable path.
+// SourceForge repositories are cloned from git.code.sf.net.
+func RepoCloneURL(u string) (string, error) {
+	repo, err := Repo(u)
+	if err != nil {
+		return "", err
+	}
+	parsedURL, err := url.Parse(repo)
+	if err != nil {
+		return "", err
+	}
+	hostname := parsedURL.Hostname()
+	if hostname == "sourceforge.net" {
+		return "https://git.code.sf.net" + parsedURL.Path, nil
+	}
+	if IsGitHubHost(hostname) || hostname == "bitbucket.org" || hostname == "pagure.io" || IsGitLabHost(hostname) {
+		if !strings.HasSuffix(repo, ".git") {
+			repo += ".git"
+		}
+	}
+	return repo, nil
+}
+
+// BestEffortRepo returns the repository most frequently referenced by the references of cve,
+// as determined by Repo(), for when there are no commit references to go by.
+// Ties are broken in favour of the repository referenced first.
+func BestEffortRepo(cve CVEItem) (string, error) {
+	var repos []string
+	counts := make(map[string]int)
+	for _, reference := range cve.CVE.References.ReferenceData {
+		repo, err := Repo(reference.URL)
+		if err != nil {
+			continue
+		}
+		if counts[repo] == 0 {
+			repos = append(repos, repo)
+		}
+		counts[repo]++
+	}
+	if len(repos) == 0 {
+		return "", fmt.Errorf("no repository found in the references of %s", cve.CVE.CVEDataMeta.ID)
+	}
+	best := repos[0]
+	for _, repo := range repos[1:] {
+		if counts[repo] > counts[best] {
+			best = repo
+		}
+	}
+	return best, nil
+}
+
+// Mirrors of repositories, keyed by the canonicalized (see canonicalRepo()) mirror URL,
+// with the canonicalized URL of the repository they mirror.
+var repoMirrors = map[string]string{
+	"gitlab.com/gitlab-org/gitlab-foss":                           "gitlab.com/gitlab-org/gitlab",
+	"gitlab.com/gitlab-org/gitlab-ce":                             "gitlab.com/gitlab-org/gitlab",
+	"gitlab.com/gitlab-org/gitlab-ee":                             "gitlab.com/gitlab-org/gitlab",
+	"github.com/torvalds/linux":                                   "git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux",
+	"git.kernel.org/cgit/linux/kernel/git/torvalds/linux":         "git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux",
+	"git.kernel.org/pub/scm/linux/kernel/git/stable/linux":        "git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux",
+	"git.kernel.org/pub/scm/linux/kernel/git/stable/linux-stable": "git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux",
+}
+
+// canonicalRepo returns a form of the repository URL u suitable for comparison:
+// the repository base URL (if Repo() understands u), without its scheme, ".git" suffix or
+// trailing slash, and lowercased, as the supported hosts treat paths case-insensitively.
+func canonicalRepo(u string) string {
+	if repo, err := Repo(u); err == nil {
+		u = repo
+	}
+	u = strings.ToLower(u)
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+len("://"):]
+	}
+	u = strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+	if origin, ok := repoMirrors[u]; ok {
+		return origin
+	}
+	// Apache projects are mirrored to GitHub under the same name.
+	if strings.HasPrefix(u, "gitbox.apache.org/repos/asf/") {
+		return "github.com/apache/" + strings.TrimPrefix(u, "gitbox.apache.org/repos/asf/")
+	}
+	return u
+}
+
+// SameRepo reports whether the repository URLs a and b refer to the same repository,
+// allowing for differences in scheme, ".git" suffixes and known mirrors.
+func SameRepo(a, b string) bool {
+	return canonicalRepo(a) == canonicalRepo(b)
+}
+
+// Query parameters added to URLs for tracking purposes, which don't change what is being referenced.
+var trackingQueryParams = []string{
+	"_ga",
+	"_gl",
+	"dclid",
+	"fbclid",
+	"gclid",
+	"igshid",
+	"mc_cid",
+	"mc_eid",
+	"msclkid",
+	"yclid",
+}
+
+// SanitizeReferenceURL removes noise from a reference URL that doesn't change what it references,
+// so the same logical URL is consistently treated the same. Specifically, it lowercases the scheme
+// and host, canonicalizes the host of known repository hosts (see canonicalHost), removes the
+// fragment and any tracking query parameters (e.g. "utm_source"), and removes trailing slashes
+// from the path when there is no query (some cGit pages rely on the slash before their query).
+// URLs that can't be parsed are returned unchanged.
+func SanitizeReferenceURL(u string) string {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	parsedURL.Host = canonicalHost(strings.ToLower(parsedURL.Host))
+	parsedURL.Fragment = ""
+	parsedURL.RawFragment = ""
+
+	// The query is manipulated as a string because url.ParseQuery rejects the ";" separators used by GitWeb.
+	var params []string
+	for _, param := range strings.Split(parsedURL.RawQuery, "&") {
+		key := strings.SplitN(param, "=", 2)[0]
+		if param == "" || strings.HasPrefix(key, "utm_") || slices.Contains(trackingQueryParams, key) {
+			continue
+		}
+		params = append(params, param)
+	}
+	parsedURL.RawQuery = strings.Join(params, "&")
+	parsedURL.ForceQuery = false
+
+	if parsedURL.RawQuery == "" && parsedURL.Path != "/" {
+		parsedURL.Path = strings.TrimRight(parsedURL.Path, "/")
+		parsedURL.RawPath = strings.TrimRight(parsedURL.RawPath, "/")
+	}
+	return parsedURL.String()
+}
+
+// canonicalHost removes the trailing dot of a fully qualified host (e.g. "github.com."), and the
+// "www." prefix of known repository hosts (e.g. "www.github.com"), which are equivalent to the host
+// without them. Any port is preserved.
+func canonicalHost(host string) string {
+	hostname, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname, port = h, p
+	}
+	hostname = strings.TrimSuffix(hostname, ".")
+	if trimmed := strings.TrimPrefix(hostname, "www."); trimmed != hostname && isRepoHost(trimmed) {
+		hostname = trimmed
+	}
+	if port != "" {
+		return net.JoinHostPort(hostname, port)
+	}
+	return hostname
+}
+
+// isRepoHost reports whether hostname is one of the known repository hosts.
+func isRepoHost(hostname string) bool {
+	switch hostname {
+	case "bitbucket.org", "pagure.io", "sourceforge.net":
+		return true
+	}
+	return IsGitHubHost(hostname) || IsGitLabHost(hostname) || isGiteaHost(hostname)
+}
+
+// GitHubHosts are the hosts of GitHub Enterprise instances (e.g. "github.example.com"), whose URLs
+// are laid out as on github.com, to be supported in addition to github.com. None by default.
+var GitHubHosts []string
+
+// GitLabHosts are the hosts of GitLab instances, e.g. gitlab.com and self-managed instances such as
+// "code.example.com". A host may be a pattern: "gitlab.*" matches any host whose first label is
+// "gitlab" (e.g. gitlab.freedesktop.org), and "*.example.com" any subdomain of example.com.
+var GitLabHosts = []string{"gitlab.com", "gitlab.*"}
+
+// IsGitHubHost reports whether hostname is github.com or one of GitHubHosts.
+func IsGitHubHost(hostname string) bool {
+	return hostname == "github.com" || slices.Contains(GitHubHosts, hostname)
+}
+
+// IsGitLabHost reports whether hostname is a GitLab instance, i.e. matches one of GitLabHosts.
+func IsGitLabHost(hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, host := range GitLabHosts {
+		switch {
+		case strings.HasSuffix(host, ".*"):
+			// The remainder must be a domain rather than a top-level domain, so e.g. "gitlab.*"
+			// doesn't match gitlab.io (of GitLab Pages).
+			prefix := strings.TrimSuffix(host, "*")
+			if strings.HasPrefix(hostname, prefix) && strings.Contains(strings.TrimPrefix(hostname, prefix), ".") {
+				return true
+			}
+		case strings.HasPrefix(host, "*."):
+			if strings.HasSuffix(hostname, strings.TrimPrefix(host, "*")) {
+				return true
+			}
+		case hostname == host:
+			return true
+		}
+	}
+	return false
+}
+
+// ErrGist is returned (wrapped) by Repo() and Commit() for GitHub Gist URLs, e.g.
+// https://gist.github.com/someone/0123456789abcdef0123456789abcdef
+// Gists are typically proof-of-concept exploits rather than the affected project's repository,
+// so callers can use errors.Is to disregard them rather than treat them as unsupported URLs.
+var ErrGist = errors.New("GitHub Gist URL")
+
+// ErrDenylistedRepo is the error (as determined by errors.Is) returned by Repo() for
+// repositories in InvalidRepos or matching the invalid repository regular expressions.
+var ErrDenylistedRepo = errors.New("denylisted repository")
+
+// IsGist reports whether u is a GitHub Gist URL.
+func IsGist(u string) bool {
+	parsedURL, err := url.Parse(SanitizeReferenceURL(u))
+	if err != nil {
+		return false
+	}
+	return parsedURL.Hostname() == "gist.github.com"
+}
+
+// ErrNotRepo is returned (wrapped) by Repo() for URLs that can never be of a repository, e.g. email
+// addresses, PDF documents and pages of advisory portals and mailing list archives, so callers can
+// use errors.Is to skip them quietly rather than treat them as unsupported URLs.
+var ErrNotRepo = errors.New("not a repository")
+
+// NonRepoHosts are the hosts (including their subdomains) of advisory portals, mailing list archives
+// and the like, that never host a repository.
+var NonRepoHosts = []string{
+	"access.redhat.com",
+	"cve.mitre.org",
+	"cve.org",
+	"exploit-db.com",
+	"hackerone.com",
+	"huntr.dev",
+	"lists.apache.org",
+	"lists.debian.org",
+	"lists.fedoraproject.org",
+	"lists.opensuse.org",
+	"mail-archive.com",
+	"marc.info",
+	"nvd.nist.gov",
+	"openwall.com",
+	"packetstormsecurity.com",
+	"securityfocus.com",
+	"securitytracker.com",
+	"seclists.org",
+	"security.gentoo.org",
+	"twitter.com",
+	"youtube.com",
+}
+
+// Extensions of documents that are never of a repository.
+var nonRepoExtensions = []string{".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".png", ".jpg", ".jpeg", ".gif", ".mp4"}
+
+// notRepo cheaply determines whether u can never be of a repository, by its scheme, host or extension,
+// returning ErrNotRepo (wrapped) if so.
+func notRepo(u string) error {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		// Left to Repo() to report.
+		return nil
+	}
+	switch strings.ToLower(parsedURL.Scheme) {
+	case "mailto", "news", "tel":
+		return fmt.Errorf("Repo(): %q has a %s scheme: %w", u, parsedURL.Scheme, ErrNotRepo)
+	}
+	hostname := strings.ToLower(parsedURL.Hostname())
+	for _, host := range NonRepoHosts {
+		if hostname == host || strings.HasSuffix(hostname, "."+host) {
+			return fmt.Errorf("Repo(): %q is on %s: %w", u, host, ErrNotRepo)
+		}
+	}
+	// Documents may be committed to repositories on known repository hosts.
+	if extension := strings.ToLower(path.Ext(parsedURL.Path)); slices.Contains(nonRepoExtensions, extension) && !isRepoHost(canonicalHost(hostname)) {
+		return fmt.Errorf("Repo(): %q is a %s document: %w", u, extension, ErrNotRepo)
+	}
+	return nil
+}
+
+// HostSupport describes the support of Repo() and Commit() for a repository host.
+type HostSupport struct {
+	// The host, or pattern of hosts (e.g. "gitlab.*"), the support applies to. Hosts identified by
+	// the layout of their URLs rather than their name (e.g. cGit and GitWeb) are named by the software.
+	Host string
+	// Whether Repo() extracts the repository base URL.
+	Repo bool
+	// Whether Commit() extracts commits.
+	Commit bool
+	// An example URL referencing a commit (or the repository, if commits aren't supported).
+	Example string
+}
+
+var hostSupport = []HostSupport{
+	{Host: "github.com", Repo: true, Commit: true, Example: "https://github.com/MariaDB/server/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+	{Host: "gitlab.*", Repo: true, Commit: true, Example: "https://gitlab.freedesktop.org/virgl/virglrenderer/-/commit/b05bb61f454eeb8a85164c8a31510aeb9d79129c"},
+	{Host: "bitbucket.org", Repo: true, Commit: true, Example: "https://bitbucket.org/openpyxl/openpyxl/commits/3b4905f428e1"},
+	{Host: "pagure.io", Repo: true, Commit: true, Example: "https://pagure.io/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede"},
+	{Host: "codeberg.org", Repo: true, Commit: true, Example: "https://codeberg.org/forgejo/forgejo/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70"},
+	{Host: "gitea.com", Repo: true, Commit: true, Example: "https://gitea.com/gitea/tea/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70"},
+	{Host: "try.gitea.io", Repo: true, Commit: true, Example: "https://try.gitea.io/gitea/tea/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70"},
+	{Host: "sourceforge.net", Repo: true, Commit: true, Example: "https://sourceforge.net/p/libpng/code/ci/a901eb3ce6087e0afeef988247f1a1aa208cb54d/"},
+	{Host: "*.googlesource.com", Repo: true, Commit: true, Example: "https://chromium.googlesource.com/chromium/src/+/8f4d6a1d5e9c6b4c2ee0b5bd1e5a4ec5c6a0d0f1"},
+	{Host: "cgit.freedesktop.org", Repo: true, Commit: false, Example: "https://cgit.freedesktop.org/xorg/lib/libXRes/commit/?id=c05c6d918b0e2011d4bfa370c321482e34630b17"},
+	{Host: "cGit", Repo: true, Commit: true, Example: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/commit/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe"},
+	{Host: "GitWeb", Repo: true, Commit: true, Example: "https://sourceware.org/git/?p=glibc.git;a=commit;h=6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c"},
+	{Host: "ViewVC", Repo: true, Commit: true, Example: "https://svn.apache.org/viewvc?view=revision&revision=1790000"},
+	{Host: "WebSVN", Repo: true, Commit: true, Example: "https://websvn.kde.org/revision.php?repname=kde&rev=1234567"},
+	{Host: "svn.code.sf.net", Repo: true, Commit: false, Example: "https://svn.code.sf.net/p/netpbm/code/trunk/converter/other/pnmtopng.c"},
+}
+
+// SupportedHosts returns the repository hosts supported by Repo() and Commit(), and their capabilities.
+func SupportedHosts() []HostSupport {
+	return slices.Clone(hostSupport)
+}
+
 // Returns the base repository URL for supported repository hosts.
 func Repo(u string) (string, error) {
+	return RepoWithForges(u, nil)
+}
+
+// RepoWithForges is Repo(), also supporting the URLs of the hosts in forges as those of their forge.
+func RepoWithForges(u string, forges Forges) (string, error) {
 	var supportedHosts = []string{
 		"github.com",
 		"gitlab.org",
 		"bitbucket.org",
 	}
+	u = withScheme(scpToHTTPS(u))
+	if IsGist(u) {
+		return "", fmt.Errorf("Repo(): %q is not a repository: %w", u, ErrGist)
+	}
+	if err := notRepo(u); err != nil {
+		return "", err
+	}
+	u = SanitizeReferenceURL(u)
 	parsedURL, err := url.Parse(u)
 	if err != nil {
 		return "", err
 	}
 
+	if _, ok := GitLabAdvisoryJSON(u); ok {
+		return "", fmt.Errorf("Repo(): %q is a GitLab advisory, not a repository", u)
+	}
+
 	// Disregard the repos we know we don't like (by regex).
-	matched, _ := regexp.MatchString(InvalidRepoRegex, u)
-	if matched {
-		return "", fmt.Errorf("%q matched invalid repo regexp", u)
+	if isInvalidRepo(u) {
+		return "", fmt.Errorf("%q matched invalid repo regexp: %w", u, ErrDenylistedRepo)
 	}
 
 	for _, dr := range InvalidRepos {
 		if strings.HasPrefix(u, dr) {
-			return "", fmt.Errorf("%q found in denylist", u)
+			return "", fmt.Errorf("%q found in denylist: %w", u, ErrDenylistedRepo)
 		}
 	}
 
 	// Were we handed a base repository URL from the get go?
-	if slices.Contains(supportedHosts, parsedURL.Hostname()) {
+	if slices.Contains(supportedHosts, parsedURL.Hostname()) || IsGitHubHost(parsedURL.Hostname()) {
 		if len(strings.Split(strings.TrimSuffix(parsedURL.Path, "/"), "/")) == 3 {
 			return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
 					parsedURL.Hostname(),
@@ -189,37 +977,64 @@ func Repo(u string) (string, error) {
 	// cGit URLs are structured another way, e.g.
 	// https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be
 	// https://git.kernel.org/cgit/linux/kernel/git/torvalds/linux.git/commit/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe
-	if strings.HasPrefix(parsedURL.Path, "/cgit") &&
-		strings.HasSuffix(parsedURL.Path, "commit/") &&
-		strings.HasPrefix(parsedURL.RawQuery, "id=") {
-		repo := strings.TrimSuffix(parsedURL.Path, "/commit/")
+	//
+	// This also supports cGit patch and diff URLs, e.g.
+	// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/patch/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe
+	// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/diff/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe
+	if page, ok := cgitCommitPage(parsedURL, forges); ok {
+		repo := strings.TrimSuffix(parsedURL.Path, "/"+page)
+		return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
+			parsedURL.Hostname(), repo), nil
+	}
+
+	// cGit tag and log URLs reference a tag or branch rather than a commit, e.g.
+	// https://git.zx2c4.com/cgit/tag/?h=v1.2.3
+	// https://git.dpkg.org/cgit/dpkg/dpkg.git/log/?h=refs/heads/main
+	if forges.isCGit(parsedURL) &&
+		(strings.HasSuffix(parsedURL.Path, "/tag/") || strings.HasSuffix(parsedURL.Path, "/log/")) &&
+		queryParam(parsedURL, "h") != "" {
+		repo := strings.TrimSuffix(strings.TrimSuffix(parsedURL.Path, "/tag/"), "/log/")
+		return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
+			parsedURL.Hostname(), repo), nil
+	}
+
+	// Gitiles URLs separate the repository from the revision with "/+/", e.g.
+	// https://chromium.googlesource.com/chromium/src/+/8f4d6a1d5e9c6b4c2ee0b5bd1e5a4ec5c6a0d0f1
+	// https://chromium.googlesource.com/v8/v8/+/refs/tags/10.2.154.4
+	// https://android.googlesource.com/platform/frameworks/base/+/refs/heads/main
+	if strings.Contains(parsedURL.Path, "/+/") {
+		repo := strings.Split(parsedURL.Path, "/+/")[0]
 		return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
 			parsedURL.Hostname(), repo), nil
 	}
 
 	// GitWeb CGI URLs are structured very differently, e.g.
 	// https://git.gnupg.org/cgi-bin/gitweb.cgi?p=libksba.git;a=commit;h=f61a5ea4e0f6a80fd4b28ef0174bee77793cf070 is another variation seen in the wild
-	if strings.HasPrefix(parsedURL.Path, "/cgi-bin/gitweb.cgi") &&
-		strings.HasPrefix(parsedURL.RawQuery, "p=") {
-		params := strings.Split(parsedURL.RawQuery, ";")
-		for _, param := range params {
-			if !strings.HasPrefix(param, "p=") {
-				continue
-			}
-			repo := strings.Split(param, "=")[1]
-			return fmt.Sprintf("%s://%s/%s", parsedURL.Scheme, parsedURL.Hostname(), repo), nil
+	// https://sourceware.org/git/?p=glibc.git;a=commit;h=6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c
+	// https://gitbox.apache.org/repos/asf?p=commons-text.git;a=commit;h=b9b40b903e2d1f9935039803c9852439576780ea
+	if base, ok := gitwebBase(parsedURL); ok {
+		if repo := queryParam(parsedURL, "p"); repo != "" {
+			return fmt.Sprintf("%s://%s%s/%s", parsedURL.Scheme, parsedURL.Hostname(), base, repo), nil
 		}
 	}
 
+	// Subversion repositories are browsed with ViewVC or WebSVN, or referenced directly, e.g.
+	// https://svn.apache.org/viewvc?view=revision&revision=1790000
+	// https://websvn.kde.org/revision.php?repname=kde&rev=1234567
+	// https://svn.code.sf.net/p/netpbm/code/trunk/converter/other/pnmtopng.c
+	if repo, ok := svnRepo(parsedURL); ok {
+		return repo, nil
+	}
+
 	// cgit.freedesktop.org is a special snowflake with enough repos to warrant special handling
 	// it is a mirror of gitlab.freedesktop.org
 	// https://cgit.freedesktop.org/xorg/lib/libXRes/commit/?id=c05c6d918b0e2011d4bfa370c321482e34630b17
 	// https://cgit.freedesktop.org/xorg/lib/libXRes
 	// http://cgit.freedesktop.org/spice/spice/refs/tags
 	if parsedURL.Hostname() == "cgit.freedesktop.org" {
-		if strings.HasSuffix(parsedURL.Path, "commit/") &&
-			strings.HasPrefix(parsedURL.RawQuery, "id=") {
-			repo := strings.TrimSuffix(parsedURL.Path, "/commit/")
+		if (strings.HasSuffix(parsedURL.Path, "/commit/") || strings.HasSuffix(parsedURL.Path, "/commit")) &&
+			queryParam(parsedURL, "id") != "" {
+			repo := strings.TrimSuffix(strings.TrimSuffix(parsedURL.Path, "/"), "/commit")
 			return fmt.Sprintf("https://gitlab.freedesktop.org%s",
 				repo), nil
 		}
@@ -234,12 +1049,56 @@ func Repo(u string) (string, error) {
 		}
 	}
 
+	// pagure.io project URLs have an optional namespace, followed by the page being viewed, e.g.
+	// https://pagure.io/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede
+	// https://pagure.io/rpms/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede
+	// https://pagure.io/freeipa/issue/9001
+	// https://pagure.io/libaio
+	if parsedURL.Hostname() == "pagure.io" {
+		if repo, ok := pagureProject(parsedURL.Path); ok {
+			return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
+				parsedURL.Hostname(), repo), nil
+		}
+	}
+
+	// Gitea (e.g. Codeberg) URLs have the page being viewed after the owner and repository, e.g.
+	// https://codeberg.org/forgejo/forgejo/commit/1b5e2e0e1a3d7b4c9f1f6d1b6c3a6d5e1b2c3d4e
+	// https://codeberg.org/forgejo/forgejo/src/branch/forgejo/modules/git
+	if forges.isGitea(parsedURL.Hostname()) {
+		if pathParts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/"); len(pathParts) >= 2 {
+			return fmt.Sprintf("%s://%s/%s", parsedURL.Scheme,
+				parsedURL.Hostname(), strings.Join(pathParts[0:2], "/")), nil
+		}
+	}
+
+	// SourceForge repositories are mounted under the project, with commits after "/ci/", e.g.
+	// https://sourceforge.net/p/libpng/code/ci/a901eb3ce6087e0afeef988247f1a1aa208cb54d/
+	// https://sourceforge.net/p/net-snmp/git/ci/4fd9a450444a434a993bf591f95c4e7bf8ed3ea3
+	if parsedURL.Hostname() == "sourceforge.net" {
+		if repo, ok := sourceForgeRepo(parsedURL.Path); ok {
+			return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
+				parsedURL.Hostname(), repo), nil
+		}
+	}
+
+	// GitLab separates the (possibly nested) project from the page being viewed with "/-/", e.g.
+	// https://gitlab.com/libtiff/libtiff/-/tags/v4.5.0
+	// https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium/-/releases/v2.30.1
+	if forges.isGitLab(parsedURL.Hostname()) && strings.Contains(parsedURL.Path, "/-/") {
+		repo := strings.SplitN(parsedURL.Path, "/-/", 2)[0]
+		if len(strings.Split(strings.Trim(repo, "/"), "/")) >= 2 {
+			return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
+				parsedURL.Hostname(), repo), nil
+		}
+	}
+
 	// GitHub and GitLab commit and blob URLs are structured one way, e.g.
 	// https://github.com/MariaDB/server/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8
 	// https://github.com/tensorflow/tensorflow/blob/master/tensorflow/core/ops/math_ops.cc
 	// https://gitlab.freedesktop.org/virgl/virglrenderer/-/commit/b05bb61f454eeb8a85164c8a31510aeb9d79129c
 	// https://gitlab.com/qemu-project/qemu/-/commit/4367a20cc4
-	// https://gitlab.com/gitlab-org/cves/-/blob/master/2022/CVE-2022-2501.json
+	// https://gitlab.com/gitlab-org/gitlab/-/blob/master/app/models/user.rb
+	// https://github.com/owner/mono/tree/b1351c15946349f9daa7e5297fb2ac6f3139e4a8/packages/foo
 	//
 	// This also supports GitHub tag URLs, e.g.
 	// https://github.com/JonMagon/KDiskMark/releases/tag/3.1.0
@@ -250,9 +1109,10 @@ func Repo(u string) (string, error) {
 	//
 	// This also supports GitHub Security Advisory URLs, e.g.
 	// https://github.com/ballcat-projects/ballcat-codegen/security/advisories/GHSA-fv3m-xhqw-9m79
-	if (parsedURL.Hostname() == "github.com" || strings.HasPrefix(parsedURL.Hostname(), "gitlab.")) &&
+	if (IsGitHubHost(parsedURL.Hostname()) || forges.isGitLab(parsedURL.Hostname())) &&
 		(strings.Contains(parsedURL.Path, "commit") ||
 			strings.Contains(parsedURL.Path, "blob") ||
+			strings.Contains(parsedURL.Path, "/tree/") ||
 			strings.Contains(parsedURL.Path, "releases/tag") ||
 			strings.Contains(parsedURL.Path, "releases") ||
 			strings.Contains(parsedURL.Path, "tags") ||
@@ -277,7 +1137,7 @@ func Repo(u string) (string, error) {
 
 	// GitHub pull request URLs are structured differently, e.g.
 	// https://github.com/google/osv.dev/pull/738
-	if parsedURL.Hostname() == "github.com" &&
+	if IsGitHubHost(parsedURL.Hostname()) &&
 		strings.Contains(parsedURL.Path, "pull") {
 		return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
 				parsedURL.Hostname(),
@@ -287,7 +1147,7 @@ func Repo(u string) (string, error) {
 
 	// Gitlab merge request URLs are structured differently, e.g.
 	// https://gitlab.com/libtiff/libtiff/-/merge_requests/378
-	if strings.HasPrefix(parsedURL.Hostname(), "gitlab.") &&
+	if forges.isGitLab(parsedURL.Hostname()) &&
 		strings.Contains(parsedURL.Path, "merge_requests") {
 		return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
 				parsedURL.Hostname(),
@@ -316,39 +1176,550 @@ func Repo(u string) (string, error) {
 			nil
 	}
 
+	// GitLab project URLs may be nested in subgroups, e.g.
+	// https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium
+	// (anything other than the project itself is under "/-/", and handled above)
+	if forges.isGitLab(parsedURL.Hostname()) &&
+		!strings.Contains(parsedURL.Path, "/-/") &&
+		len(strings.Split(strings.Trim(parsedURL.Path, "/"), "/")) >= 2 {
+		return fmt.Sprintf("%s://%s%s", parsedURL.Scheme,
+				parsedURL.Hostname(),
+				strings.TrimSuffix(parsedURL.Path, "/")),
+			nil
+	}
+
 	// If we get to here, we've encountered an unsupported URL.
 	return "", fmt.Errorf("Repo(): unsupported URL: %s", u)
 }
 
-// Returns the commit ID from supported links.
-func Commit(u string) (string, error) {
-	parsedURL, err := url.Parse(u)
-	if err != nil {
-		return "", err
+// withScheme prepends "https://" to scheme-less URLs of known repository hosts, e.g.
+// github.com/owner/repo
+// gitlab.com/group/subgroup/project
+func withScheme(u string) string {
+	if strings.Contains(u, "://") {
+		return u
 	}
-
-	// cGit URLs are structured another way, e.g.
-	// https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be
-	// https://git.kernel.org/cgit/linux/kernel/git/torvalds/linux.git/commit/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe
-	if strings.HasPrefix(parsedURL.Path, "/cgit") &&
-		strings.HasSuffix(parsedURL.Path, "commit/") &&
-		strings.HasPrefix(parsedURL.RawQuery, "id=") {
-		return strings.Split(parsedURL.RawQuery, "=")[1], nil
+	host := strings.Split(u, "/")[0]
+	if isRepoHost(canonicalHost(strings.ToLower(host))) {
+		return "https://" + u
+	}
+	return u
+}
+
+// scpToHTTPS rewrites an scp-like SSH clone URL for a known repository host (e.g.
+// git@gitlab.com:group/subgroup/repo.git) as the equivalent https URL (https://gitlab.com/group/subgroup/repo),
+// which url.Parse can make sense of. Other URLs are returned unchanged.
+func scpToHTTPS(u string) string {
+	if strings.Contains(u, "://") {
+		return u
+	}
+	userHost, repoPath, ok := strings.Cut(u, ":")
+	if !ok {
+		return u
+	}
+	// Without a user, host:path is indistinguishable from host:port.
+	_, host, ok := strings.Cut(userHost, "@")
+	if !ok || strings.Contains(host, "/") || !isRepoHost(canonicalHost(strings.ToLower(host))) {
+		return u
+	}
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	if repoPath == "" {
+		return u
+	}
+	return fmt.Sprintf("https://%s/%s", canonicalHost(strings.ToLower(host)), repoPath)
+}
+
+// Hosts serving cGit from the root of the domain, rather than under "/cgit".
+var cgitHosts = []string{
+	"git.kernel.org",
+}
+
+// isCGit returns whether u is for a page served by cGit.
+func isCGit(u *url.URL) bool {
+	return strings.HasPrefix(u.Path, "/cgit") || slices.Contains(cgitHosts, u.Hostname())
+}
+
+// queryParam returns the (percent-decoded) value of the first key parameter in the query of u.
+// url.URL.Query() can't be used, as it rejects the ";" separators used by GitWeb. Encoded
+// separators (e.g. "p=glibc.git%3Ba%3Dcommit") are treated as separators.
+func queryParam(u *url.URL, key string) string {
+	query, err := url.PathUnescape(u.RawQuery)
+	if err != nil {
+		query = u.RawQuery
+	}
+	for _, param := range strings.FieldsFunc(query, func(r rune) bool { return r == '&' || r == ';' }) {
+		if k, v, _ := strings.Cut(param, "="); k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// cgitCommitPage returns the cGit page (e.g. "commit/") of a URL for a single commit, identified by its "id=" query.
+// Some cGit instances link to the page without the trailing slash, e.g. "commit?id=".
+func cgitCommitPage(u *url.URL, forges Forges) (string, bool) {
+	if !forges.isCGit(u) || queryParam(u, "id") == "" {
+		return "", false
+	}
+	for _, page := range []string{"commit/", "commit", "patch/", "patch", "diff/", "diff"} {
+		if strings.HasSuffix(u.Path, "/"+page) {
+			return page, true
+		}
+	}
+	return "", false
+}
+
+// Matches the path of one of GitLab's CVE advisory JSON records.
+var gitlabAdvisoryJSONPathPattern = regexp.MustCompile(`^/gitlab-org/cves/-/(?:blob|raw)/[^/]+/\d{4}/CVE-\d{4}-\d+\.json$`)
+
+// GitLabAdvisoryJSON reports whether u is one of GitLab's CVE advisory JSON records, e.g.
+// https://gitlab.com/gitlab-org/cves/-/blob/master/2022/CVE-2022-2501.json
+// and if so returns the URL of the raw JSON.
+func GitLabAdvisoryJSON(u string) (string, bool) {
+	parsedURL, err := url.Parse(u)
+	if err != nil || parsedURL.Hostname() != "gitlab.com" {
+		return "", false
+	}
+	if !gitlabAdvisoryJSONPathPattern.MatchString(parsedURL.Path) {
+		return "", false
+	}
+	return fmt.Sprintf("https://gitlab.com%s", strings.Replace(parsedURL.Path, "/-/blob/", "/-/raw/", 1)), true
+}
+
+// parseVersionConstraint parses a version constraint expression, e.g. ">=15.0, <15.0.5", into an AffectedVersion.
+// Returns false if the expression contains no versions.
+func parseVersionConstraint(expression string) (AffectedVersion, bool) {
+	var version AffectedVersion
+	matches := referenceVersionPattern.FindAllStringSubmatch(expression, -1)
+	for _, match := range matches {
+		switch match[1] {
+		case ">=", ">":
+			version.Introduced = match[2]
+		case "<":
+			version.Fixed = match[2]
+		case "<=":
+			version.LastAffected = match[2]
+		default:
+			version.Introduced, version.LastAffected = match[2], match[2]
+		}
+	}
+	return version, len(matches) > 0
+}
+
+// extractVersionsFromAdvisoryJSON extracts the affected versions from a CVE JSON record, in either
+// the 5.x format (containers.cna.affected) or the 4.0 format (affects, with version constraint expressions).
+func extractVersionsFromAdvisoryJSON(validVersions []string, data []byte) ([]AffectedVersion, []string, error) {
+	var cve5 CVE5
+	if err := json.Unmarshal(data, &cve5); err != nil {
+		return nil, nil, err
+	}
+	if len(cve5.Containers.CNA.Affected) > 0 {
+		versions, notes := extractVersionsFromCVE5Affected(validVersions, cve5.Containers.CNA.Affected)
+		return versions, notes, nil
+	}
+
+	var cve4 CVE4Affects
+	if err := json.Unmarshal(data, &cve4); err != nil {
+		return nil, nil, err
+	}
+	var versions []AffectedVersion
+	for _, vendor := range cve4.Affects.Vendor.VendorData {
+		for _, product := range vendor.Product.ProductData {
+			for _, versionData := range product.Version.VersionData {
+				// Multiple ranges may be given as alternatives.
+				for _, expression := range strings.Split(versionData.VersionValue, "||") {
+					if version, ok := parseVersionConstraint(expression); ok && !containsAffectedVersion(versions, version) {
+						versions = append(versions, version)
+					}
+				}
+			}
+		}
+	}
+	return versions, nil, nil
+}
+
+// RefKind describes what a Git ref found in a URL refers to.
+type RefKind int
+
+const (
+	CommitRef RefKind = iota // A (possibly abbreviated) commit hash.
+	BranchRef
+	TagRef
+)
+
+func (k RefKind) String() string {
+	switch k {
+	case CommitRef:
+		return "commit"
+	case BranchRef:
+		return "branch"
+	case TagRef:
+		return "tag"
+	}
+	return fmt.Sprintf("RefKind(%d)", int(k))
+}
+
+// Well known branch names, and a pattern matching the names of release branches, e.g. "1-2-stable" or "release/1.2.x".
+var (
+	branchNames          = []string{"main", "master", "develop", "development", "dev", "trunk", "next", "HEAD"}
+	releaseBranchPattern = regexp.MustCompile(`(?i)(?:stable|branch|maint|release/|\.x$)`)
+)
+
+// classifyRef infers what kind of ref ref is from its shape: a commit hash, a version tag, or
+// otherwise (e.g. "main" or "1-2-stable") a branch.
+func classifyRef(ref string) RefKind {
+	if IsCommitHash(ref) {
+		return CommitRef
+	}
+	if slices.Contains(branchNames, ref) || releaseBranchPattern.MatchString(ref) {
+		return BranchRef
+	}
+	if _, err := NormalizeVersion(ref); err == nil && strings.IndexAny(ref, "0123456789") <= len("release-") {
+		return TagRef
+	}
+	return BranchRef
+}
+
+// treeCommit returns the commit a GitHub or GitLab tree or blob URL is pinned to, and the path
+// within the repository it references (if any), e.g.
+// https://github.com/owner/mono/tree/b1351c15946349f9daa7e5297fb2ac6f3139e4a8/packages/foo
+// https://gitlab.com/gitlab-org/gitlab/-/blob/4367a20cc4/app/models/user.rb
+// URLs pinned to a branch or tag aren't of a commit.
+func treeCommit(u *url.URL) (commit, filePath string, ok bool) {
+	var rest string
+	switch {
+	case IsGitHubHost(u.Hostname()):
+		pathParts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 4)
+		if len(pathParts) < 4 || (pathParts[2] != "tree" && pathParts[2] != "blob") {
+			return "", "", false
+		}
+		rest = pathParts[3]
+	case IsGitLabHost(u.Hostname()):
+		for _, page := range []string{"/-/tree/", "/-/blob/"} {
+			if parts := strings.SplitN(u.Path, page, 2); len(parts) == 2 {
+				rest = parts[1]
+			}
+		}
+	}
+	commit, filePath, _ = strings.Cut(rest, "/")
+	if !IsCommitHash(commit) {
+		return "", "", false
+	}
+	return commit, strings.TrimSuffix(filePath, "/"), true
+}
+
+// GitLabBlobRef returns the ref a GitLab "/-/blob/<ref>/<path>" URL is pinned to, and what kind of ref it appears to be, e.g.
+// https://gitlab.com/gitlab-org/gitlab/-/blob/v15.3.1/app/models/user.rb (a tag)
+// https://gitlab.com/gitlab-org/gitlab/-/blob/master/app/models/user.rb (a branch)
+// https://gitlab.com/gitlab-org/gitlab/-/blob/4367a20cc4/app/models/user.rb (a commit)
+func GitLabBlobRef(u string) (string, RefKind, error) {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return "", 0, err
+	}
+	if !IsGitLabHost(parsedURL.Hostname()) || !strings.Contains(parsedURL.Path, "/-/blob/") {
+		return "", 0, fmt.Errorf("GitLabBlobRef(): %s is not a GitLab blob URL", u)
+	}
+	ref := strings.Split(strings.SplitN(parsedURL.Path, "/-/blob/", 2)[1], "/")[0]
+	if ref == "" {
+		return "", 0, fmt.Errorf("GitLabBlobRef(): %s has no ref", u)
+	}
+	return ref, classifyRef(ref), nil
+}
+
+// githubReleaseTag returns the tag of a GitHub release URL.
+func githubReleaseTag(u *url.URL) (string, bool) {
+	if !IsGitHubHost(u.Hostname()) {
+		return "", false
+	}
+	// ["", owner, repo, "releases", "tag", tag...]
+	pathParts := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
+	if len(pathParts) < 6 || pathParts[3] != "releases" || pathParts[4] != "tag" {
+		return "", false
+	}
+	// Tags may contain slashes.
+	return strings.Join(pathParts[5:], "/"), true
+}
+
+// gitlabReleaseTag returns the tag named by a GitLab tag or release URL.
+func gitlabReleaseTag(u *url.URL) (string, bool) {
+	if !IsGitLabHost(u.Hostname()) {
+		return "", false
+	}
+	for _, page := range []string{"/-/tags/", "/-/releases/"} {
+		if parts := strings.SplitN(u.Path, page, 2); len(parts) == 2 {
+			// Tags may contain slashes.
+			if tag := strings.TrimSuffix(parts[1], "/"); tag != "" {
+				return tag, true
+			}
+		}
+	}
+	return "", false
+}
+
+// GitLabMergeRequest returns the repository and number of the merge request of a GitLab
+// merge request URL, e.g. https://gitlab.com/libtiff/libtiff/-/merge_requests/378
+func GitLabMergeRequest(u string) (repo string, mergeRequest int, ok bool) {
+	parsedURL, err := url.Parse(u)
+	if err != nil || !IsGitLabHost(parsedURL.Hostname()) {
+		return "", 0, false
+	}
+	parts := strings.SplitN(parsedURL.Path, "/-/merge_requests/", 2)
+	if len(parts) != 2 || strings.Trim(parts[0], "/") == "" {
+		return "", 0, false
+	}
+	// Subpages of the merge request (e.g. /diffs) are of the same merge request.
+	number, _, _ := strings.Cut(parts[1], "/")
+	mergeRequest, err = strconv.Atoi(number)
+	if err != nil || mergeRequest <= 0 {
+		return "", 0, false
+	}
+	return fmt.Sprintf("%s://%s%s", parsedURL.Scheme, parsedURL.Host, strings.TrimSuffix(parts[0], "/")), mergeRequest, true
+}
+
+// isGitHubMilestone reports whether u is a GitHub milestone URL, e.g.
+// https://github.com/owner/repo/milestone/5
+func isGitHubMilestone(u string) bool {
+	parsedURL, err := url.Parse(u)
+	if err != nil || !IsGitHubHost(parsedURL.Hostname()) {
+		return false
+	}
+	pathParts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+	return len(pathParts) == 4 && pathParts[2] == "milestone"
+}
+
+// gitwebBase reports whether u is a GitWeb page, and if so the path its repositories are clonable under:
+// cgi-bin GitWeb repositories are clonable from the root of the host, whereas sourceware.org style
+// "/git/?p=<repo>" repositories are clonable under "/git", and gitbox.apache.org ones under "/repos/asf".
+func gitwebBase(u *url.URL) (string, bool) {
+	if strings.HasPrefix(u.Path, "/cgi-bin/gitweb.cgi") {
+		return "", true
+	}
+	if u.Path == "/git/" || u.Path == "/git" {
+		return "/git", true
+	}
+	if u.Hostname() == "gitbox.apache.org" && (u.Path == "/repos/asf" || u.Path == "/repos/asf/") {
+		return "/repos/asf", true
+	}
+	return "", false
+}
+
+// pagureProject returns the (possibly namespaced) project path from a pagure.io URL path.
+func pagureProject(urlPath string) (string, bool) {
+	// Path segments that follow the project and denote a page within it.
+	pages := []string{"c", "commits", "blob", "tree", "raw", "issue", "issues", "pull-request", "pull-requests", "releases", "tags", "branches"}
+	pathParts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	project := pathParts
+	for i, part := range pathParts {
+		if slices.Contains(pages, part) {
+			project = pathParts[:i]
+			break
+		}
+	}
+	// A project is either "<project>" or "<namespace>/<project>".
+	if len(project) < 1 || len(project) > 2 || project[0] == "" {
+		return "", false
+	}
+	return "/" + strings.Join(project, "/"), true
+}
+
+// giteaCommit returns the commit in a Gitea commit URL path, which may reference it as the
+// commit itself, or a file (rendered or raw) at the commit, e.g.
+// "/owner/repo/commit/<hash>", "/owner/repo/src/commit/<hash>/path" or "/owner/repo/raw/commit/<hash>/path".
+func giteaCommit(urlPath string) (string, bool) {
+	// [owner, repo, page...]
+	pathParts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	switch {
+	case len(pathParts) >= 4 && pathParts[2] == "commit":
+		return pathParts[3], true
+	case len(pathParts) >= 5 && (pathParts[2] == "src" || pathParts[2] == "raw") && pathParts[3] == "commit":
+		return pathParts[4], true
+	}
+	return "", false
+}
+
+// Matches the repository (the tool mounted in a project) of SourceForge URLs, e.g. "/p/libpng/code".
+var sourceForgeRepoPattern = regexp.MustCompile(`^(/p/[^/]+/[^/]+)(?:/|$)`)
+
+// sourceForgeRepo returns the repository part of a SourceForge URL path, e.g.
+// "/p/libpng/code" for "/p/libpng/code/ci/a901eb3ce6087e0afeef988247f1a1aa208cb54d/".
+func sourceForgeRepo(urlPath string) (string, bool) {
+	match := sourceForgeRepoPattern.FindStringSubmatch(urlPath)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// Returns the commit ID from supported links. For Subversion repositories, this is the revision number.
+func Commit(u string) (string, error) {
+	return CommitWithForges(u, nil)
+}
+
+// CommitWithForges is Commit(), also supporting the URLs of the hosts in forges as those of their forge.
+func CommitWithForges(u string, forges Forges) (string, error) {
+	if parsedURL, err := url.Parse(SanitizeReferenceURL(u)); err == nil {
+		if revision, ok := svnRevision(parsedURL); ok {
+			return revision, nil
+		}
+	}
+	c, err := commitFromURL(u, forges)
+	if err != nil {
+		return "", err
+	}
+	if !IsCommitHash(c) {
+		return "", fmt.Errorf("Commit(): %q in %s does not look like a commit hash", c, u)
+	}
+	return c, nil
+}
+
+// GitWeb hosts CommitURL() can link to, keyed by host, with the path their repositories are clonable
+// under (as returned by gitwebBase) and the path of the GitWeb pages browsing them.
+var gitwebHosts = map[string]struct{ base, pages string }{
+	"sourceware.org":    {base: "/git", pages: "/git/"},
+	"gitbox.apache.org": {base: "/repos/asf", pages: "/repos/asf"},
+	"git.gnupg.org":     {base: "", pages: "/cgi-bin/gitweb.cgi"},
+}
+
+// CommitURL returns the canonical URL of the page of the commit in gc, in its repository's web interface.
+// It is the inverse of Repo() and Commit() for most repositories: Repo() and Commit() of the URL return gc's
+// repository (less any ".git" suffix, on forges whose pages omit it) and commit. The exceptions are
+// Subversion repositories, whose revisions aren't commit hashes, and GitWeb repositories on hosts other
+// than those in gitwebHosts, which (when clonable from the root of the host) look like any other repository.
+func CommitURL(gc GitCommit) (string, error) {
+	if !IsCommitHash(gc.Commit) {
+		return "", fmt.Errorf("CommitURL(): %q does not look like a commit hash", gc.Commit)
+	}
+	repo := strings.TrimSuffix(gc.Repo, "/")
+	u, err := url.Parse(repo)
+	if err != nil {
+		return "", err
+	}
+	hostname := u.Hostname()
+	// The pages of forges name the project without the ".git" suffix of its clone URL.
+	project := strings.TrimSuffix(repo, ".git")
+	switch {
+	case IsGitHubHost(hostname):
+		return fmt.Sprintf("%s/commit/%s", project, gc.Commit), nil
+	case IsGitLabHost(hostname):
+		return fmt.Sprintf("%s/-/commit/%s", project, gc.Commit), nil
+	case hostname == "bitbucket.org":
+		return fmt.Sprintf("%s/commits/%s", project, gc.Commit), nil
+	case isGiteaHost(hostname):
+		return fmt.Sprintf("%s/commit/%s", project, gc.Commit), nil
+	case hostname == "pagure.io":
+		return fmt.Sprintf("%s/c/%s", project, gc.Commit), nil
+	case hostname == "sourceforge.net":
+		return fmt.Sprintf("%s/ci/%s/", repo, gc.Commit), nil
+	case strings.HasSuffix(hostname, ".googlesource.com"):
+		return fmt.Sprintf("%s/+/%s", repo, gc.Commit), nil
+	case isCGit(u):
+		return fmt.Sprintf("%s/commit/?id=%s", repo, gc.Commit), nil
+	}
+	if gitweb, ok := gitwebHosts[hostname]; ok && strings.HasPrefix(u.Path, gitweb.base+"/") {
+		return fmt.Sprintf("%s://%s%s?p=%s;a=commit;h=%s", u.Scheme, u.Host, gitweb.pages, strings.TrimPrefix(u.Path, gitweb.base+"/"), gc.Commit), nil
+	}
+	return "", fmt.Errorf("CommitURL(): unsupported repository: %s", gc.Repo)
+}
+
+// The range of lengths of a (possibly abbreviated) commit hash accepted by Commit().
+// The maximum accommodates repositories using SHA-256 object names.
+var (
+	MinCommitHashLength = 7
+	MaxCommitHashLength = 64
+)
+
+// IsCommitHash reports whether s looks like a (possibly abbreviated) commit hash:
+// hexadecimal, between MinCommitHashLength and MaxCommitHashLength characters long.
+func IsCommitHash(s string) bool {
+	if len(s) < MinCommitHashLength || len(s) > MaxCommitHashLength {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// commitFromURL returns the commit hash (or what is presumed to be) in u, without validating it.
+func commitFromURL(u string, forges Forges) (string, error) {
+	if IsGist(u) {
+		return "", fmt.Errorf("Commit(): %q is not a repository: %w", u, ErrGist)
+	}
+	u = SanitizeReferenceURL(u)
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	// cGit URLs are structured another way, e.g.
+	// https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be
+	// https://git.kernel.org/cgit/linux/kernel/git/torvalds/linux.git/commit/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe
+	// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/patch/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe
+	if _, ok := cgitCommitPage(parsedURL, forges); ok {
+		return queryParam(parsedURL, "id"), nil
 	}
 
 	// GitWeb cgi-bin URLs are structured another way, e.g.
 	// https://git.gnupg.org/cgi-bin/gitweb.cgi?p=libksba.git;a=commit;h=f61a5ea4e0f6a80fd4b28ef0174bee77793cf070
-	if strings.HasPrefix(parsedURL.Path, "/cgi-bin/gitweb.cgi") &&
-		strings.Contains(parsedURL.RawQuery, "a=commit") {
-		params := strings.Split(parsedURL.RawQuery, ";")
-		for _, param := range params {
-			if !strings.HasPrefix(param, "h=") {
-				continue
+	// https://sourceware.org/git/?p=glibc.git;a=commit;h=6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c
+	// https://gitbox.apache.org/repos/asf?p=commons-text.git;a=commit;h=b9b40b903e2d1f9935039803c9852439576780ea
+	if _, ok := gitwebBase(parsedURL); ok &&
+		strings.HasPrefix(queryParam(parsedURL, "a"), "commit") {
+		if commit := queryParam(parsedURL, "h"); commit != "" {
+			return commit, nil
+		}
+	}
+
+	// pagure.io commit URLs have the hash after "/c/", e.g.
+	// https://pagure.io/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede
+	// https://pagure.io/rpms/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede?branch=master
+	if parsedURL.Hostname() == "pagure.io" {
+		if _, ok := pagureProject(parsedURL.Path); ok {
+			pathParts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+			for i, part := range pathParts {
+				if part == "c" && i+1 < len(pathParts) {
+					return strings.TrimSuffix(pathParts[i+1], ".patch"), nil
+				}
 			}
-			return strings.Split(param, "=")[1], nil
 		}
 	}
 
+	// Gitea commit URLs reference the commit directly, or files at it, e.g.
+	// https://codeberg.org/forgejo/forgejo/commit/1b5e2e0e1a3d7b4c9f1f6d1b6c3a6d5e1b2c3d4e
+	// https://codeberg.org/forgejo/forgejo/src/commit/1b5e2e0e1a3d7b4c9f1f6d1b6c3a6d5e1b2c3d4e/modules/git/repo.go
+	// https://codeberg.org/forgejo/forgejo/raw/commit/1b5e2e0e1a3d7b4c9f1f6d1b6c3a6d5e1b2c3d4e/modules/git/repo.go
+	// whereas branches and tags (e.g. "/src/branch/main") aren't commits.
+	if forges.isGitea(parsedURL.Hostname()) {
+		if commit, ok := giteaCommit(parsedURL.Path); ok && IsCommitHash(commit) {
+			return commit, nil
+		}
+		return "", fmt.Errorf("Commit(): %s is not a Gitea commit URL", u)
+	}
+
+	// SourceForge commit URLs have the hash after "/ci/", often with a trailing slash, e.g.
+	// https://sourceforge.net/p/libpng/code/ci/a901eb3ce6087e0afeef988247f1a1aa208cb54d/
+	// https://sourceforge.net/p/net-snmp/git/ci/4fd9a450444a434a993bf591f95c4e7bf8ed3ea3
+	if parsedURL.Hostname() == "sourceforge.net" {
+		if repo, ok := sourceForgeRepo(parsedURL.Path); ok && strings.HasPrefix(parsedURL.Path, repo+"/ci/") {
+			return strings.Split(strings.TrimPrefix(parsedURL.Path, repo+"/ci/"), "/")[0], nil
+		}
+	}
+
+	// Gitiles URLs reference either a commit or a ref after "/+/", e.g.
+	// https://chromium.googlesource.com/chromium/src/+/8f4d6a1d5e9c6b4c2ee0b5bd1e5a4ec5c6a0d0f1
+	// https://android.googlesource.com/platform/frameworks/base/+/refs/heads/main
+	if strings.Contains(parsedURL.Path, "/+/") {
+		revision := strings.Split(strings.SplitN(parsedURL.Path, "/+/", 2)[1], "/")[0]
+		// Anything other than a hash (e.g. "refs/heads/main" or "main") is a ref, not a commit.
+		if !IsCommitHash(revision) {
+			return "", fmt.Errorf("Commit(): %s references a tag or branch, not a commit", u)
+		}
+		return revision, nil
+	}
+
 	// GitHub and GitLab commit URLs are structured one way, e.g.
 	// https://github.com/MariaDB/server/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8
 	// https://gitlab.freedesktop.org/virgl/virglrenderer/-/commit/b05bb61f454eeb8a85164c8a31510aeb9d79129c
@@ -360,279 +1731,1669 @@ func Commit(u string) (string, error) {
 	// change the behaviour of path.Split(), so normalize the path to be tolerant of this.
 	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
 	directory, possibleCommitHash := path.Split(parsedURL.Path)
+	// GitHub "commits/" URLs are usually a listing of the commits on a branch, e.g.
+	// https://github.com/google/osv.dev/commits/master
+	// and only denote a single commit when followed by a full hash, e.g.
+	// https://github.com/log4js-node/log4js-node/pull/1141/commits/8042252861a1b65adb66931fdf702ead34fa9b76
+	if IsGitHubHost(parsedURL.Hostname()) && strings.HasSuffix(directory, "commits/") &&
+		!(IsCommitHash(possibleCommitHash) && (len(possibleCommitHash) == 40 || len(possibleCommitHash) == 64)) {
+		return "", fmt.Errorf("Commit(): %s is a listing of commits, not a commit", u)
+	}
 	if strings.HasSuffix(directory, "commit/") || strings.HasSuffix(directory, "commits/") {
 		return possibleCommitHash, nil
 	}
 
-	// TODO(apollock): add support for resolving a GitHub PR to a commit hash
-
-	// If we get to here, we've encountered an unsupported URL.
-	return "", fmt.Errorf("Commit(): unsupported URL: %s", u)
+	// GitHub and GitLab tree and blob URLs may be pinned to a commit, e.g.
+	// https://github.com/owner/mono/tree/b1351c15946349f9daa7e5297fb2ac6f3139e4a8/packages/foo
+	if commit, _, ok := treeCommit(parsedURL); ok {
+		return commit, nil
+	}
+
+	// TODO(apollock): add support for resolving a GitHub PR to a commit hash
+
+	// If we get to here, we've encountered an unsupported URL.
+	return "", fmt.Errorf("Commit(): unsupported URL: %s", u)
+}
+
+// Returns the tag from supported links referencing one.
+// Links referencing a branch (i.e. "refs/heads/") are rejected.
+func Tag(u string) (string, error) {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	// Gitiles URLs embed the fully qualified ref in the path, e.g.
+	// https://chromium.googlesource.com/v8/v8/+/refs/tags/10.2.154.4
+	// https://chromium.googlesource.com/v8/v8/+/refs/heads/main
+	//
+	// cGit URLs may carry it in the query, e.g.
+	// https://git.dpkg.org/cgit/dpkg/dpkg.git/log/?h=refs/tags/1.21.10
+	// https://git.dpkg.org/cgit/dpkg/dpkg.git/log/?h=refs/heads/main
+	ref := ""
+	if strings.Contains(parsedURL.Path, "/+/") {
+		ref = strings.SplitN(parsedURL.Path, "/+/", 2)[1]
+	}
+	for _, key := range []string{"id", "h"} {
+		if value := queryParam(parsedURL, key); strings.HasPrefix(value, "refs/") {
+			ref = value
+		}
+	}
+	if strings.HasPrefix(ref, "refs/heads/") {
+		return "", fmt.Errorf("Tag(): %s references a branch, not a tag", u)
+	}
+	if strings.HasPrefix(ref, "refs/tags/") {
+		// Anything after the tag is a path within the repository.
+		tag := strings.Split(strings.TrimPrefix(ref, "refs/tags/"), "/")[0]
+		if tag != "" {
+			return tag, nil
+		}
+	}
+
+	// GitLab blob URLs may be pinned to a tag, e.g.
+	// https://gitlab.com/gitlab-org/gitlab/-/blob/v15.3.1/app/models/user.rb
+	if ref, kind, err := GitLabBlobRef(u); err == nil && kind == TagRef {
+		return ref, nil
+	}
+
+	// GitHub release URLs name the tag in the path, e.g.
+	// https://github.com/JonMagon/KDiskMark/releases/tag/3.1.0
+	if tag, ok := githubReleaseTag(parsedURL); ok {
+		return tag, nil
+	}
+
+	// GitLab tag and release URLs name the tag after the "/-/" delimiter, e.g.
+	// https://gitlab.com/libtiff/libtiff/-/tags/v4.5.0
+	// https://gitlab.com/gitlab-org/gitlab/-/releases/v15.3.1
+	if tag, ok := gitlabReleaseTag(parsedURL); ok {
+		return tag, nil
+	}
+
+	// cGit tag URLs name the tag directly, e.g.
+	// https://git.zx2c4.com/cgit/tag/?h=v1.2.3
+	if isCGit(parsedURL) &&
+		strings.HasSuffix(parsedURL.Path, "/tag/") &&
+		queryParam(parsedURL, "h") != "" {
+		return queryParam(parsedURL, "h"), nil
+	}
+
+	// If we get to here, we've encountered an unsupported URL.
+	return "", fmt.Errorf("Tag(): unsupported URL: %s", u)
+}
+
+// Returns the version a release tag corresponds to, as confirmed by NormalizeVersion.
+// When validVersions is supplied, the valid version normalizing identically to the
+// tag is preferred, otherwise any non-numeric prefix (e.g. "v" or "project-") is removed.
+func tagToVersion(tag string, validVersions []string) (string, error) {
+	normalizedTag, err := NormalizeVersion(tag)
+	if err != nil {
+		return "", err
+	}
+	for _, version := range validVersions {
+		if normalizedVersion, err := NormalizeVersion(version); err == nil && normalizedVersion == normalizedTag {
+			return version, nil
+		}
+	}
+	idx := strings.IndexAny(tag, "0123456789")
+	if idx == -1 {
+		return "", fmt.Errorf("%q is not a supported version", tag)
+	}
+	return dotUnderscoredVersion(tag[idx:]), nil
+}
+
+// For URLs referencing commits in supported Git repository hosts (or those in forges), return a GitCommit.
+func extractGitCommit(link string, forges Forges) *GitCommit {
+	r, err := RepoWithForges(link, forges)
+	if err != nil {
+		return nil
+	}
+
+	c, err := CommitWithForges(link, forges)
+	if err != nil {
+		return nil
+	}
+
+	gc := &GitCommit{
+		Repo:   r,
+		Commit: c,
+	}
+	if parsedURL, err := url.Parse(SanitizeReferenceURL(link)); err == nil {
+		if _, filePath, ok := treeCommit(parsedURL); ok {
+			gc.Path = filePath
+		}
+	}
+	return gc
+}
+
+// Reference tags marking a commit as the one that introduced the vulnerability.
+var introducingReferenceTags = []string{"introduced", "introducing commit", "regression"}
+
+// Matches text that attributes the vulnerability to a commit, e.g. "introduced by commit 1234abcd"
+// as found in kernel bisection results.
+var introducedByCommitRegex = regexp.MustCompile(`(?i)\b(?:introduced|caused|regressed) (?:by|in) (?:commit |the commit )?([0-9a-f]{7,40})\b`)
+
+// referenceIntroducesCommit reports whether the commit extracted from reference is described,
+// by the reference's tags or name, or by the CVE description, as introducing (rather than fixing) the vulnerability.
+func referenceIntroducesCommit(reference CVEReferenceData, commit GitCommit, description string) bool {
+	for _, tag := range reference.Tags {
+		if slices.Contains(introducingReferenceTags, strings.ToLower(tag)) {
+			return true
+		}
+	}
+	if strings.Contains(strings.ToLower(reference.Name), "introduced by") {
+		return true
+	}
+	// Either hash may be abbreviated.
+	for _, match := range introducedByCommitRegex.FindAllStringSubmatch(reference.Name+"\n"+description, -1) {
+		hash := strings.ToLower(match[1])
+		commitHash := strings.ToLower(commit.Commit)
+		if strings.HasPrefix(commitHash, hash) || strings.HasPrefix(hash, commitHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// The repository and commit hash of a commit mentioned in shorthand in a description. The host may be
+// omitted, i.e. the "owner/repo" shorthand for a GitHub repository.
+const (
+	descriptionRepoPattern = `((?:https?://)?(?:(?:github\.com|gitlab\.com|bitbucket\.org)/)?[\w.\-]+/[\w.\-]+)`
+	descriptionHashPattern = `([0-9a-fA-F]{7,40})\b`
+)
+
+// Match commits mentioned as "<repo>@<hash>" and "commit <hash> in <repo>" respectively.
+var (
+	descriptionAtCommitPattern = regexp.MustCompile(descriptionRepoPattern + `@` + descriptionHashPattern)
+	descriptionInCommitPattern = regexp.MustCompile(`(?i)\bcommit\s+` + descriptionHashPattern + `\s+(?:in|of|to)\s+(?:the\s+)?` + descriptionRepoPattern)
+)
+
+// extractGitCommitsFromDescription finds commits mentioned in shorthand in a description,
+// as "<repo>@<hash>" (e.g. "github.com/owner/repo@abcdef123") or "commit <hash> in <repo>".
+func extractGitCommitsFromDescription(description string) []GitCommit {
+	var commits []GitCommit
+	addCommit := func(repo, hash string, start int) {
+		// Sentence punctuation may have been captured as part of the repository name.
+		repo = strings.TrimSuffix(strings.TrimRight(repo, "."), ".git")
+		if strings.Count(repo, "/") == 1 {
+			// The tail of a longer path, e.g. of a GitLab subgroup, isn't a shorthand.
+			if start > 0 && description[start-1] == '/' {
+				return
+			}
+			expanded, err := ExpandRepoShorthand(repo, "github.com")
+			if err != nil {
+				return
+			}
+			repo = expanded
+		}
+		if !strings.HasPrefix(repo, "http") {
+			repo = "https://" + repo
+		}
+		commit := GitCommit{Repo: repo, Commit: hash}
+		if !slices.Contains(commits, commit) {
+			commits = append(commits, commit)
+		}
+	}
+	for _, match := range descriptionAtCommitPattern.FindAllStringSubmatchIndex(description, -1) {
+		addCommit(description[match[2]:match[3]], description[match[4]:match[5]], match[2])
+	}
+	for _, match := range descriptionInCommitPattern.FindAllStringSubmatchIndex(description, -1) {
+		addCommit(description[match[4]:match[5]], description[match[2]:match[3]], match[4])
+	}
+	return commits
+}
+
+// repoShorthandSegmentPattern matches an owner or repository name of an "owner/repo" shorthand.
+var repoShorthandSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9][\w.\-]*$`)
+
+// ExpandRepoShorthand expands the "owner/repo" shorthand for a repository on host (github.com if empty)
+// into the repository's base URL, e.g. google/osv.dev becomes https://github.com/google/osv.dev.
+// Shorthands with more or fewer than two segments are ambiguous, and rejected.
+func ExpandRepoShorthand(shorthand string, host string) (string, error) {
+	if host == "" {
+		host = "github.com"
+	}
+	segments := strings.Split(strings.TrimSuffix(strings.TrimSpace(shorthand), ".git"), "/")
+	if len(segments) != 2 {
+		return "", fmt.Errorf("ExpandRepoShorthand(): %q is not of the form owner/repo", shorthand)
+	}
+	for _, segment := range segments {
+		if !repoShorthandSegmentPattern.MatchString(segment) {
+			return "", fmt.Errorf("ExpandRepoShorthand(): %q is not of the form owner/repo", shorthand)
+		}
+	}
+	return fmt.Sprintf("https://%s/%s/%s", strings.ToLower(host), segments[0], segments[1]), nil
+}
+
+func hasVersion(validVersions []string, version string) bool {
+	if validVersions == nil || len(validVersions) == 0 {
+		return true
+	}
+	return versionIndex(validVersions, version) != -1
+}
+
+func versionIndex(validVersions []string, version string) int {
+	for i, cur := range validVersions {
+		if cur == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextVersion returns the version of validVersions that comes after version. Without compare,
+// validVersions is assumed to be sorted, and this is the next in validVersions.
+func nextVersion(validVersions []string, version string, compare VersionComparator) (string, error) {
+	idx := versionIndex(validVersions, version)
+	if idx == -1 {
+		return "", fmt.Errorf("Warning: %s is not a valid version", version)
+	}
+
+	if compare != nil {
+		next := ""
+		for _, cur := range validVersions {
+			if compare(cur, version) > 0 && (next == "" || compare(cur, next) < 0) {
+				next = cur
+			}
+		}
+		if next == "" {
+			return "", fmt.Errorf("Warning: %s does not have a version that comes after.", version)
+		}
+		return next, nil
+	}
+
+	idx += 1
+	if idx >= len(validVersions) {
+		return "", fmt.Errorf("Warning: %s does not have a version that comes after.", version)
+	}
+
+	return validVersions[idx], nil
+}
+
+// ProcessExtractedVersion tidies up a version extracted from free text (such as a CVE description)
+// by trimming punctuation that is part of sentences (periods, commas, semicolons, colons and
+// brackets surrounding the version) and converting underscore-separated numeric
+// components (e.g. "1_2_3") to dot-separated ones. Build metadata following a "+" is preserved.
+// It returns an empty string if what remains doesn't look like a version, i.e. contains neither
+// a "." nor a number.
+func ProcessExtractedVersion(version string) string {
+	// Trailing punctuation may be nested, e.g. "1.2.3)," or "1.2.3).".
+	version = strings.TrimLeft(strings.TrimRight(version, ".,;:)]}"), ".([{")
+	// Version should contain at least a "." or a number.
+	if !strings.ContainsAny(version, ".") && !strings.ContainsAny(version, "0123456789") {
+		return ""
+	}
+
+	return dotUnderscoredVersion(version)
+}
+
+// Matches an underscore separating numeric version components, e.g. in "1_1_1k".
+var underscoreSeparatorPattern = regexp.MustCompile(`(\d)_(\d)`)
+
+// dotUnderscoredVersion replaces underscores separating numeric version components with dots,
+// e.g. "1_1_1k" becomes "1.1.1k".
+func dotUnderscoredVersion(version string) string {
+	// Adjacent separators share a digit, so repeat until none remain.
+	for underscoreSeparatorPattern.MatchString(version) {
+		version = underscoreSeparatorPattern.ReplaceAllString(version, "${1}.${2}")
+	}
+	return version
+}
+
+// Matches a labelled version line in reference content, e.g. "Affected: 1.2.3", "Fixed in: 1.2.4" or "== Introduced == 1.0".
+var referenceVersionLinePattern = regexp.MustCompile(`(?i)^=*\s*(affected|vulnerable|introduced|fixed|patched)(?:\s+(?:in|versions?))?\s*(?:=+|:)\s*(.+)$`)
+
+// Matches a version, optionally preceded by a comparison operator, in a labelled version line.
+var referenceVersionPattern = regexp.MustCompile(`(<=|>=|<|>|=)?\s*v?(\d+(?:[.\-]\w+)*)`)
+
+// extractVersionsFromReferenceContent scans markdown or plain text (e.g. an advisory page referenced by a CVE)
+// for lines labelling versions as affected, introduced or fixed, tolerating markdown formatting such as
+// headings, list items, tables and emphasis.
+//
+// Fixed versions are paired with introduced versions when there's an equal number of each (introduced
+// versions without any fixed versions are open-ended). Affected versions
+// may be bounded with comparison operators ("< 1.2.4" is fixed in 1.2.4, "<= 1.2.3" last affected in 1.2.3,
+// ">= 1.0" introduced in 1.0); unbounded affected versions are only used when there are no fixed versions.
+func extractVersionsFromReferenceContent(content string) ([]AffectedVersion, []string) {
+	var notes []string
+	var introduced, fixed []string
+	var affected []AffectedVersion
+	for _, line := range strings.Split(content, "\n") {
+		// Table cells ("| Fixed | 1.2.4 |") become labelled the same as "Fixed: 1.2.4".
+		line = strings.NewReplacer("**", "", "__", "", "`", "", "|", ":").Replace(line)
+		line = strings.TrimLeft(strings.TrimSpace(line), "#>-*+: \t")
+		match := referenceVersionLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		label := strings.ToLower(match[1])
+		var bounded AffectedVersion
+		for _, versionMatch := range referenceVersionPattern.FindAllStringSubmatch(match[2], -1) {
+			operator, version := versionMatch[1], strings.TrimRight(versionMatch[2], ".")
+			switch label {
+			case "introduced":
+				introduced = append(introduced, version)
+			case "fixed", "patched":
+				fixed = append(fixed, version)
+			default:
+				switch operator {
+				case "<":
+					bounded.Fixed = version
+				case "<=":
+					bounded.LastAffected = version
+				case ">=", ">":
+					bounded.Introduced = version
+				default:
+					affected = append(affected, AffectedVersion{Introduced: version, LastAffected: version})
+				}
+			}
+		}
+		if !bounded.SameRange(AffectedVersion{}) {
+			affected = append(affected, bounded)
+		}
+	}
+
+	var versions []AffectedVersion
+	if len(introduced) > 0 && len(fixed) > 0 && len(introduced) != len(fixed) {
+		notes = append(notes, fmt.Sprintf("Unable to pair introduced versions %v with fixed versions %v", introduced, fixed))
+	}
+	if len(fixed) == 0 {
+		for _, i := range introduced {
+			versions = append(versions, AffectedVersion{Introduced: i})
+		}
+	}
+	for i, f := range fixed {
+		version := AffectedVersion{Fixed: f}
+		if len(introduced) == len(fixed) {
+			version.Introduced = introduced[i]
+		}
+		versions = append(versions, version)
+	}
+	for _, version := range affected {
+		if len(fixed) > 0 && version.Fixed == "" && version.LastAffected == version.Introduced {
+			continue
+		}
+		if !containsAffectedVersion(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+	return versions, notes
+}
+
+// Matches the label of a list of fixed versions in reference content, e.g. "Fixed Versions: 1.2.3, 1.3.1"
+// or "Patched releases:" followed by a list.
+var fixedVersionListPattern = regexp.MustCompile(`(?i)^=*\s*(?:fixed|patched)(?:\s+in)?\s+(?:versions|releases)\s*(?:=+|:)\s*(.*)$`)
+
+// releaseLine returns the major.minor release line of a dotted version, e.g. "1.2" of "1.2.3".
+func releaseLine(version string) string {
+	components := strings.SplitN(version, ".", 3)
+	if len(components) < 2 {
+		return version
+	}
+	return components[0] + "." + components[1]
+}
+
+// ExtractFixedVersionLists is a ReferenceScraper for lists of fixed versions in markdown or plain text
+// reference content, as vendor advisories use to cover several release branches, e.g.
+// "Fixed Versions: 1.2.3, 1.3.1, 2.0.0", or a "Patched releases:" label followed by list items.
+// Each listed version that is valid (see validVersions) becomes a fixed version.
+//
+// When the introduced version is known (from an "Introduced:" or "Affected: >=" line), fixed versions
+// (listed in ascending order) are grouped by release branch: the first is introduced in the introduced
+// version, and those of later branches at the start of their branch (its first version of validVersions,
+// or its ".0" release). Fixed versions preceding the introduced version in validVersions are dropped.
+func ExtractFixedVersionLists(content string, validVersions []string) ([]AffectedVersion, []string) {
+	var notes []string
+	var fixed []string
+	var introduced string
+	inList := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.NewReplacer("**", "", "__", "", "`", "").Replace(line)
+		listItem := strings.HasPrefix(strings.TrimSpace(line), "-") || strings.HasPrefix(strings.TrimSpace(line), "*")
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#>-*+ \t"))
+		var listed string
+		if match := fixedVersionListPattern.FindStringSubmatch(line); match != nil {
+			listed = match[1]
+			inList = listed == ""
+		} else if inList && listItem {
+			listed = line
+		} else {
+			inList = false
+			if match := referenceVersionLinePattern.FindStringSubmatch(line); match != nil && introduced == "" {
+				label := strings.ToLower(match[1])
+				for _, versionMatch := range referenceVersionPattern.FindAllStringSubmatch(match[2], -1) {
+					operator := versionMatch[1]
+					if label == "introduced" || ((label == "affected" || label == "vulnerable") && (operator == ">=" || operator == ">")) {
+						introduced = strings.TrimRight(versionMatch[2], ".")
+						break
+					}
+				}
+			}
+			continue
+		}
+		for _, versionMatch := range referenceVersionPattern.FindAllStringSubmatch(listed, -1) {
+			version := CleanVersion(strings.TrimRight(versionMatch[2], "."))
+			if _, err := NormalizeVersion(version); err != nil || !hasVersion(validVersions, version) {
+				notes = append(notes, fmt.Sprintf("Listed fixed version %s is not a valid version", version))
+				continue
+			}
+			if !slices.Contains(fixed, version) {
+				fixed = append(fixed, version)
+			}
+		}
+	}
+
+	var versions []AffectedVersion
+	for _, f := range fixed {
+		version := AffectedVersion{Fixed: f}
+		switch {
+		case introduced == "":
+		case len(validVersions) > 0 && versionIndex(validVersions, introduced) > versionIndex(validVersions, f):
+			notes = append(notes, fmt.Sprintf("Dropping listed fixed version %s, which precedes the introduced version %s", f, introduced))
+			continue
+		case len(versions) == 0 || releaseLine(f) == releaseLine(introduced):
+			version.Introduced = introduced
+		case len(validVersions) > 0:
+			version.Introduced = f
+			for _, valid := range validVersions {
+				if releaseLine(valid) == releaseLine(f) {
+					version.Introduced = valid
+					break
+				}
+			}
+		default:
+			version.Introduced = releaseLine(f) + ".0"
+		}
+		versions = append(versions, version)
+	}
+	return versions, notes
+}
+
+// Matches a version denoting a whole release line, e.g. "1.2.*" or "1.2.x".
+var wildcardVersionPattern = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)\.[*xX]$`)
+
+// wildcardVersionRange expands a wildcard version (e.g. "1.2.*" or "1.2.x") into the range covering
+// the release line it denotes. The boundaries are taken from validVersions when available (the first
+// version in the line, and the version following the last one in the line), otherwise the range is
+// assumed to be from "1.2.0" up to "1.3.0".
+// Returns false if version isn't a wildcard version, or the release line can't be found in validVersions.
+func wildcardVersionRange(validVersions []string, version string) (AffectedVersion, []string, bool) {
+	match := wildcardVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return AffectedVersion{}, nil, false
+	}
+	line := match[1]
+	if len(validVersions) == 0 {
+		components := strings.Split(line, ".")
+		last, err := strconv.Atoi(components[len(components)-1])
+		if err != nil {
+			return AffectedVersion{}, []string{fmt.Sprintf("Unable to determine the range of %s: %v", version, err)}, false
+		}
+		components[len(components)-1] = strconv.Itoa(last + 1)
+		return AffectedVersion{Introduced: line + ".0", Fixed: strings.Join(components, ".") + ".0"}, nil, true
+	}
+
+	first, last := -1, -1
+	for i, validVersion := range validVersions {
+		v := strings.TrimPrefix(validVersion, "v")
+		if v == line || strings.HasPrefix(v, line+".") {
+			if first == -1 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first == -1 {
+		return AffectedVersion{}, []string{fmt.Sprintf("Unable to determine the range of %s: no valid versions in the %s release line", version, line)}, false
+	}
+	if last+1 >= len(validVersions) {
+		return AffectedVersion{Introduced: validVersions[first], LastAffected: validVersions[last]},
+			[]string{fmt.Sprintf("Unable to determine the version after the %s release line, using %s as last_affected version instead", line, validVersions[last])}, true
+	}
+	return AffectedVersion{Introduced: validVersions[first], Fixed: validVersions[last+1]}, nil, true
+}
+
+// normalizeDescription replaces the unicode whitespace and punctuation sometimes found in descriptions
+// (e.g. non-breaking spaces and smart quotes) with their ASCII equivalents, and removes zero-width characters,
+// so they don't defeat the version patterns.
+func normalizeDescription(description string) string {
+	normalized, _ := normalizeDescriptionWithOffsets(description)
+	return normalized
+}
+
+// normalizeDescriptionWithOffsets is normalizeDescription, also returning the byte offset in description
+// of each byte of the normalized description (and of its end), as normalization changes the byte length of text.
+func normalizeDescriptionWithOffsets(description string) (string, []int) {
+	var normalized strings.Builder
+	var offsets []int
+	for i, r := range description {
+		switch r {
+		case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+			continue
+		case '\u2018', '\u2019', '\u201a', '\u2032':
+			r = '\''
+		case '\u201c', '\u201d', '\u201e', '\u2033':
+			r = '"'
+		case '\u2010', '\u2011', '\u2012', '\u2013', '\u2014', '\u2212':
+			r = '-'
+		default:
+			if r > unicode.MaxASCII && unicode.IsSpace(r) {
+				r = ' '
+			}
+		}
+		n, _ := normalized.WriteRune(r)
+		for j := 0; j < n; j++ {
+			offsets = append(offsets, i)
+		}
+	}
+	offsets = append(offsets, len(description))
+	return normalized.String(), offsets
+}
+
+// DescriptionVersion is an AffectedVersion extracted from a description, with the evidence for it:
+// the text of the description it was extracted from, at the byte offsets [Start, End).
+// Branch is the release line the range was stated for, if any, e.g. "2.x" of "2.x before 2.1.3".
+type DescriptionVersion struct {
+	AffectedVersion
+	Text   string
+	Start  int
+	End    int
+	Branch string
+}
+
+func extractVersionsFromDescription(validVersions []string, description string, compare VersionComparator) ([]AffectedVersion, []string) {
+	descriptionVersions, notes := extractDescriptionVersions(validVersions, description, compare)
+	var versions []AffectedVersion
+	for _, version := range descriptionVersions {
+		versions = append(versions, version.AffectedVersion)
+	}
+	return versions, notes
+}
+
+// descriptionBoundPhrases are alternative phrasings (e.g. from CNAs translating from other languages)
+// of the upper bound of the affected versions in a description, as in "versions inferior to 1.2.3".
+// Inclusive phrases bound the last affected version, the others the fixed version.
+var descriptionBoundPhrases = []struct {
+	phrase    string
+	inclusive bool
+}{
+	{phrase: `inferior\s+to`},
+	{phrase: `earlier\s+than`},
+	{phrase: `older\s+than`},
+	{phrase: `less\s+than`},
+	{phrase: `lower\s+than`},
+	{phrase: `inferior\s+or\s+equal\s+to`, inclusive: true},
+	{phrase: `(?:earlier|older|less|lower)\s+than\s+or\s+equal\s+to`, inclusive: true},
+}
+
+// descriptionBoundPatterns match each of descriptionBoundPhrases followed by a version.
+var descriptionBoundPatterns = func() []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, bound := range descriptionBoundPhrases {
+		patterns = append(patterns, regexp.MustCompile(`(?i)\b`+bound.phrase+`\s+(?:version\s+)?(v?\d[\w.+\-]*)`))
+	}
+	return patterns
+}()
+
+// MaxDescriptionLength bounds the length in bytes of the description versions are extracted from,
+// so pathologically long descriptions can't dominate a bulk run. Longer descriptions are truncated
+// (with a note). Zero or less disables the limit.
+var MaxDescriptionLength = 16 * 1024
+
+// singleAffectedVersionPattern matches the statement of a single affected version of a product,
+// without any range, e.g. "This affects Apache HTTP Server 2.4.52." The version must end the clause,
+// so the start of a range (e.g. "affects Foo 1.2 through 1.4") isn't mistaken for one.
+var singleAffectedVersionPattern = regexp.MustCompile(`(?i)\b(?:affects|affecting)\s+((?:[\w\-]+\s+){0,6}?)(?:version\s+)?(v?\d+(?:\.\d+)+(?:[\-+~]?[A-Za-z0-9]+)?)(?:[,;)]|\.(?:\s|$)|$)`)
+
+// sinceVersionPattern matches the version a vulnerability was introduced in, stated as e.g.
+// "since version 1.2.0", "starting with 1.2.0" or "as of 1.2.0". The version must be dotted,
+// so years (as in "since 2019") aren't mistaken for one.
+var sinceVersionPattern = regexp.MustCompile(`(?i)\b(?:since|starting\s+(?:with|from)|as\s+of)\s+(?:versions?\s+)?(v?\d+(?:\.[\w+\-]+)+)`)
+
+// weaknessIdentifierPattern matches CWE and CVE identifiers, e.g. "CWE-79" and "CVE-2021-44228",
+// whose numbers aren't versions.
+var weaknessIdentifierPattern = regexp.MustCompile(`(?i)\b(?:CWE-\d+|CVE-\d{4}-\d+)\b`)
+
+// maskWeaknessIdentifiers blanks out the CWE and CVE identifiers in description, so they aren't
+// mistaken for versions. The identifiers are replaced with spaces of the same length, so offsets
+// into description are unchanged.
+func maskWeaknessIdentifiers(description string) string {
+	return weaknessIdentifierPattern.ReplaceAllStringFunc(description, func(identifier string) string {
+		return strings.Repeat(" ", len(identifier))
+	})
+}
+
+// buildRangePattern matches an inclusive range of build numbers, e.g. "builds 1000 through 1050"
+// or "build 1000 to build 1050".
+var buildRangePattern = regexp.MustCompile(`(?i)\bbuilds?\s+(\d+)\s+(?:through|to)\s+(?:builds?\s+)?(\d+)\b`)
+
+// compareBuildNumbers is a VersionComparator for build numbers, which must be integers.
+func compareBuildNumbers(a, b string) int {
+	aNum, _ := strconv.Atoi(a)
+	bNum, _ := strconv.Atoi(b)
+	switch {
+	case aNum < bNum:
+		return -1
+	case aNum > bNum:
+		return 1
+	}
+	return 0
+}
+
+// buildNumbers reports whether all of versions are build numbers, i.e. integers.
+func buildNumbers(versions []string) bool {
+	for _, version := range versions {
+		if _, err := strconv.Atoi(version); err != nil {
+			return false
+		}
+	}
+	return len(versions) > 0
+}
+
+// Matches an "x.x.x before x.x.x", "x.x.x through x.x.x", "through x.x.x" or "before x.x.x" range (or
+// "x.x.* before x.x.x", of a whole release line) in a description, where either version may be preceded by
+// "version" or "versions", as in "Versions x.x.x through versions x.x.x are affected", and the first may be
+// followed by it, as in "x.x versions before x.x.x".
+var descriptionRangePattern = regexp.MustCompile(`(?i)([\w.*+\-]+)?\s+(?:versions?\s+)?(through|before)\s+(?:versions?\s+)?([\w.+\-]+)`)
+
+// Matches an "x.x.x up to and including x.x.x", "x.x.x up to but not including x.x.x" or "x.x.x up to but
+// excluding x.x.x" range in a description.
+var descriptionUpToPattern = regexp.MustCompile(`(?i)(?:([\w.+\-]+)\s+)?up\s+to\s+(and\s+including|but\s+not\s+including|but\s+excluding)\s+(?:version\s+)?([\w.+\-]+)`)
+
+// Matches a wildcard version denoting a whole release line in a description, e.g. "1.2.*" or "1.2.x".
+var descriptionWildcardPattern = regexp.MustCompile(`(?i)(?:^|[\s(])(v?\d+(?:\.\d+)*\.[*x])(?:[\s,;:)]|\.\s|\.?$)`)
+
+// ExtractDescriptionVersions extracts the affected versions from the free text description of a CVE,
+// along with the text each was extracted from, for review.
+func ExtractDescriptionVersions(validVersions []string, description string) ([]DescriptionVersion, []string) {
+	return extractDescriptionVersions(validVersions, description, nil)
+}
+
+func extractDescriptionVersions(validVersions []string, description string, compare VersionComparator) ([]DescriptionVersion, []string) {
+	var truncationNotes []string
+	if MaxDescriptionLength > 0 && len(description) > MaxDescriptionLength {
+		// Don't split a multi-byte character.
+		cut := MaxDescriptionLength
+		for cut > 0 && !utf8.RuneStart(description[cut]) {
+			cut--
+		}
+		truncationNotes = append(truncationNotes, fmt.Sprintf("Description of %d bytes truncated to %d bytes", len(description), cut))
+		description = description[:cut]
+	}
+	original := description
+	description, offsets := normalizeDescriptionWithOffsets(description)
+	description = maskWeaknessIdentifiers(description)
+	// span returns the evidence for a version, from the normalized description's [start, end).
+	span := func(start, end int) DescriptionVersion {
+		for start < end && unicode.IsSpace(rune(description[start])) {
+			start++
+		}
+		return DescriptionVersion{Text: original[offsets[start]:offsets[end]], Start: offsets[start], End: offsets[end]}
+	}
+	group := func(match []int, i int) string {
+		if match[2*i] < 0 {
+			return ""
+		}
+		return description[match[2*i]:match[2*i+1]]
+	}
+	contains := func(versions []DescriptionVersion, version AffectedVersion) bool {
+		return slices.IndexFunc(versions, func(v DescriptionVersion) bool { return v.AffectedVersion.SameRange(version) }) != -1
+	}
+	// Match:
+	//  - x.x.x before x.x.x, x.x.x through x.x.x, through x.x.x or before x.x.x (see descriptionRangePattern)
+	//  - x.x.x up to and including x.x.x (inclusive, i.e. last affected)
+	//  - x.x.x up to but not including x.x.x (exclusive, i.e. fixed)
+	//  - x.x.x up to but excluding x.x.x (exclusive, i.e. fixed)
+	matches := descriptionRangePattern.FindAllStringSubmatchIndex(description, -1)
+	upToMatches := descriptionUpToPattern.FindAllStringSubmatchIndex(description, -1)
+	wildcardMatches := descriptionWildcardPattern.FindAllStringSubmatchIndex(description, -1)
+	boundMatches := make([][][]int, len(descriptionBoundPatterns))
+	hasBoundMatches := false
+	for i, boundPattern := range descriptionBoundPatterns {
+		boundMatches[i] = boundPattern.FindAllStringSubmatchIndex(description, -1)
+		hasBoundMatches = hasBoundMatches || boundMatches[i] != nil
+	}
+	singleMatches := singleAffectedVersionPattern.FindAllStringSubmatchIndex(description, -1)
+	sinceMatches := sinceVersionPattern.FindAllStringSubmatchIndex(description, -1)
+	buildMatches := buildRangePattern.FindAllStringSubmatchIndex(description, -1)
+	correlated, correlatedSpan, correlatedOk := correlateIntroducedAndFixed(validVersions, description)
+	if matches == nil && upToMatches == nil && wildcardMatches == nil && !hasBoundMatches && singleMatches == nil && sinceMatches == nil && buildMatches == nil && !correlatedOk {
+		return nil, append(truncationNotes, "Failed to parse versions from description")
+	}
+
+	notes := truncationNotes
+	var versions []DescriptionVersion
+	// Build number ranges are handled first, as the range pattern below would mistake the "build"
+	// preceding the upper bound for the bound.
+	for _, match := range buildMatches {
+		introduced, lastAffected := group(match, 1), group(match, 2)
+		for _, build := range []string{introduced, lastAffected} {
+			if !hasVersion(validVersions, build) {
+				notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", build))
+			}
+		}
+		version := span(match[0], match[1])
+		version.AffectedVersion = AffectedVersion{Introduced: introduced, LastAffected: lastAffected}
+		// Build numbers sort numerically, whatever the order of validVersions.
+		buildCompare := compare
+		if buildCompare == nil && buildNumbers(validVersions) {
+			buildCompare = compareBuildNumbers
+		}
+		if fixed, err := nextVersion(validVersions, lastAffected, buildCompare); err == nil {
+			version.AffectedVersion = AffectedVersion{Introduced: introduced, Fixed: fixed,
+				Notes: []string{fmt.Sprintf("Fixed version inferred as the build after %s, the last stated to be affected", lastAffected)}}
+		}
+		versions = append(versions, version)
+	}
+	inBuildRange := func(match []int) bool {
+		return slices.IndexFunc(buildMatches, func(buildMatch []int) bool {
+			return match[0] < buildMatch[1] && buildMatch[0] < match[1]
+		}) != -1
+	}
+	var rangeWildcards []string
+	for _, match := range matches {
+		if inBuildRange(match) {
+			continue
+		}
+		// Trim periods that are part of sentences.
+		introduced := ProcessExtractedVersion(group(match, 1))
+		fixed := ProcessExtractedVersion(group(match, 3))
+		// The range of e.g. "1.2.x before 1.2.5" starts at the beginning of the release line.
+		branch := ""
+		var versionNotes []string
+		if wildcardRange, wildcardNotes, ok := wildcardVersionRange(validVersions, introduced); ok {
+			rangeWildcards = append(rangeWildcards, introduced)
+			branch = introduced
+			introduced = wildcardRange.Introduced
+			versionNotes = append(versionNotes, fmt.Sprintf("Introduced version inferred as the start of the release line %s", branch))
+		} else if wildcardVersionPattern.MatchString(introduced) {
+			notes = append(notes, wildcardNotes...)
+			rangeWildcards = append(rangeWildcards, introduced)
+			branch = introduced
+			introduced = ""
+		}
+		if group(match, 2) == "through" {
+			// "Through" implies inclusive range, so the fixed version is the one that comes after.
+			lastAffected := fixed
+			var err error
+			fixed, err = nextVersion(validVersions, fixed, compare)
+			if err != nil {
+				notes = append(notes, err.Error())
+			} else {
+				versionNotes = append(versionNotes, fmt.Sprintf("Fixed version inferred as the version after %s, the last stated to be affected", lastAffected))
+			}
+		}
+
+		if introduced == "" && fixed == "" {
+			notes = append(notes, "Failed to match version range from description")
+			continue
+		}
+
+		if introduced != "" && !hasVersion(validVersions, introduced) {
+			notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", introduced))
+		}
+		if fixed != "" && !hasVersion(validVersions, fixed) {
+			notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", fixed))
+		}
+
+		version := span(match[0], match[1])
+		version.AffectedVersion = AffectedVersion{
+			Introduced: introduced,
+			Fixed:      fixed,
+			Notes:      versionNotes,
+		}
+		version.Branch = branch
+		versions = append(versions, version)
+	}
+
+	for _, match := range upToMatches {
+		introduced := ProcessExtractedVersion(group(match, 1))
+		bound := ProcessExtractedVersion(group(match, 3))
+		if bound == "" {
+			notes = append(notes, "Failed to match version range from description")
+			continue
+		}
+		for _, version := range []string{introduced, bound} {
+			if version != "" && !hasVersion(validVersions, version) {
+				notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", version))
+			}
+		}
+		version := span(match[0], match[1])
+		version.AffectedVersion = AffectedVersion{Introduced: introduced, Fixed: bound}
+		if strings.HasPrefix(strings.ToLower(group(match, 2)), "and") {
+			version.AffectedVersion = AffectedVersion{Introduced: introduced, LastAffected: bound}
+		}
+		if !contains(versions, version.AffectedVersion) {
+			versions = append(versions, version)
+		}
+	}
+
+	for i, bound := range descriptionBoundPhrases {
+		for _, match := range boundMatches[i] {
+			boundVersion := ProcessExtractedVersion(group(match, 1))
+			if !hasVersion(validVersions, boundVersion) {
+				notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", boundVersion))
+			}
+			version := span(match[0], match[1])
+			version.AffectedVersion = AffectedVersion{Fixed: boundVersion}
+			if bound.inclusive {
+				version.AffectedVersion = AffectedVersion{LastAffected: boundVersion}
+			}
+			if !contains(versions, version.AffectedVersion) {
+				versions = append(versions, version)
+			}
+		}
+	}
+
+	for _, match := range singleMatches {
+		single := ProcessExtractedVersion(group(match, 2))
+		if !hasVersion(validVersions, single) {
+			notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", single))
+		}
+		// The evidence excludes the punctuation ending the clause.
+		version := span(match[0], match[5])
+		version.AffectedVersion = AffectedVersion{Introduced: single, LastAffected: single}
+		if !contains(versions, version.AffectedVersion) {
+			notes = append(notes, fmt.Sprintf("Only version %s of %s is stated to be affected, other versions may be too", single, strings.TrimSpace(group(match, 1))))
+			versions = append(versions, version)
+		}
+	}
+
+	for _, match := range wildcardMatches {
+		if slices.Contains(rangeWildcards, group(match, 1)) {
+			continue
+		}
+		wildcardRange, wildcardNotes, ok := wildcardVersionRange(validVersions, group(match, 1))
+		notes = append(notes, wildcardNotes...)
+		wildcardRange.Notes = []string{fmt.Sprintf("Range inferred from the release line %s", group(match, 1))}
+		if ok && !contains(versions, wildcardRange) {
+			version := span(match[2], match[3])
+			version.AffectedVersion = wildcardRange
+			versions = append(versions, version)
+		}
+	}
+
+	if correlatedOk {
+		// Complete a range matched above that lacks an introduced version, rather than duplicating it.
+		completed := false
+		for i, version := range versions {
+			if version.Introduced == "" && version.Fixed == correlated.Fixed {
+				// The evidence then spans both the range and the introducing clause.
+				correlatedVersion := span(correlatedSpan[0], correlatedSpan[1])
+				start, end := version.Start, version.End
+				if correlatedVersion.Start < start {
+					start = correlatedVersion.Start
+				}
+				if correlatedVersion.End > end {
+					end = correlatedVersion.End
+				}
+				versions[i].Introduced = correlated.Introduced
+				versions[i].Text, versions[i].Start, versions[i].End = original[start:end], start, end
+				completed = true
+			}
+		}
+		if !completed && !contains(versions, correlated) {
+			version := span(correlatedSpan[0], correlatedSpan[1])
+			version.AffectedVersion = correlated
+			versions = append(versions, version)
+		}
+	}
+
+	// An introduced version with no fix found for it is an open-ended range.
+	for _, match := range sinceMatches {
+		introduced := ProcessExtractedVersion(group(match, 1))
+		if slices.IndexFunc(versions, func(v DescriptionVersion) bool { return v.Introduced == introduced }) != -1 {
+			continue
+		}
+		if !hasVersion(validVersions, introduced) {
+			notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", introduced))
+		}
+		version := span(match[0], match[1])
+		version.AffectedVersion = AffectedVersion{Introduced: introduced}
+		notes = append(notes, fmt.Sprintf("No fix found for the range introduced in %s", introduced))
+		versions = append(versions, version)
+	}
+
+	return versions, notes
 }
 
-// For URLs referencing commits in supported Git repository hosts, return a GitCommit.
-func extractGitCommit(link string) *GitCommit {
-	r, err := Repo(link)
-	if err != nil {
-		return nil
+// Match the "introduced in X" and "fixed in Y" clauses of a description respectively.
+var (
+	introducedInPattern = regexp.MustCompile(`(?i)\bintroduced\s+in\s+(?:version\s+)?([\w.+\-]+)`)
+	fixedInPattern      = regexp.MustCompile(`(?i)\b(?:fixed|patched|resolved|addressed)\s+in\s+(?:version\s+)?([\w.+\-]+)`)
+)
+
+// correlateIntroducedAndFixed combines an "introduced in X" (or "since X") clause with a "fixed in Y" clause
+// found elsewhere (e.g. in a different sentence) in description into a single AffectedVersion,
+// also returning the byte offsets of the text spanning both clauses.
+// To avoid combining unrelated version mentions, this only succeeds when each clause occurs
+// exactly once, and (when validVersions is supplied) both versions are valid and in order.
+func correlateIntroducedAndFixed(validVersions []string, description string) (AffectedVersion, [2]int, bool) {
+	introducedMatches := append(introducedInPattern.FindAllStringSubmatchIndex(description, -1), sinceVersionPattern.FindAllStringSubmatchIndex(description, -1)...)
+	fixedMatches := fixedInPattern.FindAllStringSubmatchIndex(description, -1)
+	if len(introducedMatches) != 1 || len(fixedMatches) != 1 {
+		return AffectedVersion{}, [2]int{}, false
 	}
+	introducedMatch, fixedMatch := introducedMatches[0], fixedMatches[0]
 
-	c, err := Commit(link)
-	if err != nil {
-		return nil
+	introduced := ProcessExtractedVersion(description[introducedMatch[2]:introducedMatch[3]])
+	fixed := ProcessExtractedVersion(description[fixedMatch[2]:fixedMatch[3]])
+	if introduced == "" || fixed == "" || introduced == fixed {
+		return AffectedVersion{}, [2]int{}, false
+	}
+	if len(validVersions) > 0 {
+		introducedIdx := versionIndex(validVersions, introduced)
+		fixedIdx := versionIndex(validVersions, fixed)
+		if introducedIdx == -1 || fixedIdx == -1 || introducedIdx > fixedIdx {
+			return AffectedVersion{}, [2]int{}, false
+		}
 	}
 
-	return &GitCommit{
-		Repo:   r,
-		Commit: c,
+	span := [2]int{introducedMatch[0], fixedMatch[1]}
+	if fixedMatch[0] < introducedMatch[0] {
+		span = [2]int{fixedMatch[0], introducedMatch[1]}
 	}
+	return AffectedVersion{
+		Introduced: introduced,
+		Fixed:      fixed,
+	}, span, true
 }
 
-func hasVersion(validVersions []string, version string) bool {
-	if validVersions == nil || len(validVersions) == 0 {
+// extractVersionsFromCVE5Affected maps the "affected" versions of CVE JSON 5.x affected products to AffectedVersions.
+// A version with a lessThan bound is a range fixed in lessThan, a version with a lessThanOrEqual bound is a range last
+// affected in lessThanOrEqual, and a version with neither is a single affected version.
+func extractVersionsFromCVE5Affected(validVersions []string, affected []CVE5Affected) ([]AffectedVersion, []string) {
+	var notes []string
+	var versions []AffectedVersion
+	for _, product := range affected {
+		for _, version := range product.Versions {
+			if version.Status != "affected" {
+				continue
+			}
+			possibleNewAffectedVersion := AffectedVersion{
+				Introduced: CleanVersion(version.Version),
+			}
+			switch {
+			case version.LessThan == "*" || version.LessThanOrEqual == "*":
+				// Unbounded, i.e. not yet fixed.
+			case version.LessThan != "":
+				possibleNewAffectedVersion.Fixed = CleanVersion(version.LessThan)
+			case version.LessThanOrEqual != "":
+				possibleNewAffectedVersion.LastAffected = CleanVersion(version.LessThanOrEqual)
+			default:
+				possibleNewAffectedVersion.LastAffected = possibleNewAffectedVersion.Introduced
+			}
+			if possibleNewAffectedVersion.Introduced == "" || possibleNewAffectedVersion.Introduced == "*" {
+				notes = append(notes, fmt.Sprintf("Warning: %s %s has an affected version without a version", product.Vendor, product.Product))
+				continue
+			}
+
+			for _, extracted := range []string{possibleNewAffectedVersion.Fixed, possibleNewAffectedVersion.LastAffected} {
+				if extracted != "" && !hasVersion(validVersions, extracted) {
+					notes = append(notes, fmt.Sprintf("Warning: %s is not a valid version", extracted))
+				}
+			}
+			if possibleNewAffectedVersion.Introduced != "0" && !hasVersion(validVersions, possibleNewAffectedVersion.Introduced) {
+				notes = append(notes, fmt.Sprintf("Warning: %s is not a valid introduced version", possibleNewAffectedVersion.Introduced))
+			}
+
+			if containsAffectedVersion(versions, possibleNewAffectedVersion) {
+				// Avoid appending duplicates
+				continue
+			}
+			versions = append(versions, possibleNewAffectedVersion)
+		}
+	}
+	return versions, notes
+}
+
+// hasMeaningfulVersions reports whether the versions of a CPE are suitable for version inference.
+// Operating system and hardware versions (e.g. Windows builds or firmware revisions) don't follow
+// the conventions of software releases, except for operating systems with an OSV ecosystem (e.g. the Linux kernel).
+func hasMeaningfulVersions(cpe *CPE) bool {
+	if cpe.IsApplication() {
 		return true
 	}
-	return versionIndex(validVersions, version) != -1
+	if cpe.Part == "o" {
+		_, ok := osEcosystems[strings.ToLower(cpe.Vendor+"/"+cpe.Product)]
+		return ok
+	}
+	return false
 }
 
-func versionIndex(validVersions []string, version string) int {
-	for i, cur := range validVersions {
-		if cur == version {
-			return i
+// cpeMatchAffectedVersion returns the AffectedVersion described by the version range of a CPE match.
+// Returns false if the match has no usable version range.
+func cpeMatchAffectedVersion(match CPEMatch, validVersions []string, opts ExtractOptions) (AffectedVersion, []string, bool) {
+	var notes, versionNotes []string
+	introduced := ""
+	fixed := ""
+	lastaffected := ""
+	// Both an including and excluding bound should never be set, but when they are,
+	// VersionStartIncluding and VersionEndExcluding take precedence as they don't
+	// require inferring an adjacent version.
+	if match.VersionStartIncluding != "" && match.VersionStartExcluding != "" {
+		notes = append(notes, fmt.Sprintf("Warning: %s has both versionStartIncluding (%s) and versionStartExcluding (%s), using versionStartIncluding",
+			match.CPE23URI, match.VersionStartIncluding, match.VersionStartExcluding))
+	}
+	if match.VersionEndExcluding != "" && match.VersionEndIncluding != "" {
+		notes = append(notes, fmt.Sprintf("Warning: %s has both versionEndExcluding (%s) and versionEndIncluding (%s), using versionEndExcluding",
+			match.CPE23URI, match.VersionEndExcluding, match.VersionEndIncluding))
+	}
+	if match.VersionStartIncluding != "" {
+		introduced = CleanVersion(match.VersionStartIncluding)
+	} else if match.VersionStartExcluding != "" {
+		var err error
+		introduced, err = nextVersion(validVersions, CleanVersion(match.VersionStartExcluding), opts.VersionComparator)
+		if err != nil {
+			notes = append(notes, err.Error())
+		} else {
+			versionNotes = append(versionNotes, fmt.Sprintf("Introduced version inferred as the version after versionStartExcluding %s", CleanVersion(match.VersionStartExcluding)))
 		}
 	}
-	return -1
-}
 
-func nextVersion(validVersions []string, version string) (string, error) {
-	idx := versionIndex(validVersions, version)
-	if idx == -1 {
-		return "", fmt.Errorf("Warning: %s is not a valid version", version)
+	if match.VersionEndExcluding != "" {
+		fixed = CleanVersion(match.VersionEndExcluding)
+	} else if match.VersionEndIncluding != "" && opts.PreferLastAffected {
+		lastaffected = CleanVersion(match.VersionEndIncluding)
+	} else if match.VersionEndIncluding != "" {
+		var err error
+		// Infer the fixed version from the next version after.
+		fixed, err = nextVersion(validVersions, CleanVersion(match.VersionEndIncluding), opts.VersionComparator)
+		if err != nil {
+			notes = append(notes, err.Error())
+			// if that inference failed, we know this version was definitely still vulnerable.
+			lastaffected = CleanVersion(match.VersionEndIncluding)
+			notes = append(notes, fmt.Sprintf("Using %s as last_affected version instead", CleanVersion(match.VersionEndIncluding)))
+		} else {
+			versionNotes = append(versionNotes, fmt.Sprintf("Fixed version inferred as the version after versionEndIncluding %s", CleanVersion(match.VersionEndIncluding)))
+		}
 	}
 
-	idx += 1
-	if idx >= len(validVersions) {
-		return "", fmt.Errorf("Warning: %s does not have a version that comes after.", version)
+	// Without PreferLastAffected, a last_affected version is only a fallback for an unresolvable fixed
+	// version, and doesn't describe a range on its own.
+	if introduced == "" && fixed == "" && (lastaffected == "" || !opts.PreferLastAffected) {
+		// A wildcard version (e.g. "cpe:2.3:a:foo:bar:1.2.*:...") denotes a whole release line.
+		if cpe, err := ParseCPE(match.CPE23URI); err == nil {
+			wildcardRange, wildcardNotes, ok := wildcardVersionRange(validVersions, cpe.Version)
+			if ok {
+				wildcardRange.Notes = []string{fmt.Sprintf("Range inferred from the release line %s", cpe.Version)}
+			}
+			return wildcardRange, append(notes, wildcardNotes...), ok
+		}
+		return AffectedVersion{}, notes, false
 	}
 
-	return validVersions[idx], nil
-}
+	// A match bounded only by versionEndExcluding, for any version, covers the whole history before the fix.
+	if match.VersionStartIncluding == "" && match.VersionStartExcluding == "" && match.VersionEndExcluding != "" {
+		if cpe, err := ParseCPE(match.CPE23URI); err == nil && (cpe.Version == "ANY" || cpe.Version == "*") {
+			introduced = "0"
+			notes = append(notes, fmt.Sprintf("%s has no start version, using 0 as introduced version", match.CPE23URI))
+			versionNotes = append(versionNotes, "No start version stated, introduced at 0")
+		}
+	}
 
-func processExtractedVersion(version string) string {
-	version = strings.Trim(version, ".")
-	// Version should contain at least a "." or a number.
-	if !strings.ContainsAny(version, ".") && !strings.ContainsAny(version, "0123456789") {
-		return ""
+	if introduced != "" && introduced != "0" && !hasVersion(validVersions, introduced) {
+		notes = append(notes, fmt.Sprintf("Warning: %s is not a valid introduced version", introduced))
 	}
 
-	return version
+	if fixed != "" && !hasVersion(validVersions, fixed) {
+		notes = append(notes, fmt.Sprintf("Warning: %s is not a valid fixed version", fixed))
+	}
+
+	return AffectedVersion{
+		Introduced:   introduced,
+		Fixed:        fixed,
+		LastAffected: lastaffected,
+		Notes:        versionNotes,
+	}, notes, true
 }
 
-func extractVersionsFromDescription(validVersions []string, description string) ([]AffectedVersion, []string) {
-	// Match:
-	//  - x.x.x before x.x.x
-	//  - x.x.x through x.x.x
-	//  - through x.x.x
-	//  - before x.x.x
-	pattern := regexp.MustCompile(`(?i)([\w.+\-]+)?\s+(through|before)\s+(?:version\s+)?([\w.+\-]+)`)
-	matches := pattern.FindAllStringSubmatch(description, -1)
-	if matches == nil {
-		return nil, []string{"Failed to parse versions from description"}
-	}
+// subsumes reports whether the AffectedVersion a contains all the information in b, i.e. every field
+// set in b is set to the same value in a. An introduced version of "0" is set (to the beginning of
+// history), so it's only subsumed by another "0". The versions of different sources, target software
+// or editions are distinct, so neither subsumes the other.
+func (a AffectedVersion) subsumes(b AffectedVersion) bool {
+	return a.Source == b.Source && a.TargetSW == b.TargetSW && a.Edition == b.Edition &&
+		(b.Introduced == "" || b.Introduced == a.Introduced) &&
+		(b.Fixed == "" || b.Fixed == a.Fixed) &&
+		(b.LastAffected == "" || b.LastAffected == a.LastAffected)
+}
 
-	var notes []string
-	var versions []AffectedVersion
-	for _, match := range matches {
-		// Trim periods that are part of sentences.
-		introduced := processExtractedVersion(match[1])
-		fixed := processExtractedVersion(match[3])
-		if match[2] == "through" {
-			// "Through" implies inclusive range, so the fixed version is the one that comes after.
-			var err error
-			fixed, err = nextVersion(validVersions, fixed)
-			if err != nil {
-				notes = append(notes, err.Error())
+// dedupeAffectedVersions removes the AffectedVersions that are duplicates of, or subsumed by, a more
+// specific one, e.g. {Introduced: "1.0"} is dropped in favour of {Introduced: "1.0", Fixed: "2.0"}.
+// The order of the remaining AffectedVersions is preserved, with the first of any duplicates kept.
+func dedupeAffectedVersions(versions []AffectedVersion) []AffectedVersion {
+	var deduped []AffectedVersion
+	for i, version := range versions {
+		redundant := false
+		for j, other := range versions {
+			if i == j || !other.subsumes(version) {
+				continue
+			}
+			// Of mutually subsuming (i.e. equivalent) AffectedVersions, keep the first.
+			if !version.subsumes(other) || j < i {
+				redundant = true
+				break
 			}
 		}
-
-		if introduced == "" && fixed == "" {
-			notes = append(notes, "Failed to match version range from description")
-			continue
+		if !redundant {
+			deduped = append(deduped, version)
 		}
+	}
+	return deduped
+}
 
-		if introduced != "" && !hasVersion(validVersions, introduced) {
-			notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", introduced))
-		}
-		if fixed != "" && !hasVersion(validVersions, fixed) {
-			notes = append(notes, fmt.Sprintf("Extracted version %s is not a valid version", fixed))
+// dedupeNotes removes repeated notes (e.g. the same warning raised by several CPE matches),
+// keeping the first occurrence of each so the output order remains that of the extraction.
+func dedupeNotes(notes []string) []string {
+	var deduped []string
+	for _, note := range notes {
+		if !slices.Contains(deduped, note) {
+			deduped = append(deduped, note)
 		}
+	}
+	return deduped
+}
 
-		versions = append(versions, AffectedVersion{
-			Introduced: introduced,
-			Fixed:      fixed,
-		})
+// Matches a version that leads with a year followed by further components, as calendar versions
+// (e.g. "2023.04.1") do. A bare four digit number (e.g. build "2100") isn't taken to be a year.
+var calVerPattern = regexp.MustCompile(`^v?((?:19|20|21)\d\d)[.\-_]\d`)
+
+// Matches the tail of a CVE ID (e.g. "2023-12345" from "CVE-2023-12345"), which can be mistaken for a version.
+var cveIDFragmentPattern = regexp.MustCompile(`^(?:19|20)\d\d-\d{4,}$`)
+
+// PlausibleCalVer reports whether version is plausible for a CVE published in publishedYear,
+// if it's a calendar version: it may not be from more than a year after publication, nor
+// look like the tail of a CVE ID. Versions that aren't calendar versions are always plausible.
+func PlausibleCalVer(version string, publishedYear int) bool {
+	if cveIDFragmentPattern.MatchString(version) {
+		return false
+	}
+	match := calVerPattern.FindStringSubmatch(version)
+	if match == nil {
+		return true
+	}
+	year, err := strconv.Atoi(match[1])
+	if err != nil {
+		return true
 	}
+	return year <= publishedYear+1
+}
 
-	return versions, notes
+// publishedYear returns the year cve was published in, if known.
+func publishedYear(cve CVEItem) (int, bool) {
+	if len(cve.PublishedDate) < len("2006") {
+		return 0, false
+	}
+	year, err := strconv.Atoi(cve.PublishedDate[:len("2006")])
+	if err != nil {
+		return 0, false
+	}
+	return year, true
 }
 
-func cleanVersion(version string) string {
+// CleanVersion tidies up a version found in CVE CPE Match data by trimming trailing colons.
+func CleanVersion(version string) string {
 	// Versions can end in ":" for some reason.
 	return strings.TrimRight(version, ":")
 }
 
-func ExtractVersionInfo(cve CVEItem, validVersions []string) (v VersionInfo, notes []string) {
+// ExtractCommits returns the distinct commits referenced by cve, without regard for whether they
+// introduce or fix the vulnerability, and without the cost of the rest of ExtractVersionInfo.
+func ExtractCommits(cve CVEItem) []GitCommit {
+	var commits []GitCommit
 	for _, reference := range cve.CVE.References.ReferenceData {
-		if commit := extractGitCommit(reference.URL); commit != nil {
-			v.FixCommits = append(v.FixCommits, *commit)
+		commit := extractGitCommit(reference.URL, nil)
+		if commit == nil || slices.Contains(commits, *commit) {
+			continue
 		}
+		commits = append(commits, *commit)
 	}
+	return commits
+}
 
-	gotVersions := false
-	for _, node := range cve.Configurations.Nodes {
-		if node.Operator != "OR" {
-			continue
+func ExtractVersionInfo(cve CVEItem, validVersions []string) (v VersionInfo, notes []string) {
+	return ExtractVersionInfoWithOptions(cve, validVersions, ExtractOptions{})
+}
+
+// ErrInvalidVersion is returned (wrapped) by ExtractVersionInfoWithError with StrictVersions (see
+// ExtractOptions) when an extracted version isn't valid.
+var ErrInvalidVersion = errors.New("not a valid version")
+
+// invalidVersionNotePattern matches the notes written when an extracted version isn't valid.
+var invalidVersionNotePattern = regexp.MustCompile(`(\S+) is not a valid (?:introduced |fixed )?version$`)
+
+// strictVersionsError returns an error wrapping ErrInvalidVersion for the first of notes that
+// reports an invalid version, if opts.StrictVersions is set.
+func strictVersionsError(id string, notes []string, opts ExtractOptions) error {
+	if !opts.StrictVersions {
+		return nil
+	}
+	for _, note := range notes {
+		if match := invalidVersionNotePattern.FindStringSubmatch(note); match != nil {
+			return fmt.Errorf("[%s]: extracted version %s: %w", id, match[1], ErrInvalidVersion)
 		}
+	}
+	return nil
+}
 
-		for _, match := range node.CPEMatch {
-			if !match.Vulnerable {
-				continue
+// ExtractVersionInfoWithError is ExtractVersionInfoWithOptions, except that with opts.StrictVersions
+// the error aborting the extraction is returned (with an empty VersionInfo), so a validating pipeline
+// can fail fast rather than emit partially wrong ranges.
+func ExtractVersionInfoWithError(cve CVEItem, validVersions []string, opts ExtractOptions) (VersionInfo, []string, error) {
+	return extractVersionInfo(cve, validVersions, opts)
+}
+
+// ExtractVersionInfoWithOptions is ExtractVersionInfo with the optional behaviour described by opts.
+// The notes are deterministic for a given input: they follow the order of the references and
+// configuration nodes in cve, and each distinct note appears only once.
+func ExtractVersionInfoWithOptions(cve CVEItem, validVersions []string, opts ExtractOptions) (v VersionInfo, notes []string) {
+	v, notes, err := extractVersionInfo(cve, validVersions, opts)
+	if err != nil {
+		notes = append(notes, err.Error())
+	}
+	return v, notes
+}
+
+// extractVersionInfo implements ExtractVersionInfoWithError and ExtractVersionInfoWithOptions.
+func extractVersionInfo(cve CVEItem, validVersions []string, opts ExtractOptions) (v VersionInfo, notes []string, err error) {
+	var diag ExtractDiagnostics
+	var tagVersions []AffectedVersion
+	id := cve.CVE.CVEDataMeta.ID
+	forges := Forges{}
+	for _, reference := range cve.CVE.References.ReferenceData {
+		originalURL := reference.URL
+		if opts.ForgeProber != nil {
+			notes = append(notes, forges.probe(reference.URL, opts.ForgeProber)...)
+		}
+		if opts.ReferenceResolver != nil {
+			if resolved, ok := resolveReference(reference.URL, opts.ReferenceResolver, forges); ok {
+				if opts.ForgeProber != nil {
+					notes = append(notes, forges.probe(resolved, opts.ForgeProber)...)
+				}
+				if _, err := RepoWithForges(resolved, forges); err == nil {
+					notes = append(notes, fmt.Sprintf("Following the redirect of %s to %s", reference.URL, resolved))
+					reference.URL = resolved
+				}
 			}
+		}
+		commit := extractGitCommit(reference.URL, forges)
+		diag.References = append(diag.References, referenceOutcome(reference.URL, commit, forges))
+		outcome := &diag.References[len(diag.References)-1]
+		outcome.URL = originalURL
+		if tag, err := Tag(reference.URL); err == nil {
+			if fixed, err := tagToVersion(tag, validVersions); err == nil {
+				tagVersion := AffectedVersion{Fixed: fixed, Source: SourceReferenceTag,
+					Notes: []string{fmt.Sprintf("Fixed version from the tag %s of %s", tag, reference.URL)}}
+				if !containsAffectedVersion(tagVersions, tagVersion) {
+					notes = append(notes, fmt.Sprintf("Using tag %s from %s as fixed version %s", tag, reference.URL, fixed))
+					tagVersions = append(tagVersions, tagVersion)
+				}
+				if opts.TagResolver != nil {
+					if repo, err := RepoWithForges(reference.URL, forges); err == nil {
+						commit, err := opts.TagResolver(repo, tag)
+						fixCommit := GitCommit{Repo: repo, Commit: commit}
+						switch {
+						case err != nil:
+							notes = append(notes, fmt.Sprintf("Unable to resolve tag %s in %s to a commit: %v", tag, repo, err))
+						case !slices.Contains(v.FixCommits, fixCommit):
+							notes = append(notes, fmt.Sprintf("Using commit %s of tag %s in %s as a fix", commit, tag, repo))
+							v.FixCommits = append(v.FixCommits, fixCommit)
+						}
+					}
+				}
+			}
+		}
 
-			introduced := ""
-			fixed := ""
-			lastaffected := ""
-			if match.VersionStartIncluding != "" {
-				introduced = cleanVersion(match.VersionStartIncluding)
-			} else if match.VersionStartExcluding != "" {
-				var err error
-				introduced, err = nextVersion(validVersions, cleanVersion(match.VersionStartExcluding))
-				if err != nil {
-					notes = append(notes, err.Error())
+		if isGitHubMilestone(reference.URL) {
+			notes = append(notes, fmt.Sprintf("%s is a GitHub milestone, which may denote the fixed version", reference.URL))
+		}
+
+		if commit == nil {
+			if repo, mergeRequest, ok := GitLabMergeRequest(reference.URL); ok && opts.MergeRequestResolver != nil {
+				mergeCommit, err := opts.MergeRequestResolver(repo, mergeRequest)
+				fixCommit := GitCommit{Repo: repo, Commit: mergeCommit}
+				switch {
+				case err != nil:
+					notes = append(notes, fmt.Sprintf("Unable to resolve merge request %s to a commit: %v", reference.URL, err))
+				case !slices.Contains(v.FixCommits, fixCommit):
+					notes = append(notes, fmt.Sprintf("Using merge commit %s of %s as a fix", mergeCommit, reference.URL))
+					v.FixCommits = append(v.FixCommits, fixCommit)
 				}
 			}
+			continue
+		}
+		if opts.CommitVerifier != nil {
+			exists, err := opts.CommitVerifier(*commit)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("Unable to verify commit %s in %s, dropping it: %v", commit.Commit, commit.Repo, err))
+				outcome.Outcome, outcome.Reason = UnverifiedReference, err.Error()
+				continue
+			}
+			if !exists {
+				notes = append(notes, fmt.Sprintf("Commit %s does not exist in %s, dropping it", commit.Commit, commit.Repo))
+				outcome.Outcome, outcome.Reason = UnverifiedReference, "commit does not exist"
+				continue
+			}
+		}
+		if referenceIntroducesCommit(reference, *commit, EnglishDescription(cve.CVE)) {
+			notes = append(notes, fmt.Sprintf("Treating commit %s in %s as introducing the vulnerability", commit.Commit, commit.Repo))
+			v.IntroducedCommits = append(v.IntroducedCommits, *commit)
+			continue
+		}
+		v.FixCommits = append(v.FixCommits, *commit)
+	}
 
-			if match.VersionEndExcluding != "" {
-				fixed = cleanVersion(match.VersionEndExcluding)
-			} else if match.VersionEndIncluding != "" {
-				var err error
-				// Infer the fixed version from the next version after.
-				fixed, err = nextVersion(validVersions, cleanVersion(match.VersionEndIncluding))
-				if err != nil {
-					notes = append(notes, err.Error())
-					// if that inference failed, we know this version was definitely still vulnerable.
-					lastaffected = cleanVersion(match.VersionEndIncluding)
-					notes = append(notes, fmt.Sprintf("Using %s as last_affected version instead", cleanVersion(match.VersionEndIncluding)))
+	// The CVE JSON 5.x affected products are more authoritative than CPE configurations when present.
+	cve5Versions, cve5Notes := extractVersionsFromCVE5Affected(validVersions, cve.Affected)
+	notes = append(notes, cve5Notes...)
+	if err := strictVersionsError(id, cve5Notes, opts); err != nil {
+		return VersionInfo{}, dedupeNotes(notes), err
+	}
+	v.AffectedVersions = append(v.AffectedVersions, withSource(cve5Versions, SourceCVE5)...)
+	diag.CVE5Versions = len(cve5Versions)
+	gotVersions := len(cve5Versions) > 0
+	nodes := configurationNodes(cve)
+	if gotVersions {
+		nodes = nil
+	}
+	for _, node := range nodes {
+		var matches []CPEMatch
+		var platforms []string
+		switch node.Operator {
+		case "OR":
+			matches = node.CPEMatch
+		case "AND":
+			// AND nodes pair a vulnerable component with the (non-vulnerable) platform it must be
+			// running on, e.g. an application that is only vulnerable on a particular operating system.
+			for _, child := range node.Children {
+				for _, match := range child.CPEMatch {
+					matches = append(matches, match)
+					if !match.Vulnerable {
+						platforms = append(platforms, match.CPE23URI)
+					}
 				}
 			}
+		default:
+			diag.CPEMatches += len(node.CPEMatch)
+			diag.SkippedUnsupportedNodes += len(node.CPEMatch)
+			continue
+		}
 
-			if introduced == "" && fixed == "" {
+		diag.CPEMatches += len(matches)
+		for _, match := range matches {
+			if !match.Vulnerable {
+				diag.SkippedNotVulnerable++
 				continue
 			}
 
-			if introduced != "" && !hasVersion(validVersions, introduced) {
-				notes = append(notes, fmt.Sprintf("Warning: %s is not a valid introduced version", introduced))
+			cpe, cpeErr := ParseCPE(match.CPE23URI)
+			if cpeErr == nil && !hasMeaningfulVersions(cpe) {
+				diag.SkippedNonApplication++
+				notes = append(notes, fmt.Sprintf("Skipping %s: versions of operating system and hardware CPEs are not inferred", match.CPE23URI))
+				continue
 			}
 
-			if fixed != "" && !hasVersion(validVersions, fixed) {
-				notes = append(notes, fmt.Sprintf("Warning: %s is not a valid fixed version", fixed))
+			possibleNewAffectedVersion, matchNotes, ok := cpeMatchAffectedVersion(match, validVersions, opts)
+			notes = append(notes, matchNotes...)
+			if err := strictVersionsError(id, matchNotes, opts); err != nil {
+				return VersionInfo{}, dedupeNotes(notes), err
+			}
+			if !ok {
+				diag.SkippedNoVersionRange++
+				continue
 			}
 
+			possibleNewAffectedVersion.Source = SourceCPE
+			if cpeErr == nil {
+				possibleNewAffectedVersion.TargetSW = cpeTargetSW(cpe)
+				possibleNewAffectedVersion.Edition = cpeEdition(cpe)
+				if possibleNewAffectedVersion.Edition != "" {
+					notes = append(notes, fmt.Sprintf("%s only applies to edition %s", match.CPE23URI, possibleNewAffectedVersion.Edition))
+				}
+			}
+			diag.UsedCPEMatches++
 			gotVersions = true
-			possibleNewAffectedVersion := AffectedVersion{
-				Introduced:   introduced,
-				Fixed:        fixed,
-				LastAffected: lastaffected,
+			if len(platforms) > 0 {
+				notes = append(notes, fmt.Sprintf("%s is only affected when running on %s", match.CPE23URI, strings.Join(platforms, ", ")))
 			}
-			if slices.Contains(v.AffectedVersions, possibleNewAffectedVersion) {
+			if containsAffectedVersion(v.AffectedVersions, possibleNewAffectedVersion) {
 				// Avoid appending duplicates
 				continue
 			}
 			v.AffectedVersions = append(v.AffectedVersions, possibleNewAffectedVersion)
 		}
 	}
-	if !gotVersions {
+	// Tags referenced as fixes are only used in the absence of CPE version ranges.
+	if !gotVersions && len(tagVersions) > 0 {
+		v.AffectedVersions = tagVersions
+		diag.ReferenceTagVersions = len(tagVersions)
+		gotVersions = true
+	}
+	if opts.CSAFFetcher != nil {
+		for _, reference := range cve.CVE.References.ReferenceData {
+			if !IsCSAFURL(reference.URL) {
+				continue
+			}
+			content, err := opts.CSAFFetcher(reference.URL)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("Unable to fetch %s: %v", reference.URL, err))
+				continue
+			}
+			csafVersions, csafNotes, err := ExtractCSAFVersions([]byte(content), cve.CVE.CVEDataMeta.ID, validVersions)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("Unable to parse %s: %v", reference.URL, err))
+				continue
+			}
+			notes = append(notes, csafNotes...)
+			if err := strictVersionsError(id, csafNotes, opts); err != nil {
+				return VersionInfo{}, dedupeNotes(notes), err
+			}
+			for _, version := range withSource(csafVersions, SourceCSAF) {
+				if !containsAffectedVersion(v.AffectedVersions, version) {
+					notes = append(notes, fmt.Sprintf("Using %+v from the CSAF document %s", version, reference.URL))
+					v.AffectedVersions = append(v.AffectedVersions, version)
+					diag.CSAFVersions++
+				}
+			}
+		}
+		gotVersions = gotVersions || diag.CSAFVersions > 0
+	}
+	if !gotVersions && opts.ChangelogFetcher != nil {
+		for _, reference := range cve.CVE.References.ReferenceData {
+			rawURL, ok := ChangelogRawURL(reference.URL)
+			if !ok {
+				continue
+			}
+			content, err := opts.ChangelogFetcher(rawURL)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("Unable to fetch %s: %v", rawURL, err))
+				continue
+			}
+			fixed, changelogNotes, ok := ExtractChangelogVersion(content, cve.CVE.CVEDataMeta.ID, validVersions)
+			notes = append(notes, changelogNotes...)
+			if err := strictVersionsError(id, changelogNotes, opts); err != nil {
+				return VersionInfo{}, dedupeNotes(notes), err
+			}
+			version := AffectedVersion{Fixed: fixed, Source: SourceChangelog}
+			if ok && !containsAffectedVersion(v.AffectedVersions, version) {
+				notes = append(notes, fmt.Sprintf("Using %s from the changelog %s as fixed version", fixed, reference.URL))
+				v.AffectedVersions = append(v.AffectedVersions, version)
+			}
+		}
+		diag.ChangelogVersions = len(v.AffectedVersions)
+		gotVersions = len(v.AffectedVersions) > 0
+	}
+	if !gotVersions && opts.ReferenceFetcher != nil {
+		for _, reference := range cve.CVE.References.ReferenceData {
+			if extractGitCommit(reference.URL, forges) != nil {
+				continue
+			}
+			if rawURL, ok := GitLabAdvisoryJSON(reference.URL); ok {
+				content, err := opts.ReferenceFetcher(rawURL)
+				if err != nil {
+					notes = append(notes, fmt.Sprintf("Unable to fetch %s: %v", rawURL, err))
+					continue
+				}
+				advisoryVersions, advisoryNotes, err := extractVersionsFromAdvisoryJSON(validVersions, []byte(content))
+				if err != nil {
+					notes = append(notes, fmt.Sprintf("Unable to parse %s: %v", rawURL, err))
+					continue
+				}
+				notes = append(notes, advisoryNotes...)
+				if err := strictVersionsError(id, advisoryNotes, opts); err != nil {
+					return VersionInfo{}, dedupeNotes(notes), err
+				}
+				for _, version := range withSource(advisoryVersions, SourceAdvisory) {
+					if !containsAffectedVersion(v.AffectedVersions, version) {
+						notes = append(notes, fmt.Sprintf("Using %+v from the GitLab advisory %s", version, reference.URL))
+						v.AffectedVersions = append(v.AffectedVersions, version)
+					}
+				}
+				continue
+			}
+			content, err := opts.ReferenceFetcher(reference.URL)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("Unable to fetch %s: %v", reference.URL, err))
+				continue
+			}
+			var contentVersions []AffectedVersion
+			var contentNotes []string
+			if opts.ScrapeHTMLReferences && looksLikeHTML(content) {
+				contentVersions, contentNotes, err = ExtractVersionsFromHTML(strings.NewReader(content), validVersions)
+				if err != nil {
+					notes = append(notes, fmt.Sprintf("Unable to parse %s: %v", reference.URL, err))
+					continue
+				}
+			} else {
+				contentVersions, contentNotes = extractVersionsFromReferenceContent(content)
+			}
+			notes = append(notes, contentNotes...)
+			if err := strictVersionsError(id, contentNotes, opts); err != nil {
+				return VersionInfo{}, dedupeNotes(notes), err
+			}
+			for _, scraper := range opts.ReferenceScrapers {
+				scrapedVersions, scrapedNotes := scraper(content, validVersions)
+				notes = append(notes, scrapedNotes...)
+				if err := strictVersionsError(id, scrapedNotes, opts); err != nil {
+					return VersionInfo{}, dedupeNotes(notes), err
+				}
+				for _, version := range scrapedVersions {
+					// A scraped version supersedes one otherwise found with the same fixed version.
+					if i := slices.IndexFunc(contentVersions, func(v AffectedVersion) bool {
+						return version.Fixed != "" && v.Fixed == version.Fixed
+					}); i != -1 {
+						contentVersions[i] = version
+					} else if !containsAffectedVersion(contentVersions, version) {
+						contentVersions = append(contentVersions, version)
+					}
+				}
+			}
+			for _, version := range withSource(contentVersions, SourceReferenceContent) {
+				if !containsAffectedVersion(v.AffectedVersions, version) {
+					notes = append(notes, fmt.Sprintf("Using %+v from the content of %s", version, reference.URL))
+					v.AffectedVersions = append(v.AffectedVersions, version)
+				}
+			}
+		}
+		diag.ReferenceContentVersions = len(v.AffectedVersions)
+		gotVersions = len(v.AffectedVersions) > 0
+	}
+	if !gotVersions && cve.Unanalyzed() {
+		notes = append(notes, fmt.Sprintf("Not extracting versions from the description of a CVE with status %q", cve.VulnStatus))
+	} else if !gotVersions {
 		var extractNotes []string
-		v.AffectedVersions, extractNotes = extractVersionsFromDescription(validVersions, EnglishDescription(cve.CVE))
+		v.AffectedVersions, extractNotes = extractVersionsFromDescription(validVersions, EnglishDescription(cve.CVE), opts.VersionComparator)
+		v.AffectedVersions = withSource(v.AffectedVersions, SourceDescription)
 		notes = append(notes, extractNotes...)
+		if err := strictVersionsError(id, extractNotes, opts); err != nil {
+			return VersionInfo{}, dedupeNotes(notes), err
+		}
+		diag.DescriptionFallbackRan = true
+		for _, commit := range extractGitCommitsFromDescription(EnglishDescription(cve.CVE)) {
+			if !slices.Contains(v.FixCommits, commit) && !slices.Contains(v.IntroducedCommits, commit) {
+				notes = append(notes, fmt.Sprintf("Using commit %s in %s mentioned in the description as a fix", commit.Commit, commit.Repo))
+				v.FixCommits = append(v.FixCommits, commit)
+			}
+		}
+		diag.DescriptionVersionsFound = len(v.AffectedVersions)
 		if len(v.AffectedVersions) > 0 {
 			log.Printf("[%s] Extracted versions from description = %+v", cve.CVE.CVEDataMeta.ID, v.AffectedVersions)
 		}
 	}
 
-	if len(v.AffectedVersions) == 0 {
-		notes = append(notes, "No versions detected.")
-	}
+	v.AffectedVersions = dedupeAffectedVersions(v.AffectedVersions)
 
-	if len(notes) != 0 && len(validVersions) > 0 {
-		notes = append(notes, "Valid versions:")
-		for _, version := range validVersions {
-			notes = append(notes, "  - "+version)
+	if year, ok := publishedYear(cve); ok {
+		for _, version := range v.AffectedVersions {
+			for _, extracted := range []string{version.Introduced, version.Fixed, version.LastAffected} {
+				if extracted != "" && !PlausibleCalVer(extracted, year) {
+					notes = append(notes, fmt.Sprintf("Warning: %s is implausible as a calendar version for a CVE published in %d", extracted, year))
+				}
+			}
 		}
 	}
-	return v, notes
-}
 
-func CPEs(cve CVEItem) []string {
-	var cpes []string
-	for _, node := range cve.Configurations.Nodes {
-		for _, match := range node.CPEMatch {
-			cpes = append(cpes, match.CPE23URI)
+	// Per the OSV convention, a repository with a fix but no known introducing commit is affected from its
+	// first commit, denoted by "0", unless IntroducedCommitResolver finds the commit introducing the fix.
+	var introducedCommits []GitCommit
+	for _, fix := range v.FixCommits {
+		if slices.IndexFunc(v.IntroducedCommits, func(introduced GitCommit) bool { return introduced.Repo == fix.Repo }) != -1 {
+			continue
+		}
+		introduced := ""
+		if opts.IntroducedCommitResolver != nil {
+			var err error
+			introduced, err = opts.IntroducedCommitResolver(fix)
+			switch {
+			case err != nil:
+				notes = append(notes, fmt.Sprintf("Unable to determine the commit introducing what %s fixed in %s, treating it as introduced at the first commit: %v", fix.Commit, fix.Repo, err))
+			case introduced == "":
+				notes = append(notes, fmt.Sprintf("No commit found introducing what %s fixed in %s, treating it as introduced at the first commit", fix.Commit, fix.Repo))
+			}
+		}
+		if introduced == "" {
+			introduced = "0"
 		}
+		introducedCommit := GitCommit{Repo: fix.Repo, Commit: introduced}
+		if slices.Contains(introducedCommits, introducedCommit) {
+			continue
+		}
+		if introduced != "0" {
+			notes = append(notes, fmt.Sprintf("Using commit %s in %s, which introduced what %s fixed, as introduced", introduced, fix.Repo, fix.Commit))
+		}
+		introducedCommits = append(introducedCommits, introducedCommit)
 	}
-
-	return cpes
-}
-
-// There are some weird and wonderful rules about quoting with strings in CPEs
-// See 5.3.2 of NISTIR 7695 for more details
-// https://nvlpubs.nist.gov/nistpubs/Legacy/IR/nistir7695.pdf
-func RemoveQuoting(s string) (result string) {
-	return strings.Replace(s, "\\", "", -1)
-}
-
-// Parse a well-formed CPE string into a struct.
-func ParseCPE(formattedString string) (*CPE, error) {
-	if !strings.HasPrefix(formattedString, "cpe:") {
-		return nil, fmt.Errorf("%q does not have expected 'cpe:' prefix", formattedString)
+	v.IntroducedCommits = append(v.IntroducedCommits, introducedCommits...)
+	if opts.ChangedFilesFetcher != nil {
+		for _, commit := range v.FixCommits {
+			files, err := opts.ChangedFilesFetcher(commit)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("Unable to determine the files changed by %s in %s: %v", commit.Commit, commit.Repo, err))
+				continue
+			}
+			if v.ChangedFiles == nil {
+				v.ChangedFiles = make(map[GitCommit][]string)
+			}
+			v.ChangedFiles[commit] = files
+		}
 	}
 
-	wfn, err := naming.UnbindFS(formattedString)
+	if len(v.AffectedVersions) > 0 {
+		notes = append(notes, v.RepoConsistencyNotes(CPEs(cve))...)
+	}
+	if len(v.AffectedVersions) == 0 {
+		notes = append(notes, fmt.Sprintf("No versions detected: %s.", diag.reason()))
+	}
+	if opts.Diagnostics != nil {
+		*opts.Diagnostics = diag
+	}
 
-	if err != nil {
-		return nil, err
-	}
-
-	return &CPE{
-		CPEVersion: strings.Split(formattedString, ":")[1],
-		Part:       wfn.GetString("part"),
-		Vendor:     RemoveQuoting(wfn.GetString("vendor")),
-		Product:    RemoveQuoting(wfn.GetString("product")),
-		Version:    RemoveQuoting(wfn.GetString("version")),
-		Update:     wfn.GetString("update"),
-		Edition:    wfn.GetString("edition"),
-		Language:   wfn.GetString("language"),
-		SWEdition:  wfn.GetString("sw_edition"),
-		TargetSW:   wfn.GetString("target_sw"),
-		TargetHW:   wfn.GetString("target_hw"),
-		Other:      wfn.GetString("other")}, nil
-}
-
-// Normalize version strings found in CVE CPE Match data or Git tags.
-// Use the same logic and behaviour as normalize_tag() osv/bug.py for consistency.
-func NormalizeVersion(version string) (normalizedVersion string, e error) {
-	// Keep in sync with the intent of https://github.com/google/osv.dev/blob/26050deb42785bc5a4dc7d802eac8e7f95135509/osv/bug.py#L31
-	var validVersion = regexp.MustCompile(`(?i)(\d+|(?:rc|alpha|beta|preview)\d*)`)
-	var validVersionText = regexp.MustCompile(`(?i)(?:rc|alpha|beta|preview)\d*`)
-	components := validVersion.FindAllString(version, -1)
-	if components == nil {
-		return "", fmt.Errorf("%q is not a supported version", version)
-	}
-	// If the very first component happens to accidentally match the strings we support, remove it.
-	// This is necessary because of the lack of negative lookbehind assertion support in RE2.
-	if validVersionText.MatchString(components[0]) {
-		components = slices.Delete(components, 0, 1)
-	}
-	normalizedVersion = strings.Join(components, "-")
-	return normalizedVersion, e
+	notes = dedupeNotes(notes)
+	if len(notes) != 0 && len(validVersions) > 0 {
+		notes = append(notes, "Valid versions:")
+		for _, version := range validVersions {
+			notes = append(notes, "  - "+version)
+		}
+	}
+	return v, notes, nil
 }
@@ -2,12 +2,21 @@ package cves
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/exp/slices"
 )
 
 // Helper function to load in a specific CVE from sample data.
@@ -27,6 +36,22 @@ func loadTestData(CVEID string) CVEItem {
 	return CVEItem{}
 }
 
+// Helper function to construct a CVEItem from inline JSON.
+// ignoreSource disregards where AffectedVersions were extracted from, for tests concerned with the versions alone.
+var ignoreSource = cmpopts.IgnoreFields(AffectedVersion{}, "Source")
+
+// ignoreNotes disregards the notes on AffectedVersions, for tests concerned with the versions alone.
+var ignoreNotes = cmpopts.IgnoreFields(AffectedVersion{}, "Notes")
+
+func cveItemFromJSON(t *testing.T, data string) CVEItem {
+	t.Helper()
+	var item CVEItem
+	if err := json.Unmarshal([]byte(data), &item); err != nil {
+		t.Fatalf("Failed to parse test CVE JSON: %v", err)
+	}
+	return item
+}
+
 func TestParseCPE(t *testing.T) {
 	tests := []struct {
 		description       string
@@ -141,6 +166,132 @@ func TestParseCPE(t *testing.T) {
 	}
 }
 
+func TestEcosystemFromCPE(t *testing.T) {
+	tests := []struct {
+		description       string
+		inputCPEString    string
+		expectedEcosystem string
+		expectedOk        bool
+	}{
+		{
+			description:       "Python package",
+			inputCPEString:    "cpe:2.3:a:palletsprojects:flask:*:*:*:*:*:python:*:*",
+			expectedEcosystem: "PyPI",
+			expectedOk:        true,
+		},
+		{
+			description:       "Node.js package",
+			inputCPEString:    "cpe:2.3:a:lodash:lodash:*:*:*:*:*:node.js:*:*",
+			expectedEcosystem: "npm",
+			expectedOk:        true,
+		},
+		{
+			description:       "Jenkins plugin",
+			inputCPEString:    "cpe:2.3:a:jenkins:git:*:*:*:*:*:jenkins:*:*",
+			expectedEcosystem: "Maven",
+			expectedOk:        true,
+		},
+		{
+			description:       "Operating system",
+			inputCPEString:    "cpe:2.3:o:debian:debian_linux:10.0:*:*:*:*:*:*:*",
+			expectedEcosystem: "Debian",
+			expectedOk:        true,
+		},
+		{
+			description:       "Linux kernel",
+			inputCPEString:    "cpe:2.3:o:linux:linux_kernel:*:*:*:*:*:*:*:*",
+			expectedEcosystem: "Linux",
+			expectedOk:        true,
+		},
+		{
+			description:       "Undeterminable application",
+			inputCPEString:    "cpe:2.3:a:gitlab:gitlab:*:*:*:*:community:*:*:*",
+			expectedEcosystem: "",
+			expectedOk:        false,
+		},
+		{
+			description:       "Hardware",
+			inputCPEString:    "cpe:2.3:h:intel:core_i3-1005g1:-:*:*:*:*:*:*:*",
+			expectedEcosystem: "",
+			expectedOk:        false,
+		},
+	}
+
+	for _, tc := range tests {
+		cpe, err := ParseCPE(tc.inputCPEString)
+		if err != nil {
+			t.Fatalf("test %q: ParseCPE for %q unexpectedly failed: %+v", tc.description, tc.inputCPEString, err)
+		}
+		got, ok := EcosystemFromCPE(cpe)
+		if ok != tc.expectedOk {
+			t.Errorf("test %q: EcosystemFromCPE for %q returned ok: %t, expected: %t", tc.description, tc.inputCPEString, ok, tc.expectedOk)
+		}
+		if got != tc.expectedEcosystem {
+			t.Errorf("test %q: EcosystemFromCPE for %q was incorrect, got: %q, expected: %q", tc.description, tc.inputCPEString, got, tc.expectedEcosystem)
+		}
+	}
+}
+
+func TestSanitizeReferenceURL(t *testing.T) {
+	tests := []struct {
+		description string
+		inputURL    string
+		expectedURL string
+	}{
+		{
+			description: "Already clean URL",
+			inputURL:    "https://github.com/google/osv.dev",
+			expectedURL: "https://github.com/google/osv.dev",
+		},
+		{
+			description: "www-prefixed repository host",
+			inputURL:    "https://www.github.com/google/osv.dev",
+			expectedURL: "https://github.com/google/osv.dev",
+		},
+		{
+			description: "www-prefixed other host",
+			inputURL:    "https://www.example.com/advisory",
+			expectedURL: "https://www.example.com/advisory",
+		},
+		{
+			description: "Fully qualified host with a port",
+			inputURL:    "https://gitlab.example.com.:8443/group/project",
+			expectedURL: "https://gitlab.example.com:8443/group/project",
+		},
+		{
+			description: "Tracking query parameters and fragment",
+			inputURL:    "https://github.com/google/osv.dev/releases?utm_source=twitter&utm_medium=social&fbclid=abc#latest",
+			expectedURL: "https://github.com/google/osv.dev/releases",
+		},
+		{
+			description: "Tracking query parameters among meaningful ones",
+			inputURL:    "https://bitbucket.org/snakeyaml/snakeyaml/downloads/?utm_campaign=foo&tab=tags",
+			expectedURL: "https://bitbucket.org/snakeyaml/snakeyaml/downloads/?tab=tags",
+		},
+		{
+			description: "Mixed case scheme and host with trailing slashes",
+			inputURL:    "HTTPS://GitHub.com/pyca/pyopenssl//",
+			expectedURL: "https://github.com/pyca/pyopenssl",
+		},
+		{
+			description: "cGit commit URL keeps its trailing slash",
+			inputURL:    "https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			expectedURL: "https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+		},
+		{
+			description: "GitWeb query separators are preserved",
+			inputURL:    "https://git.gnupg.org/cgi-bin/gitweb.cgi?p=libksba.git;a=commit;h=f61a5ea4e0f6a80fd4b28ef0174bee77793cf070",
+			expectedURL: "https://git.gnupg.org/cgi-bin/gitweb.cgi?p=libksba.git;a=commit;h=f61a5ea4e0f6a80fd4b28ef0174bee77793cf070",
+		},
+	}
+
+	for _, tc := range tests {
+		if got := SanitizeReferenceURL(tc.inputURL); got != tc.expectedURL {
+			t.Errorf("test %q: SanitizeReferenceURL(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputURL, got, tc.expectedURL)
+		}
+	}
+}
+
 func TestRepo(t *testing.T) {
 	tests := []struct {
 		description     string // human-readable description of test case
@@ -148,6 +299,36 @@ func TestRepo(t *testing.T) {
 		expectedRepoURL string // The expected  repository URL to get back from Repo()
 		expectedOk      bool   // If an error is expected
 	}{
+		{
+			description:     "GitHub scp-like SSH URL",
+			inputLink:       "git@github.com:kovidgoyal/kitty.git",
+			expectedRepoURL: "https://github.com/kovidgoyal/kitty",
+			expectedOk:      true,
+		},
+		{
+			description:     "GitLab scp-like SSH URL",
+			inputLink:       "git@gitlab.com:mayan-edms/mayan-edms.git",
+			expectedRepoURL: "https://gitlab.com/mayan-edms/mayan-edms",
+			expectedOk:      true,
+		},
+		{
+			description:     "GitLab scp-like SSH URL in a nested subgroup",
+			inputLink:       "git@gitlab.com:gitlab-org/security/gitaly.git",
+			expectedRepoURL: "https://gitlab.com/gitlab-org/security/gitaly",
+			expectedOk:      true,
+		},
+		{
+			description:     "Bitbucket scp-like SSH URL",
+			inputLink:       "git@bitbucket.org:openpyxl/openpyxl.git",
+			expectedRepoURL: "https://bitbucket.org/openpyxl/openpyxl",
+			expectedOk:      true,
+		},
+		{
+			description:     "scp-like SSH URL for an unknown host",
+			inputLink:       "git@git.example.com:foo/bar.git",
+			expectedRepoURL: "",
+			expectedOk:      false,
+		},
 		{
 			description:     "GitHub compare URL",
 			inputLink:       "https://github.com/kovidgoyal/kitty/compare/v0.26.1...v0.26.2",
@@ -190,6 +371,42 @@ func TestRepo(t *testing.T) {
 			expectedRepoURL: "https://github.com/apache/activemq-artemis",
 			expectedOk:      true,
 		},
+		{
+			description:     "gitbox.apache.org commit URL",
+			inputLink:       "https://gitbox.apache.org/repos/asf?p=commons-text.git;a=commit;h=b9b40b903e2d1f9935039803c9852439576780ea",
+			expectedRepoURL: "https://gitbox.apache.org/repos/asf/commons-text.git",
+			expectedOk:      true,
+		},
+		{
+			description:     "sourceware.org GitWeb commit URL",
+			inputLink:       "https://sourceware.org/git/?p=glibc.git;a=commit;h=6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c",
+			expectedRepoURL: "https://sourceware.org/git/glibc.git",
+			expectedOk:      true,
+		},
+		{
+			description:     "GitHub repository without a scheme",
+			inputLink:       "github.com/owner/repo",
+			expectedRepoURL: "https://github.com/owner/repo",
+			expectedOk:      true,
+		},
+		{
+			description:     "GitLab repository in a subgroup without a scheme",
+			inputLink:       "gitlab.com/group/sub/proj",
+			expectedRepoURL: "https://gitlab.com/group/sub/proj",
+			expectedOk:      true,
+		},
+		{
+			description:     "Exact GitLab repository URL",
+			inputLink:       "https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium/",
+			expectedRepoURL: "https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium",
+			expectedOk:      true,
+		},
+		{
+			description:     "Unknown host without a scheme",
+			inputLink:       "example.com/owner/repo",
+			expectedRepoURL: "",
+			expectedOk:      false,
+		},
 		{
 			description:     "Freedesktop cGit mirror",
 			inputLink:       "https://cgit.freedesktop.org/xorg/lib/libXRes/commit/?id=c05c6d918b0e2011d4bfa370c321482e34630b17",
@@ -266,6 +483,168 @@ func TestRepo(t *testing.T) {
 			expectedRepoURL: "https://bitbucket.org/snakeyaml/snakeyaml",
 			expectedOk:      true,
 		},
+		{
+			description:     "Gitiles commit URL",
+			inputLink:       "https://chromium.googlesource.com/chromium/src/+/8f4d6a1d5e9c6b4c2ee0b5bd1e5a4ec5c6a0d0f1",
+			expectedRepoURL: "https://chromium.googlesource.com/chromium/src",
+			expectedOk:      true,
+		},
+		{
+			description:     "Gitiles tag URL",
+			inputLink:       "https://chromium.googlesource.com/v8/v8/+/refs/tags/10.2.154.4",
+			expectedRepoURL: "https://chromium.googlesource.com/v8/v8",
+			expectedOk:      true,
+		},
+		{
+			description:     "cGit tag URL",
+			inputLink:       "https://git.zx2c4.com/cgit/tag/?h=v1.2.3",
+			expectedRepoURL: "https://git.zx2c4.com/cgit",
+			expectedOk:      true,
+		},
+		{
+			description:     "cGit branch log URL",
+			inputLink:       "https://git.dpkg.org/cgit/dpkg/dpkg.git/log/?h=refs/heads/main",
+			expectedRepoURL: "https://git.dpkg.org/cgit/dpkg/dpkg.git",
+			expectedOk:      true,
+		},
+		{
+			description:     "pagure.io commit URL",
+			inputLink:       "https://pagure.io/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede",
+			expectedRepoURL: "https://pagure.io/libaio",
+			expectedOk:      true,
+		},
+		{
+			description:     "Namespaced pagure.io commit URL",
+			inputLink:       "https://pagure.io/rpms/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede?branch=master",
+			expectedRepoURL: "https://pagure.io/rpms/libaio",
+			expectedOk:      true,
+		},
+		{
+			description:     "pagure.io issue URL",
+			inputLink:       "https://pagure.io/freeipa/issue/9001",
+			expectedRepoURL: "https://pagure.io/freeipa",
+			expectedOk:      true,
+		},
+		{
+			description:     "Exact pagure.io repository URL",
+			inputLink:       "https://pagure.io/libaio",
+			expectedRepoURL: "https://pagure.io/libaio",
+			expectedOk:      true,
+		},
+		{
+			description:     "cGit patch URL",
+			inputLink:       "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/patch/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe",
+			expectedRepoURL: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+			expectedOk:      true,
+		},
+		{
+			description:     "ViewVC revision URL on svn.apache.org",
+			inputLink:       "https://svn.apache.org/viewvc?view=revision&revision=1790000",
+			expectedRepoURL: "https://svn.apache.org/repos/asf",
+			expectedOk:      true,
+		},
+		{
+			description:     "ViewVC revision URL with a root",
+			inputLink:       "https://svn.example.org/viewvc/project/trunk/?view=rev&rev=4321",
+			expectedRepoURL: "https://svn.example.org/viewvc/project",
+			expectedOk:      true,
+		},
+		{
+			description:     "WebSVN revision URL",
+			inputLink:       "https://websvn.kde.org/revision.php?repname=kde&rev=1234567",
+			expectedRepoURL: "https://websvn.kde.org/listing.php?repname=kde",
+			expectedOk:      true,
+		},
+		{
+			description:     "svn.code.sf.net path",
+			inputLink:       "https://svn.code.sf.net/p/netpbm/code/trunk/converter/other/pnmtopng.c",
+			expectedRepoURL: "https://svn.code.sf.net/p/netpbm/code",
+			expectedOk:      true,
+		},
+		{
+			description:     "cGit commit URL without a trailing slash",
+			inputLink:       "https://git.dpkg.org/cgit/dpkg/dpkg.git/commit?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			expectedRepoURL: "https://git.dpkg.org/cgit/dpkg/dpkg.git",
+			expectedOk:      true,
+		},
+		{
+			description:     "cGit patch URL without a trailing slash",
+			inputLink:       "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/patch?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe",
+			expectedRepoURL: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+			expectedOk:      true,
+		},
+		{
+			description:     "cgit.freedesktop.org commit URL without a trailing slash",
+			inputLink:       "https://cgit.freedesktop.org/xorg/lib/libXRes/commit?id=c05c6d918b0e2011d4bfa370c321482e34630b17",
+			expectedRepoURL: "https://gitlab.freedesktop.org/xorg/lib/libXRes",
+			expectedOk:      true,
+		},
+		{
+			description:     "cGit diff URL",
+			inputLink:       "https://git.dpkg.org/cgit/dpkg/dpkg.git/diff/?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			expectedRepoURL: "https://git.dpkg.org/cgit/dpkg/dpkg.git",
+			expectedOk:      true,
+		},
+		{
+			description:     "GitHub commit URL with tracking noise",
+			inputLink:       "https://github.com/google/osv.dev/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5?utm_source=nvd#diff-1",
+			expectedRepoURL: "https://github.com/google/osv.dev",
+			expectedOk:      true,
+		},
+		{
+			description:     "www-prefixed GitHub commit URL",
+			inputLink:       "https://www.github.com/google/osv.dev/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5",
+			expectedRepoURL: "https://github.com/google/osv.dev",
+			expectedOk:      true,
+		},
+		{
+			description:     "Fully qualified GitHub repository URL",
+			inputLink:       "https://github.com./google/osv.dev",
+			expectedRepoURL: "https://github.com/google/osv.dev",
+			expectedOk:      true,
+		},
+		{
+			description:     "www-prefixed and fully qualified GitLab commit URL",
+			inputLink:       "https://www.gitlab.com./qemu-project/qemu/-/commit/4367a20cc4",
+			expectedRepoURL: "https://gitlab.com/qemu-project/qemu",
+			expectedOk:      true,
+		},
+		{
+			description:     "Scheme-less www-prefixed GitHub repository",
+			inputLink:       "www.github.com/google/osv.dev",
+			expectedRepoURL: "https://github.com/google/osv.dev",
+			expectedOk:      true,
+		},
+		{
+			description:     "GitLab tag URL",
+			inputLink:       "https://gitlab.com/libtiff/libtiff/-/tags/v4.5.0",
+			expectedRepoURL: "https://gitlab.com/libtiff/libtiff",
+			expectedOk:      true,
+		},
+		{
+			description:     "GitLab release URL in a subgroup",
+			inputLink:       "https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium/-/releases/v2.30.1",
+			expectedRepoURL: "https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium",
+			expectedOk:      true,
+		},
+		{
+			description:     "Gitea file at commit URL",
+			inputLink:       "https://codeberg.org/forgejo/forgejo/src/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70/routers/web/repo/view.go",
+			expectedRepoURL: "https://codeberg.org/forgejo/forgejo",
+			expectedOk:      true,
+		},
+		{
+			description:     "SourceForge commit URL",
+			inputLink:       "https://sourceforge.net/p/libpng/code/ci/a901eb3ce6087e0afeef988247f1a1aa208cb54d/",
+			expectedRepoURL: "https://sourceforge.net/p/libpng/code",
+			expectedOk:      true,
+		},
+		{
+			description:     "SourceForge git mount commit URL",
+			inputLink:       "https://sourceforge.net/p/net-snmp/git/ci/4fd9a450444a434a993bf591f95c4e7bf8ed3ea3",
+			expectedRepoURL: "https://sourceforge.net/p/net-snmp/git",
+			expectedOk:      true,
+		},
 		{
 			description:     "Valid URL but not wanted (by denylist)",
 			inputLink:       "https://github.com/orangecertcc/security-research/security/advisories/GHSA-px2c-q384-5wxc",
@@ -300,6 +679,83 @@ func TestRepo(t *testing.T) {
 		if !reflect.DeepEqual(got, tc.expectedRepoURL) {
 			t.Errorf("test %q: Repo(%q) was incorrect, got: %#v, expected: %#v", tc.description, tc.inputLink, got, tc.expectedRepoURL)
 		}
+		// Commit URLs lead back to the repository and commit, except for Subversion revisions.
+		commit, err := Commit(tc.inputLink)
+		if err != nil || !tc.expectedOk {
+			continue
+		}
+		if u, err := url.Parse(tc.inputLink); err == nil {
+			if _, ok := svnRevision(u); ok {
+				continue
+			}
+		}
+		commitURL, err := CommitURL(GitCommit{Repo: got, Commit: commit})
+		if err != nil {
+			t.Errorf("test %q: CommitURL() of %q unexpectedly failed: %v", tc.description, tc.inputLink, err)
+			continue
+		}
+		if repo, _ := Repo(commitURL); repo != strings.TrimSuffix(got, ".git") && repo != got {
+			t.Errorf("test %q: Repo(%q) was incorrect, got: %q, expected: %q", tc.description, commitURL, repo, got)
+		}
+		if gotCommit, _ := Commit(commitURL); gotCommit != commit {
+			t.Errorf("test %q: Commit(%q) was incorrect, got: %q, expected: %q", tc.description, commitURL, gotCommit, commit)
+		}
+	}
+}
+
+func TestRepoCloneURL(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputLink        string
+		expectedCloneURL string
+		expectedOk       bool
+	}{
+		{
+			description:      "GitHub issue URL",
+			inputLink:        "https://github.com/axiomatic-systems/Bento4/issues/755",
+			expectedCloneURL: "https://github.com/axiomatic-systems/Bento4.git",
+			expectedOk:       true,
+		},
+		{
+			description:      "GitLab commit URL",
+			inputLink:        "https://gitlab.com/qemu-project/qemu/-/commit/4367a20cc4",
+			expectedCloneURL: "https://gitlab.com/qemu-project/qemu.git",
+			expectedOk:       true,
+		},
+		{
+			description:      "Bitbucket commit URL",
+			inputLink:        "https://bitbucket.org/openpyxl/openpyxl/commits/3b4905f428e1",
+			expectedCloneURL: "https://bitbucket.org/openpyxl/openpyxl.git",
+			expectedOk:       true,
+		},
+		{
+			description:      "cGit commit URL",
+			inputLink:        "https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			expectedCloneURL: "https://git.dpkg.org/cgit/dpkg/dpkg.git",
+			expectedOk:       true,
+		},
+		{
+			description:      "SourceForge commit URL",
+			inputLink:        "https://sourceforge.net/p/libpng/code/ci/a901eb3ce6087e0afeef988247f1a1aa208cb54d/",
+			expectedCloneURL: "https://git.code.sf.net/p/libpng/code",
+			expectedOk:       true,
+		},
+		{
+			description:      "Unsupported URL",
+			inputLink:        "https://example.com/advisory",
+			expectedCloneURL: "",
+			expectedOk:       false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := RepoCloneURL(tc.inputLink)
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: RepoCloneURL(%q) unexpectedly failed: %+v", tc.description, tc.inputLink, err)
+		}
+		if got != tc.expectedCloneURL {
+			t.Errorf("test %q: RepoCloneURL(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputLink, got, tc.expectedCloneURL)
+		}
 	}
 }
 
@@ -318,74 +774,540 @@ func TestExtractGitCommit(t *testing.T) {
 			},
 		},
 		{
-			description: "Valid GitLab commit URL",
-			inputLink:   "https://gitlab.freedesktop.org/virgl/virglrenderer/-/commit/b05bb61f454eeb8a85164c8a31510aeb9d79129c",
+			description: "GitHub tree URL of a monorepo package at a commit",
+			inputLink:   "https://github.com/owner/mono/tree/b1351c15946349f9daa7e5297fb2ac6f3139e4a8/packages/foo/",
 			expectedGitCommit: &GitCommit{
-				Repo:   "https://gitlab.freedesktop.org/virgl/virglrenderer",
-				Commit: "b05bb61f454eeb8a85164c8a31510aeb9d79129c",
+				Repo:   "https://github.com/owner/mono",
+				Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8",
+				Path:   "packages/foo",
 			},
 		},
 		{
-			description: "Valid GitLab.com commit URL",
-			inputLink:   "https://gitlab.com/mayan-edms/mayan-edms/commit/9ebe80595afe4fdd1e2c74358d6a9421f4ce130e",
+			description: "GitHub blob URL at a commit",
+			inputLink:   "https://github.com/owner/mono/blob/b1351c15946349f9daa7e5297fb2ac6f3139e4a8/packages/foo/index.js#L10",
 			expectedGitCommit: &GitCommit{
-				Repo:   "https://gitlab.com/mayan-edms/mayan-edms",
-				Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e",
+				Repo:   "https://github.com/owner/mono",
+				Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8",
+				Path:   "packages/foo/index.js",
 			},
 		},
 		{
-			description: "Valid bitbucket.org commit URL",
-			inputLink:   "https://bitbucket.org/openpyxl/openpyxl/commits/3b4905f428e1",
+			description: "GitHub tree URL of a whole commit",
+			inputLink:   "https://github.com/owner/mono/tree/b1351c15946349f9daa7e5297fb2ac6f3139e4a8",
 			expectedGitCommit: &GitCommit{
-				Repo:   "https://bitbucket.org/openpyxl/openpyxl",
-				Commit: "3b4905f428e1",
+				Repo:   "https://github.com/owner/mono",
+				Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8",
 			},
 		},
 		{
-			description: "Valid bitbucket.org commit URL with trailing slash",
-			inputLink:   "https://bitbucket.org/jespern/django-piston/commits/91bdaec89543/",
+			description:       "GitHub tree URL of a branch",
+			inputLink:         "https://github.com/owner/mono/tree/main/packages/foo",
+			expectedGitCommit: nil,
+		},
+		{
+			description: "GitLab blob URL at a commit",
+			inputLink:   "https://gitlab.com/gitlab-org/gitlab/-/blob/4367a20cc4/app/models/user.rb",
 			expectedGitCommit: &GitCommit{
-				Repo:   "https://bitbucket.org/jespern/django-piston",
-				Commit: "91bdaec89543",
+				Repo:   "https://gitlab.com/gitlab-org/gitlab",
+				Commit: "4367a20cc4",
+				Path:   "app/models/user.rb",
 			},
 		},
 		{
-			description: "Valid cGit commit URL",
-			inputLink:   "https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			description: "Valid SourceForge commit URL with a trailing slash",
+			inputLink:   "https://sourceforge.net/p/libpng/code/ci/a901eb3ce6087e0afeef988247f1a1aa208cb54d/",
 			expectedGitCommit: &GitCommit{
-				Repo:   "https://git.dpkg.org/cgit/dpkg/dpkg.git",
-				Commit: "faa4c92debe45412bfcf8a44f26e827800bb24be",
+				Repo:   "https://sourceforge.net/p/libpng/code",
+				Commit: "a901eb3ce6087e0afeef988247f1a1aa208cb54d",
 			},
 		},
 		{
-			description: "Valid GitWeb commit URL",
-			inputLink:   "https://git.gnupg.org/cgi-bin/gitweb.cgi?p=libksba.git;a=commit;h=f61a5ea4e0f6a80fd4b28ef0174bee77793cf070",
+			description: "Valid SourceForge commit URL",
+			inputLink:   "https://sourceforge.net/p/net-snmp/git/ci/4fd9a450444a434a993bf591f95c4e7bf8ed3ea3",
 			expectedGitCommit: &GitCommit{
-				Repo:   "https://git.gnupg.org/libksba.git",
-				Commit: "f61a5ea4e0f6a80fd4b28ef0174bee77793cf070",
+				Repo:   "https://sourceforge.net/p/net-snmp/git",
+				Commit: "4fd9a450444a434a993bf591f95c4e7bf8ed3ea3",
 			},
 		},
 		{
-			description:       "Unsupported GitHub PR URL",
-			inputLink:         "https://github.com/google/osv/pull/123",
-			expectedGitCommit: nil,
+			description: "GitWeb commit URL with an encoded query",
+			inputLink:   "https://sourceware.org/git/?p=glibc.git%3Ba%3Dcommit%3Bh%3D6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://sourceware.org/git/glibc.git",
+				Commit: "6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c",
+			},
 		},
 		{
-			description:       "Unsupported GitHub tag URL",
-			inputLink:         "https://github.com/google/osv.dev/releases/tag/v0.0.14",
-			expectedGitCommit: nil,
+			description: "cGit commit URL with an encoded parameter and further parameters",
+			inputLink:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/commit/?id%3D817b8b9c5396d2b2d92311b46719aad5d3339dbe&context=10",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+				Commit: "817b8b9c5396d2b2d92311b46719aad5d3339dbe",
+			},
 		},
 		{
-			description:       "Completely invalid input",
-			inputLink:         "",
-			expectedGitCommit: nil,
+			description: "Valid Gitea commit URL",
+			inputLink:   "https://codeberg.org/forgejo/forgejo/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://codeberg.org/forgejo/forgejo",
+				Commit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			},
 		},
-	}
-
-	for _, tc := range tests {
-		got := extractGitCommit(tc.inputLink)
-		if !reflect.DeepEqual(got, tc.expectedGitCommit) {
-			t.Errorf("test %q: extractGitCommit for %q was incorrect, got: %#v, expected: %#v", tc.description, tc.inputLink, got, tc.expectedGitCommit)
+		{
+			description: "Valid Gitea file at commit URL",
+			inputLink:   "https://codeberg.org/forgejo/forgejo/src/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70/routers/web/repo/view.go",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://codeberg.org/forgejo/forgejo",
+				Commit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			},
+		},
+		{
+			description: "Valid Gitea raw file at commit URL",
+			inputLink:   "https://codeberg.org/forgejo/forgejo/raw/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70/routers/web/repo/view.go",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://codeberg.org/forgejo/forgejo",
+				Commit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			},
+		},
+		{
+			description:       "Gitea branch URL",
+			inputLink:         "https://codeberg.org/forgejo/forgejo/src/branch/main/routers/web/repo/view.go",
+			expectedGitCommit: nil,
+		},
+		{
+			description:       "SourceForge tree URL",
+			inputLink:         "https://sourceforge.net/p/libpng/code/ci/master/tree/",
+			expectedGitCommit: nil,
+		},
+		{
+			description: "Valid GitLab commit URL",
+			inputLink:   "https://gitlab.freedesktop.org/virgl/virglrenderer/-/commit/b05bb61f454eeb8a85164c8a31510aeb9d79129c",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://gitlab.freedesktop.org/virgl/virglrenderer",
+				Commit: "b05bb61f454eeb8a85164c8a31510aeb9d79129c",
+			},
+		},
+		{
+			description: "Valid GitLab.com commit URL",
+			inputLink:   "https://gitlab.com/mayan-edms/mayan-edms/commit/9ebe80595afe4fdd1e2c74358d6a9421f4ce130e",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://gitlab.com/mayan-edms/mayan-edms",
+				Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e",
+			},
+		},
+		{
+			description: "Valid bitbucket.org commit URL",
+			inputLink:   "https://bitbucket.org/openpyxl/openpyxl/commits/3b4905f428e1",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://bitbucket.org/openpyxl/openpyxl",
+				Commit: "3b4905f428e1",
+			},
+		},
+		{
+			description: "Valid bitbucket.org commit URL with trailing slash",
+			inputLink:   "https://bitbucket.org/jespern/django-piston/commits/91bdaec89543/",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://bitbucket.org/jespern/django-piston",
+				Commit: "91bdaec89543",
+			},
+		},
+		{
+			description: "Valid cGit commit URL",
+			inputLink:   "https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://git.dpkg.org/cgit/dpkg/dpkg.git",
+				Commit: "faa4c92debe45412bfcf8a44f26e827800bb24be",
+			},
+		},
+		{
+			description: "Valid cGit patch URL",
+			inputLink:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/patch/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+				Commit: "817b8b9c5396d2b2d92311b46719aad5d3339dbe",
+			},
+		},
+		{
+			description: "Valid ViewVC revision URL",
+			inputLink:   "https://svn.apache.org/viewvc?view=revision&revision=1790000",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://svn.apache.org/repos/asf",
+				Commit: "1790000",
+			},
+		},
+		{
+			description: "Valid WebSVN revision URL",
+			inputLink:   "https://websvn.kde.org/revision.php?repname=kde&rev=1234567",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://websvn.kde.org/listing.php?repname=kde",
+				Commit: "1234567",
+			},
+		},
+		{
+			description:       "ViewVC file URL",
+			inputLink:         "https://svn.apache.org/viewvc/httpd/httpd/trunk/server/core.c?view=markup",
+			expectedGitCommit: nil,
+		},
+		{
+			description: "Valid cGit commit URL without a trailing slash",
+			inputLink:   "https://git.dpkg.org/cgit/dpkg/dpkg.git/commit?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://git.dpkg.org/cgit/dpkg/dpkg.git",
+				Commit: "faa4c92debe45412bfcf8a44f26e827800bb24be",
+			},
+		},
+		{
+			description: "Valid cGit diff URL without a trailing slash",
+			inputLink:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/diff?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+				Commit: "817b8b9c5396d2b2d92311b46719aad5d3339dbe",
+			},
+		},
+		{
+			description: "Valid cGit diff URL",
+			inputLink:   "https://git.dpkg.org/cgit/dpkg/dpkg.git/diff/?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://git.dpkg.org/cgit/dpkg/dpkg.git",
+				Commit: "faa4c92debe45412bfcf8a44f26e827800bb24be",
+			},
+		},
+		{
+			description: "Valid GitWeb commit URL",
+			inputLink:   "https://git.gnupg.org/cgi-bin/gitweb.cgi?p=libksba.git;a=commit;h=f61a5ea4e0f6a80fd4b28ef0174bee77793cf070",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://git.gnupg.org/libksba.git",
+				Commit: "f61a5ea4e0f6a80fd4b28ef0174bee77793cf070",
+			},
+		},
+		{
+			description: "Valid Gitiles commit URL",
+			inputLink:   "https://chromium.googlesource.com/chromium/src/+/8f4d6a1d5e9c6b4c2ee0b5bd1e5a4ec5c6a0d0f1",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://chromium.googlesource.com/chromium/src",
+				Commit: "8f4d6a1d5e9c6b4c2ee0b5bd1e5a4ec5c6a0d0f1",
+			},
+		},
+		{
+			description:       "Unsupported Gitiles branch URL",
+			inputLink:         "https://android.googlesource.com/platform/frameworks/base/+/refs/heads/main",
+			expectedGitCommit: nil,
+		},
+		{
+			description:       "Unsupported Gitiles tag URL",
+			inputLink:         "https://chromium.googlesource.com/v8/v8/+/refs/tags/10.2.154.4",
+			expectedGitCommit: nil,
+		},
+		{
+			description: "Valid pagure.io commit URL",
+			inputLink:   "https://pagure.io/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://pagure.io/libaio",
+				Commit: "d025927efa75a0d138d2ea67f5b1a3ee59eb8ede",
+			},
+		},
+		{
+			description: "Valid namespaced pagure.io commit URL",
+			inputLink:   "https://pagure.io/rpms/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede?branch=master",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://pagure.io/rpms/libaio",
+				Commit: "d025927efa75a0d138d2ea67f5b1a3ee59eb8ede",
+			},
+		},
+		{
+			description:       "Unsupported GitHub PR URL",
+			inputLink:         "https://github.com/google/osv/pull/123",
+			expectedGitCommit: nil,
+		},
+		{
+			description:       "Unsupported GitHub tag URL",
+			inputLink:         "https://github.com/google/osv.dev/releases/tag/v0.0.14",
+			expectedGitCommit: nil,
+		},
+		{
+			description:       "Completely invalid input",
+			inputLink:         "",
+			expectedGitCommit: nil,
+		},
+		{
+			description: "Valid gitbox.apache.org commit URL",
+			inputLink:   "https://gitbox.apache.org/repos/asf?p=commons-text.git;a=commit;h=b9b40b903e2d1f9935039803c9852439576780ea",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://gitbox.apache.org/repos/asf/commons-text.git",
+				Commit: "b9b40b903e2d1f9935039803c9852439576780ea",
+			},
+		},
+		{
+			description: "Valid sourceware.org GitWeb commit URL",
+			inputLink:   "https://sourceware.org/git/?p=glibc.git;a=commit;h=6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://sourceware.org/git/glibc.git",
+				Commit: "6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c",
+			},
+		},
+		{
+			description: "Valid sourceware.org GitWeb commit URL without a trailing slash",
+			inputLink:   "https://sourceware.org/git?p=binutils-gdb.git;a=commit;h=1a2b3c4d5e6f",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://sourceware.org/git/binutils-gdb.git",
+				Commit: "1a2b3c4d5e6f",
+			},
+		},
+		{
+			description: "GitHub pull request commit URL",
+			inputLink:   "https://github.com/log4js-node/log4js-node/pull/1141/commits/8042252861a1b65adb66931fdf702ead34fa9b76",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://github.com/log4js-node/log4js-node",
+				Commit: "8042252861a1b65adb66931fdf702ead34fa9b76",
+			},
+		},
+		{
+			description:       "GitHub commit listing URL for a branch",
+			inputLink:         "https://github.com/google/osv.dev/commits/master",
+			expectedGitCommit: nil,
+		},
+		{
+			description:       "GitHub commit listing URL for a hex-like branch",
+			inputLink:         "https://github.com/google/osv.dev/commits/deadbeef",
+			expectedGitCommit: nil,
+		},
+		{
+			description:       "GitHub commit URL referencing a branch",
+			inputLink:         "https://github.com/google/osv/commit/main",
+			expectedGitCommit: nil,
+		},
+		{
+			description:       "GitHub commit URL with a truncated hash",
+			inputLink:         "https://github.com/google/osv/commit/cd4e9",
+			expectedGitCommit: nil,
+		},
+		{
+			description: "GitLab commit URL with a SHA-256 hash",
+			inputLink:   "https://gitlab.com/foo/bar/-/commit/5f2b8e6b7e0a4f5d9c3b2a1e0f9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d",
+			expectedGitCommit: &GitCommit{
+				Repo:   "https://gitlab.com/foo/bar",
+				Commit: "5f2b8e6b7e0a4f5d9c3b2a1e0f9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		got := extractGitCommit(tc.inputLink, nil)
+		if !reflect.DeepEqual(got, tc.expectedGitCommit) {
+			t.Errorf("test %q: extractGitCommit for %q was incorrect, got: %#v, expected: %#v", tc.description, tc.inputLink, got, tc.expectedGitCommit)
+		}
+	}
+}
+
+func TestTag(t *testing.T) {
+	tests := []struct {
+		description string
+		inputLink   string
+		expectedTag string
+		expectedOk  bool
+	}{
+		{
+			description: "Gitiles tag URL",
+			inputLink:   "https://chromium.googlesource.com/v8/v8/+/refs/tags/10.2.154.4",
+			expectedTag: "10.2.154.4",
+			expectedOk:  true,
+		},
+		{
+			description: "Gitiles tag URL with a path",
+			inputLink:   "https://chromium.googlesource.com/v8/v8/+/refs/tags/10.2.154.4/src/api/api.cc",
+			expectedTag: "10.2.154.4",
+			expectedOk:  true,
+		},
+		{
+			description: "Gitiles branch URL",
+			inputLink:   "https://android.googlesource.com/platform/frameworks/base/+/refs/heads/main",
+			expectedTag: "",
+			expectedOk:  false,
+		},
+		{
+			description: "cGit tag URL",
+			inputLink:   "https://git.zx2c4.com/cgit/tag/?h=v1.2.3",
+			expectedTag: "v1.2.3",
+			expectedOk:  true,
+		},
+		{
+			description: "cGit log URL for a tag",
+			inputLink:   "https://git.dpkg.org/cgit/dpkg/dpkg.git/log/?h=refs/tags/1.21.10",
+			expectedTag: "1.21.10",
+			expectedOk:  true,
+		},
+		{
+			description: "cGit log URL for an encoded tag",
+			inputLink:   "https://git.dpkg.org/cgit/dpkg/dpkg.git/log/?h=refs%2Ftags%2F1.21.10",
+			expectedTag: "1.21.10",
+			expectedOk:  true,
+		},
+		{
+			description: "cGit log URL for a branch",
+			inputLink:   "https://git.dpkg.org/cgit/dpkg/dpkg.git/log/?h=refs/heads/main",
+			expectedTag: "",
+			expectedOk:  false,
+		},
+		{
+			description: "GitLab blob URL pinned to a tag",
+			inputLink:   "https://gitlab.com/gitlab-org/gitlab/-/blob/v15.3.1/app/models/user.rb",
+			expectedTag: "v15.3.1",
+			expectedOk:  true,
+		},
+		{
+			description: "GitLab blob URL pinned to a branch",
+			inputLink:   "https://gitlab.com/gitlab-org/gitlab/-/blob/master/app/models/user.rb",
+			expectedTag: "",
+			expectedOk:  false,
+		},
+		{
+			description: "GitHub release URL",
+			inputLink:   "https://github.com/owner/repo/releases/tag/v2.3.1",
+			expectedTag: "v2.3.1",
+			expectedOk:  true,
+		},
+		{
+			description: "GitHub release URL for a tag containing a slash",
+			inputLink:   "https://github.com/owner/repo/releases/tag/release/2.3.1",
+			expectedTag: "release/2.3.1",
+			expectedOk:  true,
+		},
+		{
+			description: "GitHub releases URL",
+			inputLink:   "https://github.com/owner/repo/releases",
+			expectedTag: "",
+			expectedOk:  false,
+		},
+		{
+			description: "GitLab tag URL",
+			inputLink:   "https://gitlab.com/gitlab-org/gitlab-foss/-/tags/v13.10.3",
+			expectedTag: "v13.10.3",
+			expectedOk:  true,
+		},
+		{
+			description: "GitLab release URL in a subgroup",
+			inputLink:   "https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium/-/releases/v2.30.1/",
+			expectedTag: "v2.30.1",
+			expectedOk:  true,
+		},
+		{
+			description: "GitLab releases URL",
+			inputLink:   "https://gitlab.com/gitlab-org/gitlab/-/releases",
+			expectedTag: "",
+			expectedOk:  false,
+		},
+		{
+			description: "Commit URL",
+			inputLink:   "https://github.com/google/osv/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5",
+			expectedTag: "",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := Tag(tc.inputLink)
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: Tag(%q) unexpectedly failed: %+v", tc.description, tc.inputLink, err)
+		}
+		if err == nil && !tc.expectedOk {
+			t.Errorf("test %q: Tag(%q) unexpectedly succeeded", tc.description, tc.inputLink)
+		}
+		if got != tc.expectedTag {
+			t.Errorf("test %q: Tag(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputLink, got, tc.expectedTag)
+		}
+	}
+}
+
+func TestCleanVersion(t *testing.T) {
+	tests := []struct {
+		inputVersion    string
+		expectedVersion string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"1.2.3:", "1.2.3"},
+		{"1.2.3::", "1.2.3"},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := CleanVersion(tc.inputVersion); got != tc.expectedVersion {
+			t.Errorf("CleanVersion(%q) was incorrect, got: %q, expected: %q", tc.inputVersion, got, tc.expectedVersion)
+		}
+	}
+}
+
+func TestProcessExtractedVersion(t *testing.T) {
+	tests := []struct {
+		inputVersion    string
+		expectedVersion string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"1.2.3.", "1.2.3"},
+		{".1.2.3", "1.2.3"},
+		{"20200101", "20200101"},
+		{"1_2_3", "1.2.3"},
+		{"1_1_1k", "1.1.1k"},
+		{"1.2.3+build5", "1.2.3+build5"},
+		{"1.2.3,", "1.2.3"},
+		{"1.2.3;", "1.2.3"},
+		{"1.2.3:", "1.2.3"},
+		{"1.2.3)", "1.2.3"},
+		{"1.2.3]", "1.2.3"},
+		{"1.2.3}", "1.2.3"},
+		{"(1.2.3)", "1.2.3"},
+		{"1.2.3).", "1.2.3"},
+		{"1.2.3),", "1.2.3"},
+		{"the", ""},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := ProcessExtractedVersion(tc.inputVersion); got != tc.expectedVersion {
+			t.Errorf("ProcessExtractedVersion(%q) was incorrect, got: %q, expected: %q", tc.inputVersion, got, tc.expectedVersion)
+		}
+	}
+}
+
+func TestTagToVersion(t *testing.T) {
+	tests := []struct {
+		description        string
+		inputTag           string
+		inputValidVersions []string
+		expectedVersion    string
+		expectedOk         bool
+	}{
+		{
+			description:     "Tag with a v prefix",
+			inputTag:        "v1.2.3",
+			expectedVersion: "1.2.3",
+			expectedOk:      true,
+		},
+		{
+			description:     "Underscore-separated OpenSSL-style tag",
+			inputTag:        "openssl-1_1_1k",
+			expectedVersion: "1.1.1k",
+			expectedOk:      true,
+		},
+		{
+			description:        "Underscore-separated tag matching a valid version",
+			inputTag:           "OpenSSL_1_1_1",
+			inputValidVersions: []string{"1.1.0", "1.1.1", "3.0.0"},
+			expectedVersion:    "1.1.1",
+			expectedOk:         true,
+		},
+		{
+			description:     "Tag that isn't a version",
+			inputTag:        "latest",
+			expectedVersion: "",
+			expectedOk:      false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := tagToVersion(tc.inputTag, tc.inputValidVersions)
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: tagToVersion(%q) unexpectedly failed: %+v", tc.description, tc.inputTag, err)
+		}
+		if got != tc.expectedVersion {
+			t.Errorf("test %q: tagToVersion(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputTag, got, tc.expectedVersion)
 		}
 	}
 }
@@ -409,6 +1331,24 @@ func TestNormalizeVersion(t *testing.T) {
 			expectedNormalizedVersion: "",
 			expectedOk:                false,
 		},
+		{
+			description:               "Release tag with a release- prefix",
+			inputVersion:              "release-1.2.3",
+			expectedNormalizedVersion: "1-2-3",
+			expectedOk:                true,
+		},
+		{
+			description:               "Release tag with a REL_ prefix",
+			inputVersion:              "REL_1_2_3",
+			expectedNormalizedVersion: "1-2-3",
+			expectedOk:                true,
+		},
+		{
+			description:               "Release tag with a ver prefix",
+			inputVersion:              "ver1.2.3rc1",
+			expectedNormalizedVersion: "1-2-3-rc1",
+			expectedOk:                true,
+		},
 		{
 			description:               "Valid supported version #1",
 			inputVersion:              "1.0",
@@ -487,7 +1427,19 @@ func TestNormalizeVersion(t *testing.T) {
 			expectedNormalizedVersion: "10-0-0-10",
 			expectedOk:                true,
 		},
-	}
+		{
+			description:               "Underscore-separated version",
+			inputVersion:              "OpenSSL_1_1_1",
+			expectedNormalizedVersion: "1-1-1",
+			expectedOk:                true,
+		},
+		{
+			description:               "Version with build metadata",
+			inputVersion:              "1.2.3+build5",
+			expectedNormalizedVersion: "1-2-3",
+			expectedOk:                true,
+		},
+	}
 	for _, tc := range tests {
 		got, err := NormalizeVersion(tc.inputVersion)
 		if err != nil && tc.expectedOk {
@@ -499,6 +1451,197 @@ func TestNormalizeVersion(t *testing.T) {
 	}
 }
 
+func TestExtractVersionsFromDescription(t *testing.T) {
+	tests := []struct {
+		description        string
+		inputDescription   string
+		inputValidVersions []string
+		expectedVersions   []AffectedVersion
+	}{
+		{
+			description:        "A simple before range",
+			inputDescription:   "An issue was discovered in Foo before 1.4.5.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.4.5"}},
+		},
+		{
+			description:        "Introduced and fixed in separate sentences",
+			inputDescription:   "The flaw was introduced in 1.2.0. It is fixed in 1.4.5.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.2.0", Fixed: "1.4.5"}},
+		},
+		{
+			description:        "Introduced in a separate sentence from a before range",
+			inputDescription:   "Foo before 1.4.5 mishandles input. This was introduced in 1.2.0 and fixed in 1.4.5.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.2.0", Fixed: "1.4.5"}},
+		},
+		{
+			description:        "A through range separated by a non-breaking space",
+			inputDescription:   "An issue was discovered in Foo 1.2.0 through\u00a01.2.3.",
+			inputValidVersions: []string{"1.2.0", "1.2.3", "1.2.4"},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.2.0", Fixed: "1.2.4"}},
+		},
+		{
+			description:        "A before range with a zero-width space",
+			inputDescription:   "An issue was discovered in Foo before 1.\u200b4.5.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.4.5"}},
+		},
+		{
+			description:        "A before range followed by punctuation",
+			inputDescription:   "Foo before 1.2.3, which is widely deployed (and bar before 2.0.1), is affected.",
+			inputValidVersions: []string{"1.2.3", "2.0.1"},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.2.3"}, {Fixed: "2.0.1"}},
+		},
+		{
+			description:        "An up to and including range",
+			inputDescription:   "Foo versions 1.0 up to and including 1.2.3 are affected.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.0", LastAffected: "1.2.3"}},
+		},
+		{
+			description:        "An up to but not including range",
+			inputDescription:   "Foo versions 1.0 up to but not including 1.2.4 are affected.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.0", Fixed: "1.2.4"}},
+		},
+		{
+			description:        "An up to but not including range without a lower bound",
+			inputDescription:   "Foo is affected up to but not including version 1.2.4.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.2.4"}},
+		},
+		{
+			description:        "An up to but excluding range",
+			inputDescription:   "Foo versions 1.0 up to but excluding 1.2.4 are affected.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.0", Fixed: "1.2.4"}},
+		},
+		{
+			description:        "An up to but excluding range without a lower bound",
+			inputDescription:   "Foo is affected up to but excluding version 1.2.4.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.2.4"}},
+		},
+		{
+			description:        "A wildcard release line",
+			inputDescription:   "All Foo 1.2.x versions are affected.",
+			inputValidVersions: []string{"1.1.9", "1.2.0", "1.2.1", "1.3.0"},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.2.0", Fixed: "1.3.0"}},
+		},
+		{
+			description:        "A wildcard release line without valid versions",
+			inputDescription:   "Foo versions 2.4.* are affected",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Introduced: "2.4.0", Fixed: "2.5.0"}},
+		},
+		{
+			description:        "A before range starting from a wildcard release line",
+			inputDescription:   "Foo 1.2.x before 1.2.5 is affected.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.2.0", Fixed: "1.2.5"}},
+		},
+		{
+			description:        "Unrelated multiple fix mentions are not combined",
+			inputDescription:   "The flaw was introduced in 1.2.0. It is fixed in 1.4.5 and fixed in 2.0.1.",
+			inputValidVersions: []string{},
+			expectedVersions:   nil,
+		},
+		{
+			description:        "Out of order versions are not combined",
+			inputDescription:   "The flaw was introduced in 1.4.5. It is fixed in 1.2.0.",
+			inputValidVersions: []string{"1.2.0", "1.4.5"},
+			expectedVersions:   nil,
+		},
+		{
+			description:        "Non-version introduced clause is not combined",
+			inputDescription:   "The flaw was introduced in commit abc. It is fixed in 1.4.5.",
+			inputValidVersions: []string{},
+			expectedVersions:   nil,
+		},
+		{
+			description:        "Versions through versions, affected",
+			inputDescription:   "Versions 1.0 through 2.0 are affected.",
+			inputValidVersions: []string{"1.0", "2.0", "2.1"},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.0", Fixed: "2.1"}},
+		},
+		{
+			description:        "Versions through versions with both keywords, affected",
+			inputDescription:   "Foo versions 1.0 through versions 2.0 are affected by a heap overflow.",
+			inputValidVersions: []string{"1.0", "2.0", "2.1"},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.0", Fixed: "2.1"}},
+		},
+		{
+			description:        "Versions through versions at the start of a sentence",
+			inputDescription:   "A flaw was found in Foo. Versions v1.0 through v2.0 are affected.",
+			inputValidVersions: []string{"v1.0", "v2.0", "v2.1"},
+			expectedVersions:   []AffectedVersion{{Introduced: "v1.0", Fixed: "v2.1"}},
+		},
+		{
+			description:        "A single affected version of a product",
+			inputDescription:   "A flaw was found in mod_proxy. This affects Apache HTTP Server 2.4.52.",
+			inputValidVersions: []string{"2.4.51", "2.4.52", "2.4.53"},
+			expectedVersions:   []AffectedVersion{{Introduced: "2.4.52", LastAffected: "2.4.52"}},
+		},
+		{
+			description:        "A single affected version mid-sentence",
+			inputDescription:   "The issue affects Foo version 1.1.1k, and is exploitable remotely.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.1.1k", LastAffected: "1.1.1k"}},
+		},
+		{
+			description:        "An affected range is not a single affected version",
+			inputDescription:   "This affects Foo 1.2 through 1.4.",
+			inputValidVersions: []string{"1.2", "1.3", "1.4", "1.5"},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.2", Fixed: "1.5"}},
+		},
+		{
+			description:        "Versions inferior to a fix",
+			inputDescription:   "Foo versions inferior to 1.4.5 are vulnerable to XSS.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.4.5"}},
+		},
+		{
+			description:        "Versions earlier than a fix",
+			inputDescription:   "A heap overflow exists in Foo earlier than version 1.4.5.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.4.5"}},
+		},
+		{
+			description:        "Versions older than a fix",
+			inputDescription:   "Foo older than v1.4.5 mishandles input.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{Fixed: "v1.4.5"}},
+		},
+		{
+			description:        "Versions inferior or equal to a last affected version",
+			inputDescription:   "Foo versions inferior or equal to 1.4.4 are affected.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{LastAffected: "1.4.4"}},
+		},
+		{
+			description:        "Versions earlier than or equal to a last affected version",
+			inputDescription:   "Foo earlier than or equal to 1.4.4 is affected.",
+			inputValidVersions: []string{},
+			expectedVersions:   []AffectedVersion{{LastAffected: "1.4.4"}},
+		},
+		{
+			description:        "A comparison without a version",
+			inputDescription:   "Foo takes longer than expected, and is older than the spec.",
+			inputValidVersions: []string{},
+			expectedVersions:   nil,
+		},
+	}
+
+	for _, tc := range tests {
+		got, _ := extractVersionsFromDescription(tc.inputValidVersions, tc.inputDescription, nil)
+		if diff := cmp.Diff(got, tc.expectedVersions, ignoreNotes); diff != "" {
+			t.Errorf("test %q: extractVersionsFromDescription for %q was incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+	}
+}
+
 func TestExtractVersionInfo(t *testing.T) {
 	tests := []struct {
 		description         string
@@ -536,7 +1679,31 @@ func TestExtractVersionInfo(t *testing.T) {
 			expectedNotes: []string{},
 		},
 		{
-			description:        "A CVE with duplicate affected versions squashed",
+			description: "A CVE with duplicate affected versions squashed",
+			inputCVEItem: func() CVEItem {
+				// CVE-2022-0090 without the editions that distinguish its duplicate ranges.
+				cve := loadTestData("CVE-2022-0090")
+				for i := range cve.Configurations.Nodes[0].CPEMatch {
+					match := &cve.Configurations.Nodes[0].CPEMatch[i]
+					match.CPE23URI = strings.NewReplacer(":community:", ":*:", ":enterprise:", ":*:").Replace(match.CPE23URI)
+				}
+				return cve
+			}(),
+			inputValidVersions: []string{},
+			expectedVersionInfo: VersionInfo{
+				FixCommits:          []GitCommit(nil),
+				LimitCommits:        []GitCommit(nil),
+				LastAffectedCommits: []GitCommit(nil),
+				AffectedVersions: []AffectedVersion{
+					{Introduced: "14.6.0", Fixed: "14.6.1"},
+					{Introduced: "14.5.0", Fixed: "14.5.3"},
+					{Introduced: "0", Fixed: "14.4.5"},
+				},
+			},
+			expectedNotes: []string{},
+		},
+		{
+			description:        "A CVE with the same affected versions of different editions",
 			inputCVEItem:       loadTestData("CVE-2022-0090"),
 			inputValidVersions: []string{},
 			expectedVersionInfo: VersionInfo{
@@ -544,20 +1711,55 @@ func TestExtractVersionInfo(t *testing.T) {
 				LimitCommits:        []GitCommit(nil),
 				LastAffectedCommits: []GitCommit(nil),
 				AffectedVersions: []AffectedVersion{
-					AffectedVersion{
-						Introduced:   "14.6.0",
-						Fixed:        "14.6.1",
-						LastAffected: "",
+					{Introduced: "14.6.0", Fixed: "14.6.1", Edition: "community"},
+					{Introduced: "14.6.0", Fixed: "14.6.1", Edition: "enterprise"},
+					{Introduced: "14.5.0", Fixed: "14.5.3", Edition: "community"},
+					{Introduced: "14.5.0", Fixed: "14.5.3", Edition: "enterprise"},
+					{Introduced: "0", Fixed: "14.4.5", Edition: "community"},
+					{Introduced: "0", Fixed: "14.4.5", Edition: "enterprise"},
+				},
+			},
+			expectedNotes: []string{},
+		},
+		{
+			description: "A CVE with a fix tag reference",
+			inputCVEItem: CVEItem{
+				CVE: CVE{
+					References: CVEReferences{
+						ReferenceData: []CVEReferenceData{
+							{URL: "https://chromium.googlesource.com/v8/v8/+/refs/tags/10.2.154.4"},
+							{URL: "https://chromium.googlesource.com/v8/v8/+/refs/heads/main"},
+						},
 					},
-					AffectedVersion{
-						Introduced:   "14.5.0",
-						Fixed:        "14.5.3",
-						LastAffected: "",
+				},
+			},
+			inputValidVersions: []string{},
+			expectedVersionInfo: VersionInfo{
+				AffectedVersions: []AffectedVersion{
+					{
+						Fixed: "10.2.154.4",
 					},
-					AffectedVersion{
-						Introduced:   "",
-						Fixed:        "14.4.5",
-						LastAffected: "",
+				},
+			},
+			expectedNotes: []string{},
+		},
+		{
+			description: "A CVE with GitHub release and milestone references",
+			inputCVEItem: CVEItem{
+				CVE: CVE{
+					References: CVEReferences{
+						ReferenceData: []CVEReferenceData{
+							{URL: "https://github.com/owner/repo/releases/tag/v2.3.1"},
+							{URL: "https://github.com/owner/repo/milestone/5"},
+						},
+					},
+				},
+			},
+			inputValidVersions: []string{"2.3.0", "2.3.1"},
+			expectedVersionInfo: VersionInfo{
+				AffectedVersions: []AffectedVersion{
+					{
+						Fixed: "2.3.1",
 					},
 				},
 			},
@@ -567,8 +1769,3839 @@ func TestExtractVersionInfo(t *testing.T) {
 
 	for _, tc := range tests {
 		gotVersionInfo, _ := ExtractVersionInfo(tc.inputCVEItem, tc.inputValidVersions)
-		if diff := cmp.Diff(gotVersionInfo, tc.expectedVersionInfo); diff != "" {
+		if diff := cmp.Diff(gotVersionInfo, tc.expectedVersionInfo, ignoreSource, ignoreNotes); diff != "" {
 			t.Errorf("test %q: VersionInfo for %#v was incorrect: %s", tc.description, tc.inputCVEItem, diff)
 		}
 	}
 }
+
+func TestExtractVersionInfoWithCommitVerifier(t *testing.T) {
+	tests := []struct {
+		description        string
+		inputCVEItem       CVEItem
+		inputVerifier      CommitVerifier
+		expectedFixCommits []GitCommit
+		expectedNote       string
+	}{
+		{
+			description:  "A verified commit is kept",
+			inputCVEItem: loadTestData("CVE-2022-3037"),
+			inputVerifier: func(gc GitCommit) (bool, error) {
+				return true, nil
+			},
+			expectedFixCommits: []GitCommit{
+				{
+					Repo:   "https://github.com/vim/vim",
+					Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb",
+				},
+			},
+		},
+		{
+			description:  "A non-existent commit is dropped",
+			inputCVEItem: loadTestData("CVE-2022-3037"),
+			inputVerifier: func(gc GitCommit) (bool, error) {
+				return false, nil
+			},
+			expectedFixCommits: nil,
+			expectedNote:       "Commit 4f1b083be43f351bc107541e7b0c9655a5d2c0bb does not exist in https://github.com/vim/vim, dropping it",
+		},
+		{
+			description:  "An unverifiable commit is dropped",
+			inputCVEItem: loadTestData("CVE-2022-3037"),
+			inputVerifier: func(gc GitCommit) (bool, error) {
+				return false, errors.New("rate limited")
+			},
+			expectedFixCommits: nil,
+			expectedNote:       "Unable to verify commit 4f1b083be43f351bc107541e7b0c9655a5d2c0bb in https://github.com/vim/vim, dropping it: rate limited",
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, gotNotes := ExtractVersionInfoWithOptions(tc.inputCVEItem, nil, ExtractOptions{CommitVerifier: tc.inputVerifier})
+		if diff := cmp.Diff(gotVersionInfo.FixCommits, tc.expectedFixCommits); diff != "" {
+			t.Errorf("test %q: FixCommits were incorrect: %s", tc.description, diff)
+		}
+		if tc.expectedNote != "" && !slices.Contains(gotNotes, tc.expectedNote) {
+			t.Errorf("test %q: notes %#v did not contain %q", tc.description, gotNotes, tc.expectedNote)
+		}
+	}
+}
+
+func TestExtractVersionInfoDiagnostics(t *testing.T) {
+	tests := []struct {
+		description         string
+		inputCVEItem        CVEItem
+		expectedDiagnostics ExtractDiagnostics
+		expectedNote        string
+	}{
+		{
+			description:  "A CVE with multiple affected versions",
+			inputCVEItem: loadTestData("CVE-2022-32746"),
+			expectedDiagnostics: ExtractDiagnostics{
+				CPEMatches:     3,
+				UsedCPEMatches: 3,
+			},
+		},
+		{
+			description:  "A CVE without configurations",
+			inputCVEItem: loadTestData("CVE-2022-36749"),
+			expectedDiagnostics: ExtractDiagnostics{
+				DescriptionFallbackRan: true,
+			},
+			expectedNote: "No versions detected: no CPE matches, and none in the description.",
+		},
+	}
+
+	for _, tc := range tests {
+		var got ExtractDiagnostics
+		_, gotNotes := ExtractVersionInfoWithOptions(tc.inputCVEItem, nil, ExtractOptions{Diagnostics: &got})
+		if tc.expectedNote != "" && !slices.Contains(gotNotes, tc.expectedNote) {
+			t.Errorf("test %q: notes %#v did not contain %q", tc.description, gotNotes, tc.expectedNote)
+		}
+		// The outcomes of references are covered by TestExtractVersionInfoReferenceOutcomes.
+		if diff := cmp.Diff(got, tc.expectedDiagnostics, cmpopts.IgnoreFields(ExtractDiagnostics{}, "References")); diff != "" {
+			t.Errorf("test %q: ExtractDiagnostics were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoConflictingBounds(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputCVEItem             string
+		expectedAffectedVersions []AffectedVersion
+		expectedNote             string
+	}{
+		{
+			description: "Both start bounds",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionStartExcluding": "0.9", "versionEndExcluding": "1.5"}
+			]}]}}`,
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.5"}},
+			expectedNote:             "Warning: cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:* has both versionStartIncluding (1.0) and versionStartExcluding (0.9), using versionStartIncluding",
+		},
+		{
+			description: "Both end bounds",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndExcluding": "1.5", "versionEndIncluding": "1.4"}
+			]}]}}`,
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.5"}},
+			expectedNote:             "Warning: cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:* has both versionEndExcluding (1.5) and versionEndIncluding (1.4), using versionEndExcluding",
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, gotNotes := ExtractVersionInfo(cveItemFromJSON(t, tc.inputCVEItem), nil)
+		if diff := cmp.Diff(gotVersionInfo.AffectedVersions, tc.expectedAffectedVersions, ignoreSource); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+		if !slices.Contains(gotNotes, tc.expectedNote) {
+			t.Errorf("test %q: notes %#v did not contain %q", tc.description, gotNotes, tc.expectedNote)
+		}
+	}
+}
+
+func TestExtractVersionInfoCVE5Affected(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputCVEItem             string
+		inputValidVersions       []string
+		expectedAffectedVersions []AffectedVersion
+	}{
+		{
+			description: "lessThan, lessThanOrEqual and single versions",
+			inputCVEItem: `{"affected": [{"vendor": "foo", "product": "bar", "versions": [
+				{"version": "1.0", "status": "affected", "lessThan": "1.5", "versionType": "semver"},
+				{"version": "2.0", "status": "affected", "lessThanOrEqual": "2.3"},
+				{"version": "3.1", "status": "affected"},
+				{"version": "4.0", "status": "affected", "lessThan": "*"},
+				{"version": "1.5", "status": "unaffected"}
+			]}]}`,
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "1.0", Fixed: "1.5"},
+				{Introduced: "2.0", LastAffected: "2.3"},
+				{Introduced: "3.1", LastAffected: "3.1"},
+				{Introduced: "4.0"},
+			},
+		},
+		{
+			description: "CVE 5.x versions take precedence over CPE configurations",
+			inputCVEItem: `{"affected": [{"vendor": "foo", "product": "bar", "versions": [
+				{"version": "0", "status": "affected", "lessThan": "1.5"}
+			]}], "configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionEndExcluding": "1.4"}
+			]}]}}`,
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "0", Fixed: "1.5"},
+			},
+		},
+		{
+			description: "CPE configurations are used when there are no CVE 5.x affected versions",
+			inputCVEItem: `{"affected": [{"vendor": "foo", "product": "bar", "versions": [
+				{"version": "1.5", "status": "unaffected"}
+			]}], "configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionEndExcluding": "1.4"}
+			]}, {"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:baz:*:*:*:*:*:*:*:*", "versionEndExcluding": "2.4"}
+			]}]}}`,
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "0", Fixed: "1.4"},
+				{Introduced: "0", Fixed: "2.4"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfo(cveItemFromJSON(t, tc.inputCVEItem), tc.inputValidVersions)
+		if diff := cmp.Diff(gotVersionInfo.AffectedVersions, tc.expectedAffectedVersions, ignoreSource, ignoreNotes); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoANDNodes(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputCVEItem             string
+		expectedAffectedVersions []AffectedVersion
+		expectedNote             string
+	}{
+		{
+			description: "Versioned application running on a platform",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "AND", "children": [
+				{"operator": "OR", "cpe_match": [
+					{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:ibm:spectrum_protect_plus:*:*:*:*:*:*:*:*", "versionStartIncluding": "10.1.0", "versionEndExcluding": "10.1.11"}
+				]},
+				{"operator": "OR", "cpe_match": [
+					{"vulnerable": false, "cpe23Uri": "cpe:2.3:o:linux:linux_kernel:-:*:*:*:*:*:*:*"}
+				]}
+			], "cpe_match": []}]}}`,
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "10.1.0", Fixed: "10.1.11"}},
+			expectedNote:             "cpe:2.3:a:ibm:spectrum_protect_plus:*:*:*:*:*:*:*:* is only affected when running on cpe:2.3:o:linux:linux_kernel:-:*:*:*:*:*:*:*",
+		},
+		{
+			description: "Multiple versioned applications running on a platform",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "AND", "children": [
+				{"operator": "OR", "cpe_match": [
+					{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:ibm:spectrum_copy_data_management:*:*:*:*:*:*:*:*", "versionStartIncluding": "2.2.0.0", "versionEndExcluding": "2.2.16.0"},
+					{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:ibm:spectrum_protect_plus:*:*:*:*:*:*:*:*", "versionStartIncluding": "10.1.0", "versionEndExcluding": "10.1.11"}
+				]},
+				{"operator": "OR", "cpe_match": [
+					{"vulnerable": false, "cpe23Uri": "cpe:2.3:o:linux:linux_kernel:-:*:*:*:*:*:*:*"}
+				]}
+			], "cpe_match": []}]}}`,
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "2.2.0.0", Fixed: "2.2.16.0"},
+				{Introduced: "10.1.0", Fixed: "10.1.11"},
+			},
+			expectedNote: "cpe:2.3:a:ibm:spectrum_copy_data_management:*:*:*:*:*:*:*:* is only affected when running on cpe:2.3:o:linux:linux_kernel:-:*:*:*:*:*:*:*",
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, gotNotes := ExtractVersionInfo(cveItemFromJSON(t, tc.inputCVEItem), nil)
+		if diff := cmp.Diff(gotVersionInfo.AffectedVersions, tc.expectedAffectedVersions, ignoreSource); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+		if !slices.Contains(gotNotes, tc.expectedNote) {
+			t.Errorf("test %q: notes %#v did not contain %q", tc.description, gotNotes, tc.expectedNote)
+		}
+	}
+}
+
+func TestCPEMatchesProduct(t *testing.T) {
+	tests := []struct {
+		description   string
+		inputCPE      string
+		inputVendor   string
+		inputProduct  string
+		expectedMatch bool
+	}{
+		{
+			description:   "exact match",
+			inputCPE:      "cpe:2.3:a:gitlab:gitlab:*:*:*:*:community:*:*:*",
+			inputVendor:   "gitlab",
+			inputProduct:  "gitlab",
+			expectedMatch: true,
+		},
+		{
+			description:   "case-insensitive match",
+			inputCPE:      "cpe:2.3:a:gitlab:gitlab:*:*:*:*:community:*:*:*",
+			inputVendor:   "GitLab",
+			inputProduct:  "GitLab",
+			expectedMatch: true,
+		},
+		{
+			description:   "different product",
+			inputCPE:      "cpe:2.3:a:gitlab:gitlab:*:*:*:*:community:*:*:*",
+			inputVendor:   "gitlab",
+			inputProduct:  "gitaly",
+			expectedMatch: false,
+		},
+		{
+			description:   "wildcard vendor in the CPE",
+			inputCPE:      "cpe:2.3:a:*:openssl:1.1.1:*:*:*:*:*:*:*",
+			inputVendor:   "openssl",
+			inputProduct:  "openssl",
+			expectedMatch: true,
+		},
+		{
+			description:   "wildcard product requested",
+			inputCPE:      "cpe:2.3:a:openssl:openssl:1.1.1:*:*:*:*:*:*:*",
+			inputVendor:   "openssl",
+			inputProduct:  "*",
+			expectedMatch: true,
+		},
+		{
+			description:   "not applicable vendor in the CPE",
+			inputCPE:      "cpe:2.3:a:-:openssl:1.1.1:*:*:*:*:*:*:*",
+			inputVendor:   "openssl",
+			inputProduct:  "openssl",
+			expectedMatch: false,
+		},
+		{
+			description:   "not applicable vendor on both sides",
+			inputCPE:      "cpe:2.3:a:-:openssl:1.1.1:*:*:*:*:*:*:*",
+			inputVendor:   "-",
+			inputProduct:  "openssl",
+			expectedMatch: true,
+		},
+		{
+			description:   "embedded colons",
+			inputCPE:      "cpe:2.3:a:http\\:\\:daemon_project:http\\:\\:daemon:*:*:*:*:*:*:*:*",
+			inputVendor:   "http::daemon_project",
+			inputProduct:  "http::daemon",
+			expectedMatch: true,
+		},
+	}
+
+	for _, tc := range tests {
+		cpe, err := ParseCPE(tc.inputCPE)
+		if err != nil {
+			t.Fatalf("test %q: ParseCPE(%q) unexpectedly failed: %v", tc.description, tc.inputCPE, err)
+		}
+		if got := CPEMatchesProduct(cpe, tc.inputVendor, tc.inputProduct); got != tc.expectedMatch {
+			t.Errorf("test %q: CPEMatchesProduct(%q, %q, %q) was incorrect, got: %t, expected: %t", tc.description, tc.inputCPE, tc.inputVendor, tc.inputProduct, got, tc.expectedMatch)
+		}
+	}
+}
+
+func TestExtractVersionInfoIntroducedCommits(t *testing.T) {
+	tests := []struct {
+		description               string
+		inputCVEItem              string
+		expectedIntroducedCommits []GitCommit
+		expectedFixCommits        []GitCommit
+	}{
+		{
+			description: "Commit of unknown purpose is a fix",
+			inputCVEItem: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/torvalds/linux/commit/1234567890abcdef1234567890abcdef12345678", "tags": ["Patch"]}
+			]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "0"}},
+			expectedFixCommits:        []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "1234567890abcdef1234567890abcdef12345678"}},
+		},
+		{
+			description: "Fixes in several repositories are each introduced at the first commit",
+			inputCVEItem: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/torvalds/linux/commit/1234567890abcdef1234567890abcdef12345678"},
+				{"url": "https://github.com/torvalds/linux/commit/abcdef1234567890abcdef1234567890abcdef12"},
+				{"url": "https://gitlab.com/libtiff/libtiff/-/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8"}
+			]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "0"}, {Repo: "https://gitlab.com/libtiff/libtiff", Commit: "0"}},
+			expectedFixCommits: []GitCommit{
+				{Repo: "https://github.com/torvalds/linux", Commit: "1234567890abcdef1234567890abcdef12345678"},
+				{Repo: "https://github.com/torvalds/linux", Commit: "abcdef1234567890abcdef1234567890abcdef12"},
+				{Repo: "https://gitlab.com/libtiff/libtiff", Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+			},
+		},
+		{
+			description: "Commit tagged as introducing the vulnerability",
+			inputCVEItem: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/torvalds/linux/commit/abcdef1234567890abcdef1234567890abcdef12", "tags": ["Regression"]},
+				{"url": "https://github.com/torvalds/linux/commit/1234567890abcdef1234567890abcdef12345678", "tags": ["Patch"]}
+			]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "abcdef1234567890abcdef1234567890abcdef12"}},
+			expectedFixCommits:        []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "1234567890abcdef1234567890abcdef12345678"}},
+		},
+		{
+			description: "Commit named as introducing the vulnerability",
+			inputCVEItem: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/torvalds/linux/commit/abcdef1234567890abcdef1234567890abcdef12", "name": "Introduced by"}
+			]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "abcdef1234567890abcdef1234567890abcdef12"}},
+		},
+		{
+			description: "Commit attributed by an abbreviated hash in the description",
+			inputCVEItem: `{"cve": {"description": {"description_data": [{"lang": "en", "value": "A use-after-free was introduced by commit abcdef123456 (\"net: rework locking\")."}]},
+				"references": {"reference_data": [
+				{"url": "https://github.com/torvalds/linux/commit/abcdef1234567890abcdef1234567890abcdef12"},
+				{"url": "https://github.com/torvalds/linux/commit/1234567890abcdef1234567890abcdef12345678"}
+			]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "abcdef1234567890abcdef1234567890abcdef12"}},
+			expectedFixCommits:        []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "1234567890abcdef1234567890abcdef12345678"}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfo(cveItemFromJSON(t, tc.inputCVEItem), nil)
+		if diff := cmp.Diff(gotVersionInfo.IntroducedCommits, tc.expectedIntroducedCommits); diff != "" {
+			t.Errorf("test %q: IntroducedCommits were incorrect: %s", tc.description, diff)
+		}
+		if diff := cmp.Diff(gotVersionInfo.FixCommits, tc.expectedFixCommits); diff != "" {
+			t.Errorf("test %q: FixCommits were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestCPEIsApplication(t *testing.T) {
+	tests := []struct {
+		description         string
+		inputCPE            string
+		expectedApplication bool
+	}{
+		{
+			description:         "Application",
+			inputCPE:            "cpe:2.3:a:gitlab:gitlab:*:*:*:*:community:*:*:*",
+			expectedApplication: true,
+		},
+		{
+			description:         "Windows",
+			inputCPE:            "cpe:2.3:o:microsoft:windows_10:1909:*:*:*:*:*:x64:*",
+			expectedApplication: false,
+		},
+		{
+			description:         "Firmware",
+			inputCPE:            "cpe:2.3:o:intel:nuc_kit_nuc8i7hvk_firmware:*:*:*:*:*:*:*:*",
+			expectedApplication: false,
+		},
+		{
+			description:         "Hardware",
+			inputCPE:            "cpe:2.3:h:intel:core_i3-1005g1:-:*:*:*:*:*:*:*",
+			expectedApplication: false,
+		},
+	}
+
+	for _, tc := range tests {
+		cpe, err := ParseCPE(tc.inputCPE)
+		if err != nil {
+			t.Fatalf("test %q: ParseCPE(%q) unexpectedly failed: %v", tc.description, tc.inputCPE, err)
+		}
+		if got := cpe.IsApplication(); got != tc.expectedApplication {
+			t.Errorf("test %q: IsApplication() for %q was incorrect, got: %t, expected: %t", tc.description, tc.inputCPE, got, tc.expectedApplication)
+		}
+	}
+}
+
+func TestExtractVersionInfoNonApplicationCPEs(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputCVEItem             string
+		expectedAffectedVersions []AffectedVersion
+		expectedSkipped          int
+	}{
+		{
+			description: "Windows and firmware CPEs are skipped",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:o:microsoft:windows_10:*:*:*:*:*:*:*:*", "versionEndExcluding": "10.0.19041.1415"},
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:o:intel:nuc_kit_nuc8i7hvk_firmware:*:*:*:*:*:*:*:*", "versionEndExcluding": "hnkbli70.86a.0067"},
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionEndExcluding": "1.2.3"}
+			]}]}}`,
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "0", Fixed: "1.2.3"}},
+			expectedSkipped:          2,
+		},
+		{
+			description: "Linux kernel CPEs are used",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:o:linux:linux_kernel:*:*:*:*:*:*:*:*", "versionStartIncluding": "5.10", "versionEndExcluding": "5.10.90"}
+			]}]}}`,
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "5.10", Fixed: "5.10.90"}},
+			expectedSkipped:          0,
+		},
+	}
+
+	for _, tc := range tests {
+		var diag ExtractDiagnostics
+		gotVersionInfo, _ := ExtractVersionInfoWithOptions(cveItemFromJSON(t, tc.inputCVEItem), nil, ExtractOptions{Diagnostics: &diag})
+		if diff := cmp.Diff(gotVersionInfo.AffectedVersions, tc.expectedAffectedVersions, ignoreSource, ignoreNotes); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+		if diag.SkippedNonApplication != tc.expectedSkipped {
+			t.Errorf("test %q: SkippedNonApplication was incorrect, got: %d, expected: %d", tc.description, diag.SkippedNonApplication, tc.expectedSkipped)
+		}
+	}
+}
+
+func TestExtractGitCommitsFromDescription(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputDescription string
+		expectedCommits  []GitCommit
+	}{
+		{
+			description:      "No commits",
+			inputDescription: "A buffer overflow in foo before 1.2.3 allows remote attackers to execute code.",
+			expectedCommits:  nil,
+		},
+		{
+			description:      "repo@hash shorthand",
+			inputDescription: "This is fixed in github.com/owner/repo@abcdef123.",
+			expectedCommits:  []GitCommit{{Repo: "https://github.com/owner/repo", Commit: "abcdef123"}},
+		},
+		{
+			description:      "repo@hash shorthand with a scheme",
+			inputDescription: "Fixed in: https://gitlab.com/group/project@0123456789abcdef0123456789abcdef01234567",
+			expectedCommits:  []GitCommit{{Repo: "https://gitlab.com/group/project", Commit: "0123456789abcdef0123456789abcdef01234567"}},
+		},
+		{
+			description:      "commit <hash> in <repo>",
+			inputDescription: "The issue was addressed by commit abcdef1234 in github.com/owner/repo.",
+			expectedCommits:  []GitCommit{{Repo: "https://github.com/owner/repo", Commit: "abcdef1234"}},
+		},
+		{
+			description:      "owner/repo@hash shorthand without a host",
+			inputDescription: "This is fixed in owner/repo@abcdef123.",
+			expectedCommits:  []GitCommit{{Repo: "https://github.com/owner/repo", Commit: "abcdef123"}},
+		},
+		{
+			description:      "commit <hash> of owner/repo without a host",
+			inputDescription: "Upgrade to a release including commit abcdef1234 of owner/repo.git.",
+			expectedCommits:  []GitCommit{{Repo: "https://github.com/owner/repo", Commit: "abcdef1234"}},
+		},
+		{
+			description:      "GitLab subgroup isn't mistaken for a shorthand",
+			inputDescription: "Fixed in gitlab.com/group/subgroup/project@abcdef123.",
+			expectedCommits:  nil,
+		},
+		{
+			description:      "Too short to be a commit",
+			inputDescription: "Fixed in github.com/owner/repo@v1.2.",
+			expectedCommits:  nil,
+		},
+	}
+
+	for _, tc := range tests {
+		got := extractGitCommitsFromDescription(tc.inputDescription)
+		if diff := cmp.Diff(got, tc.expectedCommits); diff != "" {
+			t.Errorf("test %q: extractGitCommitsFromDescription(%q) was incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoNotesAreStable(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"configurations": {"nodes": [
+		{"operator": "OR", "cpe_match": [
+			{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionEndExcluding": "1.2.3"},
+			{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar_server:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndExcluding": "1.2.3"}
+		]},
+		{"operator": "OR", "cpe_match": [
+			{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar_client:*:*:*:*:*:*:*:*", "versionEndExcluding": "1.2.3"}
+		]}
+	]}}`)
+	validVersions := []string{"1.0", "1.1", "1.2"}
+	expectedNotes := []string{
+		"cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:* has no start version, using 0 as introduced version",
+		"Warning: 1.2.3 is not a valid fixed version",
+		"cpe:2.3:a:foo:bar_client:*:*:*:*:*:*:*:* has no start version, using 0 as introduced version",
+		"Valid versions:",
+		"  - 1.0",
+		"  - 1.1",
+		"  - 1.2",
+	}
+
+	for i := 0; i < 3; i++ {
+		_, gotNotes := ExtractVersionInfo(cve, validVersions)
+		if diff := cmp.Diff(gotNotes, expectedNotes); diff != "" {
+			t.Errorf("run %d: notes were incorrect: %s", i, diff)
+		}
+	}
+}
+
+func TestGistURLs(t *testing.T) {
+	tests := []struct {
+		description  string
+		inputLink    string
+		expectedGist bool
+	}{
+		{
+			description:  "Gist URL",
+			inputLink:    "https://gist.github.com/someone/0123456789abcdef0123456789abcdef",
+			expectedGist: true,
+		},
+		{
+			description:  "Gist revision URL",
+			inputLink:    "https://gist.github.com/someone/0123456789abcdef0123456789abcdef/6f2a1c9b7e0d4a5f8b3c2e1d0a9b8c7d6e5f4a3b",
+			expectedGist: true,
+		},
+		{
+			description:  "GitHub commit URL",
+			inputLink:    "https://github.com/google/osv/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5",
+			expectedGist: false,
+		},
+	}
+
+	for _, tc := range tests {
+		if got := IsGist(tc.inputLink); got != tc.expectedGist {
+			t.Errorf("test %q: IsGist(%q) was incorrect, got: %t, expected: %t", tc.description, tc.inputLink, got, tc.expectedGist)
+		}
+		if !tc.expectedGist {
+			continue
+		}
+		if _, err := Repo(tc.inputLink); !errors.Is(err, ErrGist) {
+			t.Errorf("test %q: Repo(%q) returned %v, expected ErrGist", tc.description, tc.inputLink, err)
+		}
+		if _, err := Commit(tc.inputLink); !errors.Is(err, ErrGist) {
+			t.Errorf("test %q: Commit(%q) returned %v, expected ErrGist", tc.description, tc.inputLink, err)
+		}
+	}
+}
+
+func TestIsCommitHash(t *testing.T) {
+	tests := []struct {
+		description    string
+		inputHash      string
+		inputMinLength int
+		inputMaxLength int
+		expectedOk     bool
+	}{
+		{
+			description:    "Full SHA-1 hash",
+			inputHash:      "cd4e934d0527e5010e373e7fed54ef5daefba2f5",
+			inputMinLength: 7,
+			inputMaxLength: 64,
+			expectedOk:     true,
+		},
+		{
+			description:    "Abbreviated hash",
+			inputHash:      "4367A20CC4",
+			inputMinLength: 7,
+			inputMaxLength: 64,
+			expectedOk:     true,
+		},
+		{
+			description:    "Empty string",
+			inputHash:      "",
+			inputMinLength: 7,
+			inputMaxLength: 64,
+			expectedOk:     false,
+		},
+		{
+			description:    "Branch name",
+			inputHash:      "deadbeef-fix",
+			inputMinLength: 7,
+			inputMaxLength: 64,
+			expectedOk:     false,
+		},
+		{
+			description:    "Abbreviated hash when full SHA-1 hashes are required",
+			inputHash:      "4367a20cc4",
+			inputMinLength: 40,
+			inputMaxLength: 40,
+			expectedOk:     false,
+		},
+		{
+			description:    "SHA-256 hash when only SHA-1 hashes are allowed",
+			inputHash:      "5f2b8e6b7e0a4f5d9c3b2a1e0f9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d",
+			inputMinLength: 7,
+			inputMaxLength: 40,
+			expectedOk:     false,
+		},
+	}
+
+	defer func(min, max int) {
+		MinCommitHashLength, MaxCommitHashLength = min, max
+	}(MinCommitHashLength, MaxCommitHashLength)
+	for _, tc := range tests {
+		MinCommitHashLength, MaxCommitHashLength = tc.inputMinLength, tc.inputMaxLength
+		if got := IsCommitHash(tc.inputHash); got != tc.expectedOk {
+			t.Errorf("test %q: IsCommitHash(%q) was incorrect, got: %t, expected: %t", tc.description, tc.inputHash, got, tc.expectedOk)
+		}
+	}
+}
+
+func TestExtractVersionInfoPreferLastAffected(t *testing.T) {
+	startAndEnd := `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndIncluding": "1.2"},
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "2.0", "versionEndExcluding": "2.1"}
+	]}]}}`
+	endOnly := `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionEndIncluding": "1.2.3"}
+	]}]}}`
+	validVersions := []string{"1.0", "1.1", "1.2", "1.3", "2.0", "2.1"}
+	tests := []struct {
+		description              string
+		inputCVEItem             string
+		inputValidVersions       []string
+		inputOptions             ExtractOptions
+		expectedAffectedVersions []AffectedVersion
+	}{
+		{
+			description:        "Fixed version is inferred by default",
+			inputCVEItem:       startAndEnd,
+			inputValidVersions: validVersions,
+			inputOptions:       ExtractOptions{},
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "1.0", Fixed: "1.3"},
+				{Introduced: "2.0", Fixed: "2.1"},
+			},
+		},
+		{
+			description:        "versionEndIncluding is used as last_affected",
+			inputCVEItem:       startAndEnd,
+			inputValidVersions: validVersions,
+			inputOptions:       ExtractOptions{PreferLastAffected: true},
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "1.0", LastAffected: "1.2"},
+				{Introduced: "2.0", Fixed: "2.1"},
+			},
+		},
+		{
+			description:              "Unresolvable versionEndIncluding alone is skipped by default",
+			inputCVEItem:             endOnly,
+			inputValidVersions:       nil,
+			inputOptions:             ExtractOptions{},
+			expectedAffectedVersions: nil,
+		},
+		{
+			description:        "versionEndIncluding alone is used as last_affected",
+			inputCVEItem:       endOnly,
+			inputValidVersions: nil,
+			inputOptions:       ExtractOptions{PreferLastAffected: true},
+			expectedAffectedVersions: []AffectedVersion{
+				{LastAffected: "1.2.3"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		cve := cveItemFromJSON(t, tc.inputCVEItem)
+		gotVersionInfo, _ := ExtractVersionInfoWithOptions(cve, tc.inputValidVersions, tc.inputOptions)
+		if diff := cmp.Diff(gotVersionInfo.AffectedVersions, tc.expectedAffectedVersions, ignoreSource, ignoreNotes); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestBestEffortRepo(t *testing.T) {
+	tests := []struct {
+		description  string
+		inputCVEItem string
+		expectedRepo string
+		expectedOk   bool
+	}{
+		{
+			description: "No repository references",
+			inputCVEItem: `{"cve": {"CVE_data_meta": {"ID": "CVE-2023-0001"}, "references": {"reference_data": [
+				{"url": "https://example.com/advisory"}
+			]}}}`,
+			expectedRepo: "",
+			expectedOk:   false,
+		},
+		{
+			description: "Most frequently referenced repository",
+			inputCVEItem: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/foo/docs/issues/1"},
+				{"url": "https://github.com/foo/bar"},
+				{"url": "https://github.com/foo/bar/issues/123"},
+				{"url": "https://example.com/advisory"}
+			]}}}`,
+			expectedRepo: "https://github.com/foo/bar",
+			expectedOk:   true,
+		},
+		{
+			description: "Ties favour the first referenced repository",
+			inputCVEItem: `{"cve": {"references": {"reference_data": [
+				{"url": "https://gitlab.com/foo/bar/-/issues/1"},
+				{"url": "https://github.com/foo/bar/issues/123"}
+			]}}}`,
+			expectedRepo: "https://gitlab.com/foo/bar",
+			expectedOk:   true,
+		},
+		{
+			description: "Denylisted repositories are disregarded",
+			inputCVEItem: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/Accenture/AARO-Bugs/blob/master/CVE-2023-0001.md"},
+				{"url": "https://github.com/Accenture/AARO-Bugs"},
+				{"url": "https://github.com/foo/bar"}
+			]}}}`,
+			expectedRepo: "https://github.com/foo/bar",
+			expectedOk:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := BestEffortRepo(cveItemFromJSON(t, tc.inputCVEItem))
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: BestEffortRepo() unexpectedly failed: %+v", tc.description, err)
+		}
+		if err == nil && !tc.expectedOk {
+			t.Errorf("test %q: BestEffortRepo() unexpectedly succeeded", tc.description)
+		}
+		if got != tc.expectedRepo {
+			t.Errorf("test %q: BestEffortRepo() was incorrect, got: %q, expected: %q", tc.description, got, tc.expectedRepo)
+		}
+	}
+}
+
+func TestNormalizeVersionCache(t *testing.T) {
+	defer func(enabled bool, max int) {
+		NormalizeVersionCacheEnabled, MaxNormalizeVersionCacheEntries = enabled, max
+		ClearNormalizeVersionCache()
+	}(NormalizeVersionCacheEnabled, MaxNormalizeVersionCacheEntries)
+	NormalizeVersionCacheEnabled = true
+	MaxNormalizeVersionCacheEntries = 2
+	ClearNormalizeVersionCache()
+
+	// Each version is normalized twice, the second time from the cache, and the cache overflows along the way.
+	for _, version := range []string{"1.2.3", "v4.5.6-rc1", "", "1_2_3", "1.2.3"} {
+		for i := 0; i < 2; i++ {
+			expected, expectedErr := normalizeVersion(version)
+			got, err := NormalizeVersion(version)
+			if got != expected || (err == nil) != (expectedErr == nil) {
+				t.Errorf("NormalizeVersion(%q) call %d was incorrect, got: %q (%v), expected: %q (%v)", version, i, got, err, expected, expectedErr)
+			}
+		}
+	}
+	if entries := normalizeVersionCacheEntries.Load(); entries > int64(MaxNormalizeVersionCacheEntries) {
+		t.Errorf("NormalizeVersion cache has %d entries, expected at most %d", entries, MaxNormalizeVersionCacheEntries)
+	}
+}
+
+func TestNormalizeVersionFor(t *testing.T) {
+	tests := []struct {
+		description     string
+		inputVersion    string
+		inputEcosystem  string
+		expectedVersion string
+		expectedOk      bool
+	}{
+		{
+			description:     "Unknown provenance",
+			inputVersion:    "v1.2.3",
+			inputEcosystem:  "",
+			expectedVersion: "1-2-3",
+			expectedOk:      true,
+		},
+		{
+			description:     "Incomplete SemVer",
+			inputVersion:    "v1.2",
+			inputEcosystem:  "npm",
+			expectedVersion: "1.2.0",
+			expectedOk:      true,
+		},
+		{
+			description:     "SemVer with a pre-release and build metadata",
+			inputVersion:    "1.02.3-rc.1+build5",
+			inputEcosystem:  "Go",
+			expectedVersion: "1.2.3-rc.1",
+			expectedOk:      true,
+		},
+		{
+			description:    "Not SemVer",
+			inputVersion:   "1.2.3.4",
+			inputEcosystem: "crates.io",
+			expectedOk:     false,
+		},
+		{
+			description:     "Debian version with a zero epoch",
+			inputVersion:    "0:2.36-9+deb12u1",
+			inputEcosystem:  "Debian",
+			expectedVersion: "2.36-9+deb12u1",
+			expectedOk:      true,
+		},
+		{
+			description:     "Ubuntu version with an epoch",
+			inputVersion:    "1:9.18.12-0ubuntu0.22.04.1",
+			inputEcosystem:  "Ubuntu",
+			expectedVersion: "1:9.18.12-0ubuntu0.22.04.1",
+			expectedOk:      true,
+		},
+		{
+			description:    "Not a Debian version",
+			inputVersion:   "debian/2.36",
+			inputEcosystem: "Debian",
+			expectedOk:     false,
+		},
+		{
+			description:    "Unsupported ecosystem",
+			inputVersion:   "1.2.3",
+			inputEcosystem: "Hackage",
+			expectedOk:     false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := NormalizeVersionFor(tc.inputVersion, tc.inputEcosystem)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: NormalizeVersionFor(%q, %q) returned unexpected error: %v", tc.description, tc.inputVersion, tc.inputEcosystem, err)
+		}
+		if got != tc.expectedVersion {
+			t.Errorf("test %q: NormalizeVersionFor(%q, %q) was incorrect, got: %q, expected: %q", tc.description, tc.inputVersion, tc.inputEcosystem, got, tc.expectedVersion)
+		}
+	}
+}
+
+func TestNormalizeVersionForCache(t *testing.T) {
+	defer func(enabled bool) {
+		NormalizeVersionCacheEnabled = enabled
+		ClearNormalizeVersionCache()
+	}(NormalizeVersionCacheEnabled)
+	NormalizeVersionCacheEnabled = true
+	ClearNormalizeVersionCache()
+
+	// The same version is cached separately for each ecosystem.
+	for i := 0; i < 2; i++ {
+		for ecosystem, expected := range map[string]string{"": "1-2", "npm": "1.2.0", "Debian": ""} {
+			got, _ := NormalizeVersionFor("v1.2", ecosystem)
+			if got != expected {
+				t.Errorf("NormalizeVersionFor(%q, %q) call %d was incorrect, got: %q, expected: %q", "v1.2", ecosystem, i, got, expected)
+			}
+		}
+	}
+	if entries := normalizeVersionCacheEntries.Load(); entries != 3 {
+		t.Errorf("NormalizeVersionFor cache has %d entries, expected 3", entries)
+	}
+}
+
+func BenchmarkNormalizeVersion(b *testing.B) {
+	// A realistic workload: the versions of a CVE's CPE matches, many of which recur across CVEs.
+	cve := loadTestData("CVE-2022-32746")
+	var versions []string
+	for _, node := range cve.Configurations.Nodes {
+		for _, match := range node.CPEMatch {
+			versions = append(versions, match.VersionStartIncluding, match.VersionEndExcluding, match.VersionEndIncluding)
+		}
+	}
+	versions = append(versions, "1.2.3", "v2.0.0-rc1", "release-1_2_3", "1.2.3+build5")
+
+	defer func(enabled bool) {
+		NormalizeVersionCacheEnabled = enabled
+		ClearNormalizeVersionCache()
+	}(NormalizeVersionCacheEnabled)
+	for _, cacheEnabled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cache=%t", cacheEnabled), func(b *testing.B) {
+			NormalizeVersionCacheEnabled = cacheEnabled
+			ClearNormalizeVersionCache()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, version := range versions {
+					_, _ = NormalizeVersion(version)
+				}
+			}
+		})
+	}
+}
+
+func TestSameRepo(t *testing.T) {
+	tests := []struct {
+		description  string
+		inputRepoA   string
+		inputRepoB   string
+		expectedSame bool
+	}{
+		{
+			description:  "Identical URLs",
+			inputRepoA:   "https://github.com/google/osv.dev",
+			inputRepoB:   "https://github.com/google/osv.dev",
+			expectedSame: true,
+		},
+		{
+			description:  "Differing scheme, case and .git suffix",
+			inputRepoA:   "http://github.com/Google/osv.dev.git",
+			inputRepoB:   "https://github.com/google/osv.dev/",
+			expectedSame: true,
+		},
+		{
+			description:  "Commit URL and repository URL",
+			inputRepoA:   "https://github.com/google/osv.dev/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5",
+			inputRepoB:   "https://github.com/google/osv.dev",
+			expectedSame: true,
+		},
+		{
+			description:  "GitLab FOSS mirror",
+			inputRepoA:   "https://gitlab.com/gitlab-org/gitlab-foss",
+			inputRepoB:   "https://gitlab.com/gitlab-org/gitlab.git",
+			expectedSame: true,
+		},
+		{
+			description:  "Freedesktop cGit mirror",
+			inputRepoA:   "https://cgit.freedesktop.org/xorg/lib/libXRes",
+			inputRepoB:   "https://gitlab.freedesktop.org/xorg/lib/libXRes",
+			expectedSame: true,
+		},
+		{
+			description:  "Linux kernel GitHub mirror",
+			inputRepoA:   "https://github.com/torvalds/linux",
+			inputRepoB:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+			expectedSame: true,
+		},
+		{
+			description:  "Apache GitHub mirror",
+			inputRepoA:   "https://gitbox.apache.org/repos/asf?p=commons-text.git;a=commit;h=b9b40b903e2d1f9935039803c9852439576780ea",
+			inputRepoB:   "https://github.com/apache/commons-text",
+			expectedSame: true,
+		},
+		{
+			description:  "Different repositories",
+			inputRepoA:   "https://github.com/google/osv.dev",
+			inputRepoB:   "https://github.com/google/osv-scanner",
+			expectedSame: false,
+		},
+	}
+
+	for _, tc := range tests {
+		if got := SameRepo(tc.inputRepoA, tc.inputRepoB); got != tc.expectedSame {
+			t.Errorf("test %q: SameRepo(%q, %q) was incorrect, got: %t, expected: %t", tc.description, tc.inputRepoA, tc.inputRepoB, got, tc.expectedSame)
+		}
+	}
+}
+
+func TestWildcardVersionRange(t *testing.T) {
+	tests := []struct {
+		description             string
+		inputVersion            string
+		inputValidVersions      []string
+		expectedAffectedVersion AffectedVersion
+		expectedNote            bool
+		expectedOk              bool
+	}{
+		{
+			description:  "Not a wildcard version",
+			inputVersion: "1.2.3",
+			expectedOk:   false,
+		},
+		{
+			description:             "Wildcard without valid versions",
+			inputVersion:            "1.2.*",
+			expectedAffectedVersion: AffectedVersion{Introduced: "1.2.0", Fixed: "1.3.0"},
+			expectedOk:              true,
+		},
+		{
+			description:             "Major release line without valid versions",
+			inputVersion:            "v3.x",
+			expectedAffectedVersion: AffectedVersion{Introduced: "3.0", Fixed: "4.0"},
+			expectedOk:              true,
+		},
+		{
+			description:             "Wildcard with valid versions",
+			inputVersion:            "1.2.x",
+			inputValidVersions:      []string{"v1.1.0", "v1.2.1", "v1.2.2", "v1.3.0"},
+			expectedAffectedVersion: AffectedVersion{Introduced: "v1.2.1", Fixed: "v1.3.0"},
+			expectedOk:              true,
+		},
+		{
+			description:             "Wildcard for the latest release line",
+			inputVersion:            "1.3.*",
+			inputValidVersions:      []string{"1.2.0", "1.3.0", "1.3.1"},
+			expectedAffectedVersion: AffectedVersion{Introduced: "1.3.0", LastAffected: "1.3.1"},
+			expectedNote:            true,
+			expectedOk:              true,
+		},
+		{
+			description:        "Wildcard for a release line without valid versions",
+			inputVersion:       "1.4.*",
+			inputValidVersions: []string{"1.2.0", "1.3.0", "1.3.1"},
+			expectedNote:       true,
+			expectedOk:         false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, gotNotes, ok := wildcardVersionRange(tc.inputValidVersions, tc.inputVersion)
+		if ok != tc.expectedOk {
+			t.Errorf("test %q: wildcardVersionRange(%q) ok was incorrect, got: %t, expected: %t", tc.description, tc.inputVersion, ok, tc.expectedOk)
+		}
+		if diff := cmp.Diff(got, tc.expectedAffectedVersion); diff != "" {
+			t.Errorf("test %q: wildcardVersionRange(%q) was incorrect: %s", tc.description, tc.inputVersion, diff)
+		}
+		if (len(gotNotes) > 0) != tc.expectedNote {
+			t.Errorf("test %q: wildcardVersionRange(%q) notes were incorrect, got: %#v", tc.description, tc.inputVersion, gotNotes)
+		}
+	}
+}
+
+func TestExtractVersionInfoWildcardCPE(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:1.2.*:*:*:*:*:*:*:*"}
+	]}]}}`)
+	expectedAffectedVersions := []AffectedVersion{{Introduced: "1.2.0", Fixed: "1.3.0"}}
+
+	gotVersionInfo, _ := ExtractVersionInfo(cve, nil)
+	if diff := cmp.Diff(gotVersionInfo.AffectedVersions, expectedAffectedVersions, ignoreSource, ignoreNotes); diff != "" {
+		t.Errorf("AffectedVersions were incorrect: %s", diff)
+	}
+}
+
+func TestExtractCommits(t *testing.T) {
+	tests := []struct {
+		description     string
+		inputCVEItem    CVEItem
+		expectedCommits []GitCommit
+	}{
+		{
+			description:  "A CVE with a commit reference",
+			inputCVEItem: loadTestData("CVE-2022-3037"),
+			expectedCommits: []GitCommit{
+				{
+					Repo:   "https://github.com/vim/vim",
+					Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb",
+				},
+			},
+		},
+		{
+			description: "A CVE referencing the same commit repeatedly",
+			inputCVEItem: cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/google/osv/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5"},
+				{"url": "https://github.com/google/osv/issues/123"},
+				{"url": "https://github.com/google/osv/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5?utm_source=feed"},
+				{"url": "https://gitlab.com/qemu-project/qemu/-/commit/4367a20cc4"}
+			]}}}`),
+			expectedCommits: []GitCommit{
+				{
+					Repo:   "https://github.com/google/osv",
+					Commit: "cd4e934d0527e5010e373e7fed54ef5daefba2f5",
+				},
+				{
+					Repo:   "https://gitlab.com/qemu-project/qemu",
+					Commit: "4367a20cc4",
+				},
+			},
+		},
+		{
+			description:     "A CVE without commit references",
+			inputCVEItem:    cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [{"url": "https://example.com/advisory"}]}}}`),
+			expectedCommits: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		got := ExtractCommits(tc.inputCVEItem)
+		if diff := cmp.Diff(got, tc.expectedCommits); diff != "" {
+			t.Errorf("test %q: ExtractCommits() was incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestAddInvalidRepoRegex(t *testing.T) {
+	defer func(regexes []*regexp.Regexp) {
+		invalidRepoRegexes = regexes
+	}(invalidRepoRegexes)
+
+	const repo = "https://github.com/someone/exploit-poc"
+	if _, err := Repo(repo); err != nil {
+		t.Fatalf("Repo(%q) unexpectedly failed: %v", repo, err)
+	}
+	if err := AddInvalidRepoRegex(`(?i)/[^/]+-poc$`); err != nil {
+		t.Fatalf("AddInvalidRepoRegex() unexpectedly failed: %v", err)
+	}
+	if _, err := Repo(repo); err == nil {
+		t.Errorf("Repo(%q) unexpectedly succeeded after adding a matching invalid repo regexp", repo)
+	}
+	if err := AddInvalidRepoRegex(`(unbalanced`); err == nil {
+		t.Errorf("AddInvalidRepoRegex() unexpectedly accepted an invalid pattern")
+	}
+}
+
+func TestLoadInvalidRepoRegexes(t *testing.T) {
+	defer func(regexes []*regexp.Regexp) {
+		invalidRepoRegexes = regexes
+	}(invalidRepoRegexes)
+
+	tests := []struct {
+		description     string
+		inputContent    string
+		expectedPattern int
+		expectedOk      bool
+	}{
+		{
+			description:     "Valid patterns, comments and blank lines",
+			inputContent:    "# Proof of concept repos\n(?i)/[^/]+-poc$\n\n(?i)/exploits?$\n",
+			expectedPattern: 2,
+			expectedOk:      true,
+		},
+		{
+			description:     "An invalid pattern",
+			inputContent:    "(?i)/[^/]+-poc$\n(unbalanced\n",
+			expectedPattern: 0,
+			expectedOk:      false,
+		},
+	}
+
+	for _, tc := range tests {
+		invalidRepoRegexes = nil
+		path := filepath.Join(t.TempDir(), "invalid_repos.txt")
+		if err := os.WriteFile(path, []byte(tc.inputContent), 0644); err != nil {
+			t.Fatalf("Failed to write test config: %v", err)
+		}
+		err := LoadInvalidRepoRegexes(path)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: LoadInvalidRepoRegexes() returned %v, expected success: %t", tc.description, err, tc.expectedOk)
+		}
+		if len(invalidRepoRegexes) != tc.expectedPattern {
+			t.Errorf("test %q: LoadInvalidRepoRegexes() loaded %d patterns, expected %d", tc.description, len(invalidRepoRegexes), tc.expectedPattern)
+		}
+	}
+}
+
+func TestExtractVersionsFromReferenceContent(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputContent     string
+		expectedVersions []AffectedVersion
+	}{
+		{
+			description:      "No labelled versions",
+			inputContent:     "# Advisory\n\nA buffer overflow was found in foo.\n",
+			expectedVersions: nil,
+		},
+		{
+			description:      "Plain text introduced and fixed lines",
+			inputContent:     "Introduced: 1.0.0\nFixed: 1.2.4\n",
+			expectedVersions: []AffectedVersion{{Introduced: "1.0.0", Fixed: "1.2.4"}},
+		},
+		{
+			description:      "Markdown list with emphasis",
+			inputContent:     "## Details\n\n* **Affected:** 1.2.3\n* **Fixed in:** `1.2.4`, `2.0.1`\n",
+			expectedVersions: []AffectedVersion{{Fixed: "1.2.4"}, {Fixed: "2.0.1"}},
+		},
+		{
+			description:      "Wiki style headings",
+			inputContent:     "== Affected == >= 2.0, < 2.3.1\n",
+			expectedVersions: []AffectedVersion{{Introduced: "2.0", Fixed: "2.3.1"}},
+		},
+		{
+			description:      "Markdown table",
+			inputContent:     "| Package | Version |\n|---|---|\n| Affected versions | <= 4.1.7 |\n",
+			expectedVersions: []AffectedVersion{{LastAffected: "4.1.7"}},
+		},
+		{
+			description:      "Unbounded affected versions without a fix",
+			inputContent:     "Vulnerable versions: v3.2.0\n",
+			expectedVersions: []AffectedVersion{{Introduced: "3.2.0", LastAffected: "3.2.0"}},
+		},
+	}
+
+	for _, tc := range tests {
+		got, _ := extractVersionsFromReferenceContent(tc.inputContent)
+		if diff := cmp.Diff(got, tc.expectedVersions); diff != "" {
+			t.Errorf("test %q: extractVersionsFromReferenceContent(%q) was incorrect: %s", tc.description, tc.inputContent, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoWithReferenceFetcher(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [
+		{"url": "https://github.com/foo/bar/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5"},
+		{"url": "https://example.com/advisory.md"},
+		{"url": "https://example.com/unreachable"}
+	]}}}`)
+	fetcher := func(u string) (string, error) {
+		switch u {
+		case "https://example.com/advisory.md":
+			return "### Versions\n- Introduced: 1.1\n- Fixed: 1.4\n", nil
+		case "https://example.com/unreachable":
+			return "", errors.New("connection refused")
+		}
+		t.Errorf("Unexpected fetch of %s", u)
+		return "", nil
+	}
+	expectedAffectedVersions := []AffectedVersion{{Introduced: "1.1", Fixed: "1.4"}}
+
+	var diag ExtractDiagnostics
+	gotVersionInfo, gotNotes := ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{ReferenceFetcher: fetcher, Diagnostics: &diag})
+	if diff := cmp.Diff(gotVersionInfo.AffectedVersions, expectedAffectedVersions, ignoreSource); diff != "" {
+		t.Errorf("AffectedVersions were incorrect: %s", diff)
+	}
+	if diag.ReferenceContentVersions != 1 || diag.DescriptionFallbackRan {
+		t.Errorf("Diagnostics were incorrect: %+v", diag)
+	}
+	if expectedNote := "Unable to fetch https://example.com/unreachable: connection refused"; !slices.Contains(gotNotes, expectedNote) {
+		t.Errorf("notes %#v did not contain %q", gotNotes, expectedNote)
+	}
+}
+
+func TestGitLabBlobRef(t *testing.T) {
+	tests := []struct {
+		description  string
+		inputLink    string
+		expectedRef  string
+		expectedKind RefKind
+		expectedOk   bool
+	}{
+		{
+			description:  "Tag",
+			inputLink:    "https://gitlab.com/gitlab-org/gitlab/-/blob/v15.3.1/app/models/user.rb",
+			expectedRef:  "v15.3.1",
+			expectedKind: TagRef,
+			expectedOk:   true,
+		},
+		{
+			description:  "Release tag",
+			inputLink:    "https://gitlab.com/foo/bar/-/blob/release-2.0.1/README.md",
+			expectedRef:  "release-2.0.1",
+			expectedKind: TagRef,
+			expectedOk:   true,
+		},
+		{
+			description:  "Branch",
+			inputLink:    "https://gitlab.com/gitlab-org/gitlab/-/blob/master/app/models/user.rb",
+			expectedRef:  "master",
+			expectedKind: BranchRef,
+			expectedOk:   true,
+		},
+		{
+			description:  "Release branch",
+			inputLink:    "https://gitlab.com/gitlab-org/gitlab/-/blob/15-3-stable-ee/app/models/user.rb",
+			expectedRef:  "15-3-stable-ee",
+			expectedKind: BranchRef,
+			expectedOk:   true,
+		},
+		{
+			description:  "Commit",
+			inputLink:    "https://gitlab.com/gitlab-org/gitlab/-/blob/4367a20cc4/app/models/user.rb",
+			expectedRef:  "4367a20cc4",
+			expectedKind: CommitRef,
+			expectedOk:   true,
+		},
+		{
+			description: "Not a blob URL",
+			inputLink:   "https://gitlab.com/gitlab-org/gitlab/-/commit/4367a20cc4",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		gotRef, gotKind, err := GitLabBlobRef(tc.inputLink)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: GitLabBlobRef(%q) returned error %v, expected success: %t", tc.description, tc.inputLink, err, tc.expectedOk)
+			continue
+		}
+		if gotRef != tc.expectedRef || (tc.expectedOk && gotKind != tc.expectedKind) {
+			t.Errorf("test %q: GitLabBlobRef(%q) was incorrect, got: %q (%s), expected: %q (%s)", tc.description, tc.inputLink, gotRef, gotKind, tc.expectedRef, tc.expectedKind)
+		}
+	}
+}
+
+func TestPlausibleCalVer(t *testing.T) {
+	tests := []struct {
+		description        string
+		inputVersion       string
+		inputPublishedYear int
+		expectedPlausible  bool
+	}{
+		{
+			description:        "Not a calendar version",
+			inputVersion:       "1.2.3",
+			inputPublishedYear: 2022,
+			expectedPlausible:  true,
+		},
+		{
+			description:        "Calendar version from the year of publication",
+			inputVersion:       "2022.08.1",
+			inputPublishedYear: 2022,
+			expectedPlausible:  true,
+		},
+		{
+			description:        "Calendar version from the year after publication",
+			inputVersion:       "v2023.1",
+			inputPublishedYear: 2022,
+			expectedPlausible:  true,
+		},
+		{
+			description:        "Calendar version from the far future",
+			inputVersion:       "2031.1.0",
+			inputPublishedYear: 2022,
+			expectedPlausible:  false,
+		},
+		{
+			description:        "Bare build number",
+			inputVersion:       "2100",
+			inputPublishedYear: 2022,
+			expectedPlausible:  true,
+		},
+		{
+			description:        "CVE ID fragment",
+			inputVersion:       "2022-31627",
+			inputPublishedYear: 2022,
+			expectedPlausible:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		if got := PlausibleCalVer(tc.inputVersion, tc.inputPublishedYear); got != tc.expectedPlausible {
+			t.Errorf("test %q: PlausibleCalVer(%q, %d) was incorrect, got: %t, expected: %t", tc.description, tc.inputVersion, tc.inputPublishedYear, got, tc.expectedPlausible)
+		}
+	}
+}
+
+func TestExtractVersionInfoImplausibleCalVer(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"publishedDate": "2022-08-30T22:15Z",
+		"cve": {"description": {"description_data": [{"lang": "en", "value": "Foo before 2031.1 allows remote code execution."}]}}}`)
+	expectedNote := "Warning: 2031.1 is implausible as a calendar version for a CVE published in 2022"
+
+	_, gotNotes := ExtractVersionInfo(cve, nil)
+	if !slices.Contains(gotNotes, expectedNote) {
+		t.Errorf("notes %#v did not contain %q", gotNotes, expectedNote)
+	}
+}
+
+func TestVersionInfoByRepo(t *testing.T) {
+	repoA := "https://github.com/foo/bar"
+	repoB := "https://github.com/foo/bar-fork"
+	tests := []struct {
+		description      string
+		inputVersionInfo VersionInfo
+		expectedByRepo   map[string]VersionInfo
+	}{
+		{
+			description:      "Empty",
+			inputVersionInfo: VersionInfo{},
+			expectedByRepo:   map[string]VersionInfo{},
+		},
+		{
+			description: "Commits in multiple repositories and affected versions",
+			inputVersionInfo: VersionInfo{
+				IntroducedCommits:   []GitCommit{{Repo: repoA, Commit: "aaaaaaa"}},
+				FixCommits:          []GitCommit{{Repo: repoA, Commit: "bbbbbbb"}, {Repo: repoB, Commit: "ccccccc"}},
+				LastAffectedCommits: []GitCommit{{Repo: repoB, Commit: "ddddddd"}},
+				AffectedVersions:    []AffectedVersion{{Fixed: "1.2.3"}},
+			},
+			expectedByRepo: map[string]VersionInfo{
+				repoA: {
+					IntroducedCommits: []GitCommit{{Repo: repoA, Commit: "aaaaaaa"}},
+					FixCommits:        []GitCommit{{Repo: repoA, Commit: "bbbbbbb"}},
+				},
+				repoB: {
+					FixCommits:          []GitCommit{{Repo: repoB, Commit: "ccccccc"}},
+					LastAffectedCommits: []GitCommit{{Repo: repoB, Commit: "ddddddd"}},
+				},
+				"": {
+					AffectedVersions: []AffectedVersion{{Fixed: "1.2.3"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		if diff := cmp.Diff(tc.inputVersionInfo.ByRepo(), tc.expectedByRepo); diff != "" {
+			t.Errorf("test %q: ByRepo() was incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestParseCPERaw(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputCPEString   string
+		expectedProduct  string
+		expectedVersion  string
+		expectedUnquoted [2]string
+		expectedRemoved  [2]string
+	}{
+		{
+			description:      "Escaped colons",
+			inputCPEString:   `cpe:2.3:a:http\:\:daemon_project:http\:\:daemon:1.0\:beta:*:*:*:*:*:*:*`,
+			expectedProduct:  `http\:\:daemon`,
+			expectedVersion:  `1\.0\:beta`,
+			expectedUnquoted: [2]string{"http::daemon", "1.0:beta"},
+			expectedRemoved:  [2]string{"http::daemon", "1.0:beta"},
+		},
+		{
+			description:      "Escaped backslash",
+			inputCPEString:   `cpe:2.3:a:vendor:foo\\bar:1.0:*:*:*:*:*:*:*`,
+			expectedProduct:  `foo\\bar`,
+			expectedVersion:  `1\.0`,
+			expectedUnquoted: [2]string{`foo\bar`, "1.0"},
+			expectedRemoved:  [2]string{"foobar", "1.0"},
+		},
+		{
+			description:      "Escaped asterisk and plus signs",
+			inputCPEString:   `cpe:2.3:a:vendor:c\+\+_lib:1.\*:*:*:*:*:*:*:*`,
+			expectedProduct:  `c\+\+_lib`,
+			expectedVersion:  `1\.\*`,
+			expectedUnquoted: [2]string{"c++_lib", "1.*"},
+			expectedRemoved:  [2]string{"c++_lib", "1.*"},
+		},
+		{
+			description:      "Unescaped wildcard",
+			inputCPEString:   `cpe:2.3:a:vendor:prod:1.2.*:*:*:*:*:*:*:*`,
+			expectedProduct:  "prod",
+			expectedVersion:  `1\.2\.*`,
+			expectedUnquoted: [2]string{"prod", "1.2.*"},
+			expectedRemoved:  [2]string{"prod", "1.2.*"},
+		},
+	}
+
+	for _, tc := range tests {
+		raw, err := ParseCPERaw(tc.inputCPEString)
+		if err != nil {
+			t.Fatalf("test %q: ParseCPERaw(%q) unexpectedly failed: %v", tc.description, tc.inputCPEString, err)
+		}
+		if raw.Product != tc.expectedProduct || raw.Version != tc.expectedVersion {
+			t.Errorf("test %q: ParseCPERaw(%q) was incorrect, got: %q %q, expected: %q %q", tc.description, tc.inputCPEString, raw.Product, raw.Version, tc.expectedProduct, tc.expectedVersion)
+		}
+		if got := [2]string{UnquoteWFN(raw.Product), UnquoteWFN(raw.Version)}; got != tc.expectedUnquoted {
+			t.Errorf("test %q: UnquoteWFN() was incorrect, got: %q, expected: %q", tc.description, got, tc.expectedUnquoted)
+		}
+		cpe, err := ParseCPE(tc.inputCPEString)
+		if err != nil {
+			t.Fatalf("test %q: ParseCPE(%q) unexpectedly failed: %v", tc.description, tc.inputCPEString, err)
+		}
+		if got := [2]string{cpe.Product, cpe.Version}; got != tc.expectedRemoved {
+			t.Errorf("test %q: ParseCPE(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputCPEString, got, tc.expectedRemoved)
+		}
+	}
+}
+
+func TestGitLabAdvisoryJSON(t *testing.T) {
+	tests := []struct {
+		description    string
+		inputLink      string
+		expectedRawURL string
+		expectedOk     bool
+	}{
+		{
+			description:    "GitLab advisory blob",
+			inputLink:      "https://gitlab.com/gitlab-org/cves/-/blob/master/2022/CVE-2022-2501.json",
+			expectedRawURL: "https://gitlab.com/gitlab-org/cves/-/raw/master/2022/CVE-2022-2501.json",
+			expectedOk:     true,
+		},
+		{
+			description:    "GitLab advisory raw",
+			inputLink:      "https://gitlab.com/gitlab-org/cves/-/raw/master/2022/CVE-2022-2501.json",
+			expectedRawURL: "https://gitlab.com/gitlab-org/cves/-/raw/master/2022/CVE-2022-2501.json",
+			expectedOk:     true,
+		},
+		{
+			description: "Code blob",
+			inputLink:   "https://gitlab.com/gitlab-org/gitlab/-/blob/master/app/models/user.rb",
+			expectedOk:  false,
+		},
+		{
+			description: "Non-GitLab host",
+			inputLink:   "https://example.com/gitlab-org/cves/-/blob/master/2022/CVE-2022-2501.json",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		gotRawURL, gotOk := GitLabAdvisoryJSON(tc.inputLink)
+		if gotRawURL != tc.expectedRawURL || gotOk != tc.expectedOk {
+			t.Errorf("test %q: GitLabAdvisoryJSON(%q) was incorrect, got: %q, %v, expected: %q, %v", tc.description, tc.inputLink, gotRawURL, gotOk, tc.expectedRawURL, tc.expectedOk)
+		}
+		if tc.expectedOk {
+			if _, err := Repo(tc.inputLink); err == nil {
+				t.Errorf("test %q: Repo(%q) unexpectedly succeeded", tc.description, tc.inputLink)
+			}
+		}
+	}
+}
+
+func TestExtractVersionsFromAdvisoryJSON(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputJSON        string
+		expectedVersions []AffectedVersion
+	}{
+		{
+			description: "CVE JSON 4.0 with constraint expressions",
+			inputJSON:   `{"data_version": "4.0", "affects": {"vendor": {"vendor_data": [{"vendor_name": "GitLab", "product": {"product_data": [{"product_name": "GitLab", "version": {"version_data": [{"version_value": ">=14.1, <15.0.5"}, {"version_value": ">=15.1, <15.1.4 || >=15.2, <=15.2.1"}]}}]}}]}}}`,
+			expectedVersions: []AffectedVersion{
+				{Introduced: "14.1", Fixed: "15.0.5"},
+				{Introduced: "15.1", Fixed: "15.1.4"},
+				{Introduced: "15.2", LastAffected: "15.2.1"},
+			},
+		},
+		{
+			description: "CVE JSON 5.x",
+			inputJSON:   `{"containers": {"cna": {"affected": [{"vendor": "GitLab", "product": "GitLab", "versions": [{"version": "14.1", "lessThan": "15.0.5", "status": "affected", "versionType": "semver"}]}]}}}`,
+			expectedVersions: []AffectedVersion{
+				{Introduced: "14.1", Fixed: "15.0.5"},
+			},
+		},
+		{
+			description: "No versions",
+			inputJSON:   `{"data_version": "4.0"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersions, _, err := extractVersionsFromAdvisoryJSON(nil, []byte(tc.inputJSON))
+		if err != nil {
+			t.Errorf("test %q: extractVersionsFromAdvisoryJSON() unexpectedly failed: %v", tc.description, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.expectedVersions, gotVersions); diff != "" {
+			t.Errorf("test %q: extractVersionsFromAdvisoryJSON() was incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestEnumerateAffected(t *testing.T) {
+	validVersions := []string{"1.0", "1.1", "1.2", "2.0", "2.1", "3.0"}
+	tests := []struct {
+		description      string
+		inputVersionInfo VersionInfo
+		expectedVersions []string
+	}{
+		{
+			description:      "Introduced and fixed",
+			inputVersionInfo: VersionInfo{AffectedVersions: []AffectedVersion{{Introduced: "1.1", Fixed: "2.0"}}},
+			expectedVersions: []string{"1.1", "1.2"},
+		},
+		{
+			description:      "From the beginning to last affected",
+			inputVersionInfo: VersionInfo{AffectedVersions: []AffectedVersion{{Introduced: "0", LastAffected: "1.1"}}},
+			expectedVersions: []string{"1.0", "1.1"},
+		},
+		{
+			description:      "Overlapping ranges",
+			inputVersionInfo: VersionInfo{AffectedVersions: []AffectedVersion{{Introduced: "2.0", Fixed: "3.0"}, {Fixed: "1.1"}, {Introduced: "2.1", LastAffected: "2.1"}}},
+			expectedVersions: []string{"1.0", "2.0", "2.1"},
+		},
+		{
+			description:      "Unknown bound",
+			inputVersionInfo: VersionInfo{AffectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.5"}}},
+		},
+		{
+			description:      "Open ended",
+			inputVersionInfo: VersionInfo{AffectedVersions: []AffectedVersion{{Introduced: "1.0"}}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersions := tc.inputVersionInfo.EnumerateAffected(validVersions)
+		if diff := cmp.Diff(tc.expectedVersions, gotVersions); diff != "" {
+			t.Errorf("test %q: EnumerateAffected() was incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestVersionInfoEvidence(t *testing.T) {
+	tests := []struct {
+		description            string
+		inputVersionInfo       VersionInfo
+		expectedFixEvidence    bool
+		expectedAnyVersionInfo bool
+	}{
+		{
+			description:      "Empty",
+			inputVersionInfo: VersionInfo{},
+		},
+		{
+			description:            "Fix commit",
+			inputVersionInfo:       VersionInfo{FixCommits: []GitCommit{{Repo: "https://github.com/foo/bar", Commit: "abc"}}},
+			expectedFixEvidence:    true,
+			expectedAnyVersionInfo: true,
+		},
+		{
+			description:            "Last affected commit",
+			inputVersionInfo:       VersionInfo{LastAffectedCommits: []GitCommit{{Repo: "https://github.com/foo/bar", Commit: "abc"}}},
+			expectedFixEvidence:    true,
+			expectedAnyVersionInfo: true,
+		},
+		{
+			description:            "Only an introduced commit",
+			inputVersionInfo:       VersionInfo{IntroducedCommits: []GitCommit{{Repo: "https://github.com/foo/bar", Commit: "abc"}}},
+			expectedAnyVersionInfo: true,
+		},
+		{
+			description:            "Fixed version",
+			inputVersionInfo:       VersionInfo{AffectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.2"}}},
+			expectedFixEvidence:    true,
+			expectedAnyVersionInfo: true,
+		},
+		{
+			description:            "Last affected version",
+			inputVersionInfo:       VersionInfo{AffectedVersions: []AffectedVersion{{LastAffected: "1.1"}}},
+			expectedFixEvidence:    true,
+			expectedAnyVersionInfo: true,
+		},
+		{
+			description:            "Open ended version range",
+			inputVersionInfo:       VersionInfo{AffectedVersions: []AffectedVersion{{Introduced: "1.0"}}},
+			expectedAnyVersionInfo: true,
+		},
+	}
+
+	for _, tc := range tests {
+		if got := tc.inputVersionInfo.HasFixEvidence(); got != tc.expectedFixEvidence {
+			t.Errorf("test %q: HasFixEvidence() was incorrect, got: %t, expected: %t", tc.description, got, tc.expectedFixEvidence)
+		}
+		if got := tc.inputVersionInfo.HasAnyVersionInfo(); got != tc.expectedAnyVersionInfo {
+			t.Errorf("test %q: HasAnyVersionInfo() was incorrect, got: %t, expected: %t", tc.description, got, tc.expectedAnyVersionInfo)
+		}
+	}
+}
+
+func TestCPEMatchAffectedVersionFixedOnly(t *testing.T) {
+	tests := []struct {
+		description     string
+		inputCPEMatch   CPEMatch
+		expectedVersion AffectedVersion
+	}{
+		{
+			description:     "Only versionEndExcluding, any version",
+			inputCPEMatch:   CPEMatch{Vulnerable: true, CPE23URI: "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", VersionEndExcluding: "1.2"},
+			expectedVersion: AffectedVersion{Introduced: "0", Fixed: "1.2"},
+		},
+		{
+			description:     "versionStartIncluding and versionEndExcluding",
+			inputCPEMatch:   CPEMatch{Vulnerable: true, CPE23URI: "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", VersionStartIncluding: "1.0", VersionEndExcluding: "1.2"},
+			expectedVersion: AffectedVersion{Introduced: "1.0", Fixed: "1.2"},
+		},
+		{
+			description:     "Only versionEndIncluding",
+			inputCPEMatch:   CPEMatch{Vulnerable: true, CPE23URI: "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", VersionEndIncluding: "1.1"},
+			expectedVersion: AffectedVersion{Fixed: "1.2"},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersion, _, ok := cpeMatchAffectedVersion(tc.inputCPEMatch, []string{"1.0", "1.1", "1.2"}, ExtractOptions{})
+		if !ok {
+			t.Errorf("test %q: cpeMatchAffectedVersion() unexpectedly failed", tc.description)
+			continue
+		}
+		if diff := cmp.Diff(tc.expectedVersion, gotVersion, ignoreNotes); diff != "" {
+			t.Errorf("test %q: cpeMatchAffectedVersion() was incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestDedupeAffectedVersions(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputVersions    []AffectedVersion
+		expectedVersions []AffectedVersion
+	}{
+		{
+			description:      "Exact duplicates",
+			inputVersions:    []AffectedVersion{{Introduced: "1.0", Fixed: "2.0"}, {Introduced: "1.0", Fixed: "2.0", LastAffected: ""}},
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "2.0"}},
+		},
+		{
+			description:      "Introduced only is subsumed by a range",
+			inputVersions:    []AffectedVersion{{Introduced: "1.0"}, {Introduced: "1.0", Fixed: "2.0"}},
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "2.0"}},
+		},
+		{
+			description:      "Fixed only is subsumed by a range from 0",
+			inputVersions:    []AffectedVersion{{Introduced: "0", Fixed: "2.0"}, {Fixed: "2.0"}},
+			expectedVersions: []AffectedVersion{{Introduced: "0", Fixed: "2.0"}},
+		},
+		{
+			description:      "Fixed only is subsumed by a later range from 0",
+			inputVersions:    []AffectedVersion{{Fixed: "2.0"}, {Introduced: "0", Fixed: "2.0"}},
+			expectedVersions: []AffectedVersion{{Introduced: "0", Fixed: "2.0"}},
+		},
+		{
+			description:      "A range from 0 is not subsumed by a narrower range",
+			inputVersions:    []AffectedVersion{{Introduced: "0", Fixed: "2.0"}, {Introduced: "1.0", Fixed: "2.0"}},
+			expectedVersions: []AffectedVersion{{Introduced: "0", Fixed: "2.0"}, {Introduced: "1.0", Fixed: "2.0"}},
+		},
+		{
+			description:      "Ranges of different target software are kept",
+			inputVersions:    []AffectedVersion{{Introduced: "1.0", Fixed: "2.0", TargetSW: "wordpress"}, {Introduced: "1.0"}},
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "2.0", TargetSW: "wordpress"}, {Introduced: "1.0"}},
+		},
+		{
+			description:      "Ranges of different sources are kept",
+			inputVersions:    []AffectedVersion{{Introduced: "1.0", Fixed: "2.0", Source: SourceCPE}, {Introduced: "1.0", Source: SourceDescription}},
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "2.0", Source: SourceCPE}, {Introduced: "1.0", Source: SourceDescription}},
+		},
+		{
+			description:      "Distinct ranges are kept",
+			inputVersions:    []AffectedVersion{{Introduced: "1.0", Fixed: "2.0"}, {Introduced: "1.5", Fixed: "2.0"}, {Introduced: "1.0", LastAffected: "1.9"}},
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "2.0"}, {Introduced: "1.5", Fixed: "2.0"}, {Introduced: "1.0", LastAffected: "1.9"}},
+		},
+		{
+			description:      "Subsumed by several ranges",
+			inputVersions:    []AffectedVersion{{Introduced: "1.0"}, {Introduced: "1.0", Fixed: "2.0"}, {Introduced: "1.0", LastAffected: "1.9"}},
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "2.0"}, {Introduced: "1.0", LastAffected: "1.9"}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersions := dedupeAffectedVersions(tc.inputVersions)
+		if diff := cmp.Diff(tc.expectedVersions, gotVersions); diff != "" {
+			t.Errorf("test %q: dedupeAffectedVersions() was incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestStripReleaseTagPrefix(t *testing.T) {
+	tests := []struct {
+		description     string
+		inputVersion    string
+		expectedVersion string
+	}{
+		{
+			description:     "release- prefix",
+			inputVersion:    "release-1.2.3",
+			expectedVersion: "1.2.3",
+		},
+		{
+			description:     "Upper case release_ prefix",
+			inputVersion:    "RELEASE_1_2_3",
+			expectedVersion: "1.2.3",
+		},
+		{
+			description:     "ver prefix",
+			inputVersion:    "ver1.2.3",
+			expectedVersion: "1.2.3",
+		},
+		{
+			description:     "version prefix",
+			inputVersion:    "version2.0",
+			expectedVersion: "2.0",
+		},
+		{
+			description:     "REL_ prefix with underscore separators",
+			inputVersion:    "REL_1_2_3",
+			expectedVersion: "1.2.3",
+		},
+		{
+			description:     "v prefix",
+			inputVersion:    "v1.2.3-rc1",
+			expectedVersion: "1.2.3-rc1",
+		},
+		{
+			description:     "Prefix not followed by a digit",
+			inputVersion:    "release-candidate-1",
+			expectedVersion: "release-candidate-1",
+		},
+		{
+			description:     "Prefix alone",
+			inputVersion:    "rel",
+			expectedVersion: "rel",
+		},
+		{
+			description:     "No prefix",
+			inputVersion:    "1.2.3",
+			expectedVersion: "1.2.3",
+		},
+	}
+
+	for _, tc := range tests {
+		got := stripReleaseTagPrefix(tc.inputVersion)
+		if got != tc.expectedVersion {
+			t.Errorf("test %q: stripReleaseTagPrefix(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputVersion, got, tc.expectedVersion)
+		}
+	}
+}
+
+func TestSupportedHosts(t *testing.T) {
+	for _, host := range SupportedHosts() {
+		repo, err := Repo(host.Example)
+		if gotRepo := err == nil && repo != ""; gotRepo != host.Repo {
+			t.Errorf("host %q: Repo(%q) support was incorrect, got: %t (%q, %v), expected: %t", host.Host, host.Example, gotRepo, repo, err, host.Repo)
+		}
+		_, err = Commit(host.Example)
+		if gotCommit := err == nil; gotCommit != host.Commit {
+			t.Errorf("host %q: Commit(%q) support was incorrect, got: %t (%v), expected: %t", host.Host, host.Example, gotCommit, err, host.Commit)
+		}
+	}
+}
+
+func TestExtractVersionInfoSources(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputCVEJSON     string
+		expectedVersions []AffectedVersion
+	}{
+		{
+			description: "CVE 5.x affected products",
+			inputCVEJSON: `{"affected": [{"vendor": "foo", "product": "bar", "versions": [
+				{"version": "1.0", "lessThan": "1.4", "status": "affected", "versionType": "semver"}]}]}`,
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.4", Source: SourceCVE5}},
+		},
+		{
+			description: "CPE match",
+			inputCVEJSON: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndExcluding": "1.4"}]}]}}`,
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.4", Source: SourceCPE}},
+		},
+		{
+			description: "Release tag reference",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/foo/bar/releases/tag/v1.4"}]}}}`,
+			expectedVersions: []AffectedVersion{{Fixed: "1.4", Source: SourceReferenceTag}},
+		},
+		{
+			description: "Description",
+			inputCVEJSON: `{"cve": {"description": {"description_data": [
+				{"lang": "en", "value": "A flaw in foo bar before 1.4 allows attackers to do things."}]}}}`,
+			expectedVersions: []AffectedVersion{{Fixed: "1.4", Source: SourceDescription}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfo(cveItemFromJSON(t, tc.inputCVEJSON), []string{"1.0", "1.4"})
+		if diff := cmp.Diff(tc.expectedVersions, gotVersionInfo.AffectedVersions, ignoreNotes); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+		for _, version := range gotVersionInfo.AffectedVersions {
+			if expected := version.Source != SourceReferenceTag && version.Source != SourceDescription; version.Source.HighConfidence() != expected {
+				t.Errorf("test %q: %s.HighConfidence() was incorrect, got: %t, expected: %t", tc.description, version.Source, !expected, expected)
+			}
+		}
+	}
+}
+
+func TestExtractVersionInfoCPEApplicability(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputCVEJSON             string
+		expectedAffectedVersions []AffectedVersion
+	}{
+		{
+			description: "Single node",
+			inputCVEJSON: `{"cpeApplicability": [{"nodes": [{"operator": "OR", "negate": false, "cpeMatch": [
+				{"vulnerable": true, "criteria": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "matchCriteriaId": "0A1B2C3D-0000-0000-0000-000000000000", "versionStartIncluding": "1.0", "versionEndExcluding": "1.4"}]}]}]}`,
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.4"}},
+		},
+		{
+			description: "Application running on a platform",
+			inputCVEJSON: `{"cpeApplicability": [{"operator": "AND", "nodes": [
+				{"operator": "OR", "cpeMatch": [{"vulnerable": true, "criteria": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionEndIncluding": "1.1"}]},
+				{"operator": "OR", "cpeMatch": [{"vulnerable": false, "criteria": "cpe:2.3:o:microsoft:windows:-:*:*:*:*:*:*:*"}]}]}]}`,
+			expectedAffectedVersions: []AffectedVersion{{Fixed: "1.4"}},
+		},
+		{
+			description: "Negated node",
+			inputCVEJSON: `{"cpeApplicability": [{"nodes": [{"operator": "OR", "negate": true, "cpeMatch": [
+				{"vulnerable": true, "criteria": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionEndExcluding": "1.4"}]}]}]}`,
+		},
+		{
+			description: "Legacy configurations take precedence",
+			inputCVEJSON: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.1", "versionEndExcluding": "1.4"}]}]},
+				"cpeApplicability": [{"nodes": [{"operator": "OR", "cpeMatch": [
+				{"vulnerable": true, "criteria": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndExcluding": "1.4"}]}]}]}`,
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "1.1", Fixed: "1.4"}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfo(cveItemFromJSON(t, tc.inputCVEJSON), []string{"1.0", "1.1", "1.4"})
+		if diff := cmp.Diff(gotVersionInfo.AffectedVersions, tc.expectedAffectedVersions, ignoreSource, ignoreNotes); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractDescriptionVersions(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputDescription string
+		expectedVersions []DescriptionVersion
+	}{
+		{
+			description:      "Range",
+			inputDescription: "A flaw in Foo 1.0 through 1.1 allows attackers to do things.",
+			expectedVersions: []DescriptionVersion{
+				{AffectedVersion: AffectedVersion{Introduced: "1.0", Fixed: "1.2"}, Text: "1.0 through 1.1", Start: 14, End: 29},
+			},
+		},
+		{
+			description:      "Offsets account for normalized unicode",
+			inputDescription: "Foo\u00a0\u201cserver\u201d before\u00a01.2 allows attackers to do things.",
+			expectedVersions: []DescriptionVersion{
+				{AffectedVersion: AffectedVersion{Fixed: "1.2"}, Text: "before\u00a01.2", Start: 18, End: 29},
+			},
+		},
+		{
+			description:      "Introduced and fixed clauses in different sentences",
+			inputDescription: "The bug was introduced in 1.0. It was fixed in version 1.2.",
+			expectedVersions: []DescriptionVersion{
+				{AffectedVersion: AffectedVersion{Introduced: "1.0", Fixed: "1.2"}, Text: "introduced in 1.0. It was fixed in version 1.2.", Start: 12, End: 59},
+			},
+		},
+		{
+			description:      "Wildcard release line",
+			inputDescription: "All versions of Foo 1.1.x are affected.",
+			expectedVersions: []DescriptionVersion{
+				{AffectedVersion: AffectedVersion{Introduced: "1.1", Fixed: "1.2"}, Text: "1.1.x", Start: 20, End: 25},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		got, _ := ExtractDescriptionVersions([]string{"1.0", "1.1", "1.2"}, tc.inputDescription)
+		if diff := cmp.Diff(tc.expectedVersions, got, ignoreNotes); diff != "" {
+			t.Errorf("test %q: ExtractDescriptionVersions(%q) was incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+		for _, version := range got {
+			if tc.inputDescription[version.Start:version.End] != version.Text {
+				t.Errorf("test %q: offsets [%d, %d) don't match the text %q", tc.description, version.Start, version.End, version.Text)
+			}
+		}
+	}
+}
+
+func TestExtractVersionInfoTagResolver(t *testing.T) {
+	resolver := func(repo, tag string) (string, error) {
+		if repo == "https://github.com/foo/bar" && tag == "v1.4" {
+			return "4f1b083be43f351bc107541e7b0c9655a5d2c0bb", nil
+		}
+		return "", errors.New("tag not found")
+	}
+	tests := []struct {
+		description        string
+		inputCVEJSON       string
+		expectedFixCommits []GitCommit
+		expectedVersions   []AffectedVersion
+	}{
+		{
+			description: "Resolvable release tag",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/foo/bar/releases/tag/v1.4"}]}}}`,
+			expectedFixCommits: []GitCommit{{Repo: "https://github.com/foo/bar", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"}},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.4", Source: SourceReferenceTag}},
+		},
+		{
+			description: "Release tag already referenced as a commit",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/foo/bar/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+				{"url": "https://github.com/foo/bar/releases/tag/v1.4"}]}}}`,
+			expectedFixCommits: []GitCommit{{Repo: "https://github.com/foo/bar", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"}},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.4", Source: SourceReferenceTag}},
+		},
+		{
+			description: "Unresolvable release tag",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/foo/baz/releases/tag/v1.4"}]}}}`,
+			expectedVersions: []AffectedVersion{{Fixed: "1.4", Source: SourceReferenceTag}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfoWithOptions(cveItemFromJSON(t, tc.inputCVEJSON), []string{"1.0", "1.4"}, ExtractOptions{TagResolver: resolver})
+		if diff := cmp.Diff(tc.expectedFixCommits, gotVersionInfo.FixCommits); diff != "" {
+			t.Errorf("test %q: FixCommits were incorrect: %s", tc.description, diff)
+		}
+		if diff := cmp.Diff(tc.expectedVersions, gotVersionInfo.AffectedVersions, ignoreNotes); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractDescriptionVersionsTruncation(t *testing.T) {
+	defer func(maxLength int) { MaxDescriptionLength = maxLength }(MaxDescriptionLength)
+	MaxDescriptionLength = 64
+	tests := []struct {
+		description      string
+		inputDescription string
+		expectedVersions []AffectedVersion
+		expectedNotes    []string
+	}{
+		{
+			description:      "A description within the limit",
+			inputDescription: "An issue was discovered in Foo before 1.4.5.",
+			expectedVersions: []AffectedVersion{{Fixed: "1.4.5"}},
+		},
+		{
+			description:      "A version past the limit",
+			inputDescription: strings.Repeat("Lorem ipsum ", 6) + "An issue was discovered in Foo before 1.4.5.",
+			expectedNotes:    []string{"Description of 116 bytes truncated to 64 bytes", "Failed to parse versions from description"},
+		},
+		{
+			description:      "A version before the limit",
+			inputDescription: "Foo before 1.4.5 is affected. " + strings.Repeat("Lorem ipsum ", 6),
+			expectedVersions: []AffectedVersion{{Fixed: "1.4.5"}},
+			expectedNotes:    []string{"Description of 102 bytes truncated to 64 bytes"},
+		},
+		{
+			description:      "A multi-byte character at the limit",
+			inputDescription: "Foo before 1.4.5 is affected. " + strings.Repeat("\u00e9", 40),
+			expectedVersions: []AffectedVersion{{Fixed: "1.4.5"}},
+			expectedNotes:    []string{"Description of 110 bytes truncated to 64 bytes"},
+		},
+	}
+
+	for _, tc := range tests {
+		got, gotNotes := ExtractDescriptionVersions([]string{"1.4.5"}, tc.inputDescription)
+		var gotVersions []AffectedVersion
+		for _, version := range got {
+			gotVersions = append(gotVersions, version.AffectedVersion)
+		}
+		if diff := cmp.Diff(tc.expectedVersions, gotVersions); diff != "" {
+			t.Errorf("test %q: ExtractDescriptionVersions for %q was incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+		if diff := cmp.Diff(tc.expectedNotes, gotNotes); diff != "" {
+			t.Errorf("test %q: ExtractDescriptionVersions notes for %q were incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+	}
+}
+
+func BenchmarkExtractDescriptionVersions(b *testing.B) {
+	// A pathologically long description: a single very long run of version-like tokens without a break.
+	description := "Foo before 1.4.5 is affected. " + strings.Repeat("1.2.3-", 1<<16)
+	for _, maxLength := range []int{MaxDescriptionLength, 0} {
+		b.Run(fmt.Sprintf("max=%d", maxLength), func(b *testing.B) {
+			defer func(maxLength int) { MaxDescriptionLength = maxLength }(MaxDescriptionLength)
+			MaxDescriptionLength = maxLength
+			for i := 0; i < b.N; i++ {
+				ExtractDescriptionVersions(nil, description)
+			}
+		})
+	}
+}
+
+func TestExtractVersionInfoTargetSW(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputCPE         string
+		expectedTargetSW string
+	}{
+		{
+			description:      "WordPress plugin",
+			inputCPE:         "cpe:2.3:a:foo:bar:*:*:*:*:*:wordpress:*:*",
+			expectedTargetSW: "wordpress",
+		},
+		{
+			description:      "Node.js package",
+			inputCPE:         "cpe:2.3:a:foo:bar:*:*:*:*:*:node.js:*:*",
+			expectedTargetSW: "node.js",
+		},
+		{
+			description:      "Mixed case target software",
+			inputCPE:         "cpe:2.3:a:foo:bar:*:*:*:*:*:Jenkins:*:*",
+			expectedTargetSW: "jenkins",
+		},
+		{
+			description:      "ANY target software",
+			inputCPE:         "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*",
+			expectedTargetSW: "",
+		},
+		{
+			description:      "NA target software",
+			inputCPE:         "cpe:2.3:a:foo:bar:*:*:*:*:*:-:*:*",
+			expectedTargetSW: "",
+		},
+	}
+
+	for _, tc := range tests {
+		inputCVEJSON := fmt.Sprintf(`{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+			{"vulnerable": true, "cpe23Uri": %q, "versionStartIncluding": "1.0", "versionEndExcluding": "1.4"}]}]}}`, tc.inputCPE)
+		gotVersionInfo, _ := ExtractVersionInfo(cveItemFromJSON(t, inputCVEJSON), []string{"1.0", "1.4"})
+		expectedVersions := []AffectedVersion{{Introduced: "1.0", Fixed: "1.4", Source: SourceCPE, TargetSW: tc.expectedTargetSW}}
+		if diff := cmp.Diff(expectedVersions, gotVersionInfo.AffectedVersions); diff != "" {
+			t.Errorf("test %q: AffectedVersions for %q were incorrect: %s", tc.description, tc.inputCPE, diff)
+		}
+	}
+}
+
+func TestGitLabMergeRequest(t *testing.T) {
+	tests := []struct {
+		description          string
+		inputURL             string
+		expectedRepo         string
+		expectedMergeRequest int
+		expectedOk           bool
+	}{
+		{
+			description:          "Merge request",
+			inputURL:             "https://gitlab.com/libtiff/libtiff/-/merge_requests/378",
+			expectedRepo:         "https://gitlab.com/libtiff/libtiff",
+			expectedMergeRequest: 378,
+			expectedOk:           true,
+		},
+		{
+			description:          "Merge request diffs in a subgroup",
+			inputURL:             "https://gitlab.com/gitlab-org/security/gitaly/-/merge_requests/12/diffs",
+			expectedRepo:         "https://gitlab.com/gitlab-org/security/gitaly",
+			expectedMergeRequest: 12,
+			expectedOk:           true,
+		},
+		{
+			description: "Merge request list",
+			inputURL:    "https://gitlab.com/libtiff/libtiff/-/merge_requests",
+			expectedOk:  false,
+		},
+		{
+			description: "Non-numeric merge request",
+			inputURL:    "https://gitlab.com/libtiff/libtiff/-/merge_requests/new",
+			expectedOk:  false,
+		},
+		{
+			description: "GitHub pull request",
+			inputURL:    "https://github.com/google/osv.dev/pull/738",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		gotRepo, gotMergeRequest, gotOk := GitLabMergeRequest(tc.inputURL)
+		if gotRepo != tc.expectedRepo || gotMergeRequest != tc.expectedMergeRequest || gotOk != tc.expectedOk {
+			t.Errorf("test %q: GitLabMergeRequest(%q) was incorrect, got: %q, %d, %t, expected: %q, %d, %t",
+				tc.description, tc.inputURL, gotRepo, gotMergeRequest, gotOk, tc.expectedRepo, tc.expectedMergeRequest, tc.expectedOk)
+		}
+	}
+}
+
+func TestExtractVersionInfoMergeRequestResolver(t *testing.T) {
+	resolver := func(repo string, mergeRequest int) (string, error) {
+		if repo == "https://gitlab.com/libtiff/libtiff" && mergeRequest == 378 {
+			return "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e", nil
+		}
+		return "", errors.New("merge request is opened, not merged")
+	}
+	tests := []struct {
+		description        string
+		inputCVEJSON       string
+		expectedFixCommits []GitCommit
+	}{
+		{
+			description: "Merged merge request",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://gitlab.com/libtiff/libtiff/-/merge_requests/378"}]}}}`,
+			expectedFixCommits: []GitCommit{{Repo: "https://gitlab.com/libtiff/libtiff", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"}},
+		},
+		{
+			description: "Merge request also referenced as a commit",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://gitlab.com/libtiff/libtiff/-/commit/9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"},
+				{"url": "https://gitlab.com/libtiff/libtiff/-/merge_requests/378"}]}}}`,
+			expectedFixCommits: []GitCommit{{Repo: "https://gitlab.com/libtiff/libtiff", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"}},
+		},
+		{
+			description: "Unmerged merge request",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://gitlab.com/libtiff/libtiff/-/merge_requests/380"}]}}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfoWithOptions(cveItemFromJSON(t, tc.inputCVEJSON), nil, ExtractOptions{MergeRequestResolver: resolver})
+		if diff := cmp.Diff(tc.expectedFixCommits, gotVersionInfo.FixCommits); diff != "" {
+			t.Errorf("test %q: FixCommits were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+// compareDottedVersions compares versions of dot separated numbers, for tests.
+func compareDottedVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart int
+		if i < len(aParts) {
+			aPart, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bPart, _ = strconv.Atoi(bParts[i])
+		}
+		if aPart != bPart {
+			return aPart - bPart
+		}
+	}
+	return 0
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		description        string
+		inputValidVersions []string
+		inputVersion       string
+		inputCompare       VersionComparator
+		expectedVersion    string
+		expectedOk         bool
+	}{
+		{
+			description:        "Sorted versions without a comparator",
+			inputValidVersions: []string{"1.0", "1.1", "1.2"},
+			inputVersion:       "1.1",
+			expectedVersion:    "1.2",
+			expectedOk:         true,
+		},
+		{
+			description:        "Unsorted versions without a comparator",
+			inputValidVersions: []string{"1.2", "1.10", "1.1", "1.9"},
+			inputVersion:       "1.2",
+			expectedVersion:    "1.10",
+			expectedOk:         true,
+		},
+		{
+			description:        "Unsorted versions with a comparator",
+			inputValidVersions: []string{"1.2", "1.10", "1.1", "1.9"},
+			inputVersion:       "1.2",
+			inputCompare:       compareDottedVersions,
+			expectedVersion:    "1.9",
+			expectedOk:         true,
+		},
+		{
+			description:        "Greatest version with a comparator",
+			inputValidVersions: []string{"1.2", "1.10", "1.1", "1.9"},
+			inputVersion:       "1.10",
+			inputCompare:       compareDottedVersions,
+			expectedOk:         false,
+		},
+		{
+			description:        "Invalid version with a comparator",
+			inputValidVersions: []string{"1.2", "1.10", "1.1", "1.9"},
+			inputVersion:       "1.3",
+			inputCompare:       compareDottedVersions,
+			expectedOk:         false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := nextVersion(tc.inputValidVersions, tc.inputVersion, tc.inputCompare)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: nextVersion(%q, %q) returned unexpected error: %v", tc.description, tc.inputValidVersions, tc.inputVersion, err)
+		}
+		if got != tc.expectedVersion {
+			t.Errorf("test %q: nextVersion(%q, %q) was incorrect, got: %q, expected: %q", tc.description, tc.inputValidVersions, tc.inputVersion, got, tc.expectedVersion)
+		}
+	}
+}
+
+func TestExtractVersionInfoVersionComparator(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.1", "versionEndIncluding": "1.2"}]}]}}`)
+	validVersions := []string{"1.2", "1.10", "1.1", "1.9"}
+	gotVersionInfo, _ := ExtractVersionInfoWithOptions(cve, validVersions, ExtractOptions{VersionComparator: compareDottedVersions})
+	expectedVersions := []AffectedVersion{{Introduced: "1.1", Fixed: "1.9", Source: SourceCPE}}
+	if diff := cmp.Diff(expectedVersions, gotVersionInfo.AffectedVersions, ignoreNotes); diff != "" {
+		t.Errorf("AffectedVersions were incorrect: %s", diff)
+	}
+}
+
+func TestExtractDescriptionVersionsSingleAffectedVersion(t *testing.T) {
+	description := "A flaw was found in mod_proxy. This affects Apache HTTP Server 2.4.52."
+	got, gotNotes := ExtractDescriptionVersions([]string{"2.4.51", "2.4.53"}, description)
+	expected := []DescriptionVersion{{
+		AffectedVersion: AffectedVersion{Introduced: "2.4.52", LastAffected: "2.4.52"},
+		Text:            "affects Apache HTTP Server 2.4.52",
+		Start:           36,
+		End:             69,
+	}}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("ExtractDescriptionVersions for %q was incorrect: %s", description, diff)
+	}
+	expectedNotes := []string{
+		"Extracted version 2.4.52 is not a valid version",
+		"Only version 2.4.52 of Apache HTTP Server is stated to be affected, other versions may be too",
+	}
+	if diff := cmp.Diff(expectedNotes, gotNotes); diff != "" {
+		t.Errorf("ExtractDescriptionVersions notes for %q were incorrect: %s", description, diff)
+	}
+}
+
+func TestConfiguredHosts(t *testing.T) {
+	defer func(githubHosts, gitlabHosts []string) {
+		GitHubHosts, GitLabHosts = githubHosts, gitlabHosts
+	}(GitHubHosts, GitLabHosts)
+	GitHubHosts = []string{"github.acme.com"}
+	GitLabHosts = append(slices.Clone(GitLabHosts), "code.example.com", "*.git.example.org")
+
+	tests := []struct {
+		description    string
+		inputLink      string
+		expectedRepo   string
+		expectedCommit string
+	}{
+		{
+			description:    "GitHub Enterprise commit",
+			inputLink:      "https://github.acme.com/platform/api/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8",
+			expectedRepo:   "https://github.acme.com/platform/api",
+			expectedCommit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8",
+		},
+		{
+			description:  "GitHub Enterprise repository",
+			inputLink:    "https://github.acme.com/platform/api",
+			expectedRepo: "https://github.acme.com/platform/api",
+		},
+		{
+			description:    "Self-managed GitLab commit in a subgroup",
+			inputLink:      "https://code.example.com/platform/backend/api/-/commit/4367a20cc4",
+			expectedRepo:   "https://code.example.com/platform/backend/api",
+			expectedCommit: "4367a20cc4",
+		},
+		{
+			description:    "Self-managed GitLab commit under a subdomain pattern",
+			inputLink:      "https://eu.git.example.org/platform/api/-/commit/4367a20cc4",
+			expectedRepo:   "https://eu.git.example.org/platform/api",
+			expectedCommit: "4367a20cc4",
+		},
+		{
+			description:    "Default gitlab.* pattern",
+			inputLink:      "https://gitlab.freedesktop.org/virgl/virglrenderer/-/commit/b05bb61f454eeb8a85164c8a31510aeb9d79129c",
+			expectedRepo:   "https://gitlab.freedesktop.org/virgl/virglrenderer",
+			expectedCommit: "b05bb61f454eeb8a85164c8a31510aeb9d79129c",
+		},
+	}
+
+	for _, tc := range tests {
+		if got, _ := Repo(tc.inputLink); got != tc.expectedRepo {
+			t.Errorf("test %q: Repo(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputLink, got, tc.expectedRepo)
+		}
+		if got, _ := Commit(tc.inputLink); got != tc.expectedCommit {
+			t.Errorf("test %q: Commit(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputLink, got, tc.expectedCommit)
+		}
+	}
+
+	GitHubHosts, GitLabHosts = nil, nil
+	if IsGitHubHost("github.acme.com") || IsGitLabHost("code.example.com") {
+		t.Errorf("hosts were unexpectedly supported without configuration")
+	}
+}
+
+func TestExtractVersionInfoReferenceOutcomes(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [
+		{"url": "https://github.com/foo/bar/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+		{"url": "https://github.com/foo/bar/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5"},
+		{"url": "https://github.com/foo/bar/issues/1"},
+		{"url": "https://github.com/Accenture/AARO-Bugs/blob/master/CVE-2022-1234.md"},
+		{"url": "https://gist.github.com/someone/0123456789abcdef0123456789abcdef"},
+		{"url": "https://www.example.com/advisory.html"}]}}}`)
+	verifier := func(gc GitCommit) (bool, error) {
+		return gc.Commit == "4f1b083be43f351bc107541e7b0c9655a5d2c0bb", nil
+	}
+	var diag ExtractDiagnostics
+	ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{CommitVerifier: verifier, Diagnostics: &diag})
+
+	expectedOutcomes := []ReferenceOutcomeKind{CommitReference, UnverifiedReference, RepositoryReference, DenylistedReference, DenylistedReference, UnsupportedReference}
+	var gotOutcomes []ReferenceOutcomeKind
+	for i, reference := range diag.References {
+		gotOutcomes = append(gotOutcomes, reference.Outcome)
+		if reference.URL != cve.CVE.References.ReferenceData[i].URL {
+			t.Errorf("ReferenceOutcome %d was for %q, expected: %q", i, reference.URL, cve.CVE.References.ReferenceData[i].URL)
+		}
+		if used := reference.Outcome == CommitReference || reference.Outcome == RepositoryReference; used != (reference.Reason == "") {
+			t.Errorf("ReferenceOutcome %d (%s) had unexpected reason %q", i, reference.Outcome, reference.Reason)
+		}
+	}
+	if diff := cmp.Diff(expectedOutcomes, gotOutcomes); diff != "" {
+		t.Errorf("ReferenceOutcomes were incorrect: %s", diff)
+	}
+	if !strings.HasSuffix(diag.String(), "considered 6 references (1 commits, 1 unverified commits, 1 repositories, 2 denylisted, 1 unsupported)") {
+		t.Errorf("ExtractDiagnostics.String() was incorrect, got: %q", diag.String())
+	}
+}
+
+func TestExtractVersionInfoEditions(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputCPEs                []string
+		expectedAffectedVersions []AffectedVersion
+		expectedNote             string
+	}{
+		{
+			description: "CPEs varying only by edition",
+			inputCPEs: []string{
+				"cpe:2.3:a:foo:bar:*:*:*:*:enterprise:*:*:*",
+				"cpe:2.3:a:foo:bar:*:*:*:*:community:*:*:*",
+			},
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "1.0", Fixed: "1.4", Edition: "enterprise"},
+				{Introduced: "1.0", Fixed: "1.4", Edition: "community"},
+			},
+			expectedNote: "cpe:2.3:a:foo:bar:*:*:*:*:community:*:*:* only applies to edition community",
+		},
+		{
+			description: "CPEs varying only by legacy edition and other",
+			inputCPEs: []string{
+				"cpe:2.3:a:foo:bar:*:*:LTS:*:*:*:*:*",
+				"cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:build\\-5",
+			},
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "1.0", Fixed: "1.4", Edition: "lts"},
+				{Introduced: "1.0", Fixed: "1.4", Edition: "build-5"},
+			},
+			expectedNote: "cpe:2.3:a:foo:bar:*:*:LTS:*:*:*:*:* only applies to edition lts",
+		},
+		{
+			description: "CPEs without an edition",
+			inputCPEs: []string{
+				"cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*",
+				"cpe:2.3:a:foo:bar:*:*:-:*:-:*:*:-",
+			},
+			expectedAffectedVersions: []AffectedVersion{
+				{Introduced: "1.0", Fixed: "1.4"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		var matches []string
+		for _, cpe := range tc.inputCPEs {
+			matches = append(matches, fmt.Sprintf(`{"vulnerable": true, "cpe23Uri": %q, "versionStartIncluding": "1.0", "versionEndExcluding": "1.4"}`, cpe))
+		}
+		cve := cveItemFromJSON(t, `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [`+strings.Join(matches, ",")+`]}]}}`)
+		gotVersionInfo, gotNotes := ExtractVersionInfo(cve, []string{"1.0", "1.4"})
+		if diff := cmp.Diff(tc.expectedAffectedVersions, gotVersionInfo.AffectedVersions, ignoreSource); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+		if tc.expectedNote != "" && !slices.Contains(gotNotes, tc.expectedNote) {
+			t.Errorf("test %q: notes %q did not contain %q", tc.description, gotNotes, tc.expectedNote)
+		}
+	}
+}
+
+func TestRepoNotRepo(t *testing.T) {
+	tests := []struct {
+		description     string
+		inputLink       string
+		expectedNotRepo bool
+	}{
+		{
+			description:     "Email address",
+			inputLink:       "mailto:security@example.com",
+			expectedNotRepo: true,
+		},
+		{
+			description:     "PDF advisory",
+			inputLink:       "https://www.example.com/advisories/2022-01.PDF",
+			expectedNotRepo: true,
+		},
+		{
+			description:     "Mailing list archive",
+			inputLink:       "https://www.openwall.com/lists/oss-security/2022/01/18/1",
+			expectedNotRepo: true,
+		},
+		{
+			description:     "Subdomain of an advisory portal",
+			inputLink:       "https://web.nvd.nist.gov/view/vuln/detail?vulnId=CVE-2022-0090",
+			expectedNotRepo: true,
+		},
+		{
+			description:     "PDF committed to a repository",
+			inputLink:       "https://github.com/foo/bar/blob/main/docs/advisory.pdf",
+			expectedNotRepo: false,
+		},
+		{
+			description:     "Unsupported URL",
+			inputLink:       "https://www.example.com/advisory.html",
+			expectedNotRepo: false,
+		},
+		{
+			description:     "Host ending in an advisory portal",
+			inputLink:       "https://notopenwall.com/foo/bar.git",
+			expectedNotRepo: false,
+		},
+	}
+
+	for _, tc := range tests {
+		_, err := Repo(tc.inputLink)
+		if got := errors.Is(err, ErrNotRepo); got != tc.expectedNotRepo {
+			t.Errorf("test %q: Repo(%q) returned %v, expected ErrNotRepo: %t", tc.description, tc.inputLink, err, tc.expectedNotRepo)
+		}
+	}
+}
+
+func TestExtractVersionInfoVulnStatus(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputVulnStatus          string
+		expectedAffectedVersions []AffectedVersion
+		expectedNote             string
+	}{
+		{
+			description:              "Legacy feed without a status",
+			inputVulnStatus:          "",
+			expectedAffectedVersions: []AffectedVersion{{Fixed: "1.2.3"}},
+		},
+		{
+			description:              "Analyzed",
+			inputVulnStatus:          "Analyzed",
+			expectedAffectedVersions: []AffectedVersion{{Fixed: "1.2.3"}},
+		},
+		{
+			description:     "Awaiting analysis",
+			inputVulnStatus: "Awaiting Analysis",
+			expectedNote:    `Not extracting versions from the description of a CVE with status "Awaiting Analysis"`,
+		},
+		{
+			description:     "Rejected",
+			inputVulnStatus: "Rejected",
+			expectedNote:    `Not extracting versions from the description of a CVE with status "Rejected"`,
+		},
+	}
+
+	for _, tc := range tests {
+		cve := cveItemFromJSON(t, `{"cve": {"description": {"description_data": [
+			{"lang": "en", "value": "A flaw in foo before 1.2.3 allows attackers to do bad things."}
+		]}}}`)
+		cve.VulnStatus = tc.inputVulnStatus
+		var diag ExtractDiagnostics
+		gotVersionInfo, gotNotes := ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{Diagnostics: &diag})
+		if diff := cmp.Diff(tc.expectedAffectedVersions, gotVersionInfo.AffectedVersions, ignoreSource); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+		if tc.expectedNote != "" {
+			if !slices.Contains(gotNotes, tc.expectedNote) {
+				t.Errorf("test %q: notes %#v did not contain %q", tc.description, gotNotes, tc.expectedNote)
+			}
+			if diag.DescriptionFallbackRan {
+				t.Errorf("test %q: description fallback unexpectedly ran", tc.description)
+			}
+		}
+	}
+}
+
+func TestExtractDescriptionVersionsSince(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputDescription         string
+		inputValidVersions       []string
+		expectedAffectedVersions []AffectedVersion
+	}{
+		{
+			description:              "Since version",
+			inputDescription:         "A flaw exists since version 1.2.0 that allows attackers to read files.",
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "1.2.0"}},
+		},
+		{
+			description:              "Starting with",
+			inputDescription:         "Starting with 2.0.1, the parser mishandles nested arrays.",
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "2.0.1"}},
+		},
+		{
+			description:              "As of",
+			inputDescription:         "As of v3.4.0, tokens are logged in plain text.",
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "v3.4.0"}},
+		},
+		{
+			description:              "Since, fixed in another sentence",
+			inputDescription:         "The bug exists since version 1.2.0. It is fixed in 1.4.0.",
+			inputValidVersions:       []string{"1.1.0", "1.2.0", "1.3.0", "1.4.0"},
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "1.2.0", Fixed: "1.4.0"}},
+		},
+		{
+			description:              "Since a year",
+			inputDescription:         "The project has been vulnerable since 2019.",
+			expectedAffectedVersions: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		got, _ := ExtractDescriptionVersions(tc.inputValidVersions, tc.inputDescription)
+		var gotAffectedVersions []AffectedVersion
+		for _, version := range got {
+			gotAffectedVersions = append(gotAffectedVersions, version.AffectedVersion)
+		}
+		if diff := cmp.Diff(tc.expectedAffectedVersions, gotAffectedVersions); diff != "" {
+			t.Errorf("test %q: ExtractDescriptionVersions(%q) was incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+	}
+}
+
+func TestNormalizeCPE(t *testing.T) {
+	tests := []struct {
+		description        string
+		inputCPEString     string
+		expectedNormalized string
+	}{
+		{
+			description:        "Unquoted",
+			inputCPEString:     "cpe:2.3:a:foo:bar:1.2:*:*:*:*:*:*:*",
+			expectedNormalized: "cpe:2.3:a:foo:bar:1.2:*:*:*:*:*:*:*",
+		},
+		{
+			description:        "Unnecessarily quoted period",
+			inputCPEString:     `cpe:2.3:a:foo:bar:1\.2:*:*:*:*:*:*:*`,
+			expectedNormalized: "cpe:2.3:a:foo:bar:1.2:*:*:*:*:*:*:*",
+		},
+		{
+			description:        "Necessarily quoted colon",
+			inputCPEString:     `cpe:2.3:a:foo:bar\:baz:1.2:*:*:*:*:*:*:*`,
+			expectedNormalized: `cpe:2.3:a:foo:bar\:baz:1.2:*:*:*:*:*:*:*`,
+		},
+		{
+			description:        "Mixed case",
+			inputCPEString:     "cpe:2.3:a:Foo:Bar:1.2:*:*:*:*:*:*:*",
+			expectedNormalized: "cpe:2.3:a:foo:bar:1.2:*:*:*:*:*:*:*",
+		},
+	}
+
+	for _, tc := range tests {
+		if got := NormalizeCPE(tc.inputCPEString); got != tc.expectedNormalized {
+			t.Errorf("test %q: NormalizeCPE(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputCPEString, got, tc.expectedNormalized)
+		}
+	}
+}
+
+func TestCPEsDeduplicated(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:1.2:*:*:*:*:*:*:*"},
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:1\\.2:*:*:*:*:*:*:*"},
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:1.3:*:*:*:*:*:*:*"}
+	]}]}}`)
+	expected := []string{"cpe:2.3:a:foo:bar:1.2:*:*:*:*:*:*:*", "cpe:2.3:a:foo:bar:1.3:*:*:*:*:*:*:*"}
+	if diff := cmp.Diff(expected, CPEs(cve)); diff != "" {
+		t.Errorf("CPEs() was incorrect: %s", diff)
+	}
+}
+
+func TestExtractDescriptionVersionsWeaknessIdentifiers(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputDescription         string
+		expectedAffectedVersions []AffectedVersion
+	}{
+		{
+			description:              "CVE identifier before a range",
+			inputDescription:         "An incomplete fix for CVE-2021-44228 before 2.16.0 allows attackers to craft malicious input.",
+			expectedAffectedVersions: []AffectedVersion{{Fixed: "2.16.0"}},
+		},
+		{
+			description:              "CWE identifier before a range",
+			inputDescription:         "Foo is affected by CWE-79 before 1.4.2.",
+			expectedAffectedVersions: []AffectedVersion{{Fixed: "1.4.2"}},
+		},
+		{
+			description:              "CVE identifier as the upper bound",
+			inputDescription:         "Foo was vulnerable before CVE-2022-1234 was reported.",
+			expectedAffectedVersions: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		got, _ := ExtractDescriptionVersions(nil, tc.inputDescription)
+		var gotAffectedVersions []AffectedVersion
+		for _, version := range got {
+			gotAffectedVersions = append(gotAffectedVersions, version.AffectedVersion)
+		}
+		if diff := cmp.Diff(tc.expectedAffectedVersions, gotAffectedVersions); diff != "" {
+			t.Errorf("test %q: ExtractDescriptionVersions(%q) was incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+	}
+}
+
+func TestRepoVCS(t *testing.T) {
+	tests := []struct {
+		description string
+		inputRepo   string
+		expectedVCS VCS
+	}{
+		{
+			description: "GitHub repository",
+			inputRepo:   "https://github.com/google/osv.dev",
+			expectedVCS: VCSGit,
+		},
+		{
+			description: "GitHub repository named svn",
+			inputRepo:   "https://github.com/apache/svn",
+			expectedVCS: VCSGit,
+		},
+		{
+			description: "Mercurial host",
+			inputRepo:   "https://hg.mozilla.org/mozilla-central",
+			expectedVCS: VCSMercurial,
+		},
+		{
+			description: "Mercurial path",
+			inputRepo:   "https://www.example.com/hg/project",
+			expectedVCS: VCSMercurial,
+		},
+		{
+			description: "Subversion host",
+			inputRepo:   "https://svn.apache.org/repos/asf/httpd",
+			expectedVCS: VCSSubversion,
+		},
+		{
+			description: "Subversion scheme",
+			inputRepo:   "svn://svn.code.sf.net/p/foo/code",
+			expectedVCS: VCSSubversion,
+		},
+		{
+			description: "SourceForge Subversion repository",
+			inputRepo:   "https://sourceforge.net/p/foo/svn",
+			expectedVCS: VCSSubversion,
+		},
+		{
+			description: "WebSVN repository",
+			inputRepo:   "https://websvn.kde.org/listing.php?repname=kde",
+			expectedVCS: VCSSubversion,
+		},
+		{
+			description: "ViewVC repository",
+			inputRepo:   "https://svn.example.org/viewvc/project",
+			expectedVCS: VCSSubversion,
+		},
+		{
+			description: "cGit repository",
+			inputRepo:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+			expectedVCS: VCSGit,
+		},
+	}
+
+	for _, tc := range tests {
+		if got := repoVCS(tc.inputRepo); got != tc.expectedVCS {
+			t.Errorf("test %q: repoVCS(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputRepo, got, tc.expectedVCS)
+		}
+	}
+}
+
+func TestRepoInfoFor(t *testing.T) {
+	got, err := RepoInfoFor("https://github.com/google/osv.dev/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5")
+	if err != nil {
+		t.Fatalf("RepoInfoFor() unexpectedly failed: %v", err)
+	}
+	if expected := (RepoInfo{URL: "https://github.com/google/osv.dev", VCS: VCSGit}); got != expected {
+		t.Errorf("RepoInfoFor() was incorrect, got: %+v, expected: %+v", got, expected)
+	}
+	if _, err := RepoInfoFor("https://example.com/advisory"); err == nil {
+		t.Errorf("RepoInfoFor() unexpectedly succeeded for an unsupported URL")
+	}
+}
+
+func TestExtractDescriptionVersionsBuilds(t *testing.T) {
+	tests := []struct {
+		description              string
+		inputDescription         string
+		inputValidVersions       []string
+		expectedAffectedVersions []AffectedVersion
+	}{
+		{
+			description:              "Builds through, no valid versions",
+			inputDescription:         "Builds 1000 through 1050 are affected.",
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "1000", LastAffected: "1050"}},
+		},
+		{
+			description:              "Build through build, numeric valid versions",
+			inputDescription:         "The issue affects build 1000 through build 1050 of the agent.",
+			inputValidVersions:       []string{"1000", "1050", "1100", "999"},
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "1000", Fixed: "1100"}},
+		},
+		{
+			description:              "Builds to, last valid version",
+			inputDescription:         "Builds 990 to 1050 are vulnerable.",
+			inputValidVersions:       []string{"990", "1000", "1050"},
+			expectedAffectedVersions: []AffectedVersion{{Introduced: "990", LastAffected: "1050"}},
+		},
+	}
+
+	for _, tc := range tests {
+		got, _ := ExtractDescriptionVersions(tc.inputValidVersions, tc.inputDescription)
+		var gotAffectedVersions []AffectedVersion
+		for _, version := range got {
+			gotAffectedVersions = append(gotAffectedVersions, version.AffectedVersion)
+		}
+		if diff := cmp.Diff(tc.expectedAffectedVersions, gotAffectedVersions, ignoreNotes); diff != "" {
+			t.Errorf("test %q: ExtractDescriptionVersions(%q) was incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+	}
+}
+
+func TestExpandRepoShorthand(t *testing.T) {
+	tests := []struct {
+		description     string
+		inputShorthand  string
+		inputHost       string
+		expectedRepoURL string
+		expectedOk      bool
+	}{
+		{
+			description:     "Default host",
+			inputShorthand:  "google/osv.dev",
+			expectedRepoURL: "https://github.com/google/osv.dev",
+			expectedOk:      true,
+		},
+		{
+			description:     "Explicit host",
+			inputShorthand:  "libtiff/libtiff.git",
+			inputHost:       "gitlab.com",
+			expectedRepoURL: "https://gitlab.com/libtiff/libtiff",
+			expectedOk:      true,
+		},
+		{
+			description:    "Too few segments",
+			inputShorthand: "osv.dev",
+		},
+		{
+			description:    "Too many segments",
+			inputShorthand: "group/subgroup/project",
+		},
+		{
+			description:    "Empty segment",
+			inputShorthand: "google/",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := ExpandRepoShorthand(tc.inputShorthand, tc.inputHost)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: ExpandRepoShorthand(%q, %q) error was incorrect, got: %v, expected ok: %t", tc.description, tc.inputShorthand, tc.inputHost, err, tc.expectedOk)
+		}
+		if got != tc.expectedRepoURL {
+			t.Errorf("test %q: ExpandRepoShorthand(%q, %q) was incorrect, got: %q, expected: %q", tc.description, tc.inputShorthand, tc.inputHost, got, tc.expectedRepoURL)
+		}
+	}
+}
+
+func TestCPEDictionaryKey(t *testing.T) {
+	tests := []struct {
+		description    string
+		inputCPEString string
+		expectedKey    string
+	}{
+		{
+			description:    "Versioned application",
+			inputCPEString: "cpe:2.3:a:apache:http_server:2.4.52:*:*:*:*:*:*:*",
+			expectedKey:    "a:apache:http_server",
+		},
+		{
+			description:    "Any version, with an update and target",
+			inputCPEString: "cpe:2.3:a:Apache:HTTP_Server:*:beta1:*:*:*:windows:*:*",
+			expectedKey:    "a:apache:http_server",
+		},
+		{
+			description:    "Quoted product",
+			inputCPEString: `cpe:2.3:a:foo:c\+\+_lib:1.0:*:*:*:*:*:*:*`,
+			expectedKey:    "a:foo:c++_lib",
+		},
+		{
+			description:    "Operating system",
+			inputCPEString: "cpe:2.3:o:linux:linux_kernel:5.10:*:*:*:*:*:*:*",
+			expectedKey:    "o:linux:linux_kernel",
+		},
+	}
+
+	for _, tc := range tests {
+		cpe, err := ParseCPE(tc.inputCPEString)
+		if err != nil {
+			t.Fatalf("test %q: ParseCPE(%q) unexpectedly failed: %v", tc.description, tc.inputCPEString, err)
+		}
+		if got := cpe.DictionaryKey(); got != tc.expectedKey {
+			t.Errorf("test %q: DictionaryKey() for %q was incorrect, got: %q, expected: %q", tc.description, tc.inputCPEString, got, tc.expectedKey)
+		}
+	}
+}
+
+func TestExtractDescriptionVersionsParallelBranches(t *testing.T) {
+	validVersions := []string{"1.0.0", "1.4.1", "1.4.2", "2.0.0", "2.1.2", "2.1.3"}
+	tests := []struct {
+		description      string
+		inputDescription string
+		expectedVersions []DescriptionVersion
+	}{
+		{
+			description:      "Wildcard branches",
+			inputDescription: "Foo affects 1.x before 1.4.2 and 2.x before 2.1.3.",
+			expectedVersions: []DescriptionVersion{
+				{AffectedVersion: AffectedVersion{Introduced: "1.0.0", Fixed: "1.4.2"}, Text: "1.x before 1.4.2", Start: 12, End: 28, Branch: "1.x"},
+				{AffectedVersion: AffectedVersion{Introduced: "2.0.0", Fixed: "2.1.3"}, Text: "2.x before 2.1.3.", Start: 33, End: 50, Branch: "2.x"},
+			},
+		},
+		{
+			description:      "Wildcard branches followed by versions",
+			inputDescription: "This issue affects Foo 1.x versions before 1.4.2 and 2.x versions before 2.1.3.",
+			expectedVersions: []DescriptionVersion{
+				{AffectedVersion: AffectedVersion{Introduced: "1.0.0", Fixed: "1.4.2"}, Text: "1.x versions before 1.4.2", Start: 23, End: 48, Branch: "1.x"},
+				{AffectedVersion: AffectedVersion{Introduced: "2.0.0", Fixed: "2.1.3"}, Text: "2.x versions before 2.1.3.", Start: 53, End: 79, Branch: "2.x"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		got, _ := ExtractDescriptionVersions(validVersions, tc.inputDescription)
+		if diff := cmp.Diff(tc.expectedVersions, got, ignoreNotes); diff != "" {
+			t.Errorf("test %q: ExtractDescriptionVersions(%q) was incorrect: %s", tc.description, tc.inputDescription, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoStrictVersions(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"cve": {"CVE_data_meta": {"ID": "CVE-2023-1234"}}, "configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndExcluding": "1.2.3"}]}]}}`)
+	endIncluding := cveItemFromJSON(t, `{"cve": {"CVE_data_meta": {"ID": "CVE-2023-1234"}}, "configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+		{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:foo:bar:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndIncluding": "1.2.3"}]}]}}`)
+	description := cveItemFromJSON(t, `{"cve": {"CVE_data_meta": {"ID": "CVE-2023-1234"}, "description": {"description_data": [
+		{"lang": "en", "value": "A flaw in Foo before 1.2.3 allows remote attackers to do bad things."}]}}}`)
+	tests := []struct {
+		description        string
+		inputCVEItem       CVEItem
+		inputValidVersions []string
+		inputStrict        bool
+		expectedVersions   []AffectedVersion
+		expectedErr        error
+	}{
+		{
+			description:        "Best-effort",
+			inputCVEItem:       cve,
+			inputValidVersions: []string{"1.0", "1.1", "1.2"},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.0", Fixed: "1.2.3"}},
+		},
+		{
+			description:        "Strict, invalid version",
+			inputCVEItem:       cve,
+			inputValidVersions: []string{"1.0", "1.1", "1.2"},
+			inputStrict:        true,
+			expectedErr:        ErrInvalidVersion,
+		},
+		{
+			description:        "Strict, valid versions",
+			inputCVEItem:       cve,
+			inputValidVersions: []string{"1.0", "1.1", "1.2.3"},
+			inputStrict:        true,
+			expectedVersions:   []AffectedVersion{{Introduced: "1.0", Fixed: "1.2.3"}},
+		},
+		{
+			description:      "Strict, no valid versions",
+			inputCVEItem:     cve,
+			inputStrict:      true,
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.2.3"}},
+		},
+		{
+			description:      "Best-effort, unresolvable end without valid versions",
+			inputCVEItem:     endIncluding,
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", LastAffected: "1.2.3"}},
+		},
+		{
+			description:  "Strict, unresolvable end without valid versions",
+			inputCVEItem: endIncluding,
+			inputStrict:  true,
+			expectedErr:  ErrInvalidVersion,
+		},
+		{
+			description:        "Strict, version dropped from the description",
+			inputCVEItem:       description,
+			inputValidVersions: []string{"1.0", "1.1", "1.2"},
+			inputStrict:        true,
+			expectedErr:        ErrInvalidVersion,
+		},
+	}
+
+	for _, tc := range tests {
+		got, _, err := ExtractVersionInfoWithError(tc.inputCVEItem, tc.inputValidVersions, ExtractOptions{StrictVersions: tc.inputStrict})
+		if !errors.Is(err, tc.expectedErr) {
+			t.Errorf("test %q: ExtractVersionInfoWithError() error was incorrect, got: %v, expected: %v", tc.description, err, tc.expectedErr)
+		}
+		if diff := cmp.Diff(tc.expectedVersions, got.AffectedVersions, ignoreSource); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+	}
+
+	_, notes := ExtractVersionInfoWithOptions(cve, []string{"1.0", "1.1", "1.2"}, ExtractOptions{StrictVersions: true})
+	if len(notes) == 0 || !strings.Contains(notes[len(notes)-1], ErrInvalidVersion.Error()) {
+		t.Errorf("ExtractVersionInfoWithOptions() with StrictVersions didn't note the error, got: %q", notes)
+	}
+}
+
+func TestIsGitLabHost(t *testing.T) {
+	tests := []struct {
+		description   string
+		inputHostname string
+		expectedOk    bool
+	}{
+		{
+			description:   "gitlab.com",
+			inputHostname: "gitlab.com",
+			expectedOk:    true,
+		},
+		{
+			description:   "Instance matching gitlab.*",
+			inputHostname: "gitlab.gnome.org",
+			expectedOk:    true,
+		},
+		{
+			description:   "Mixed case",
+			inputHostname: "GitLab.Freedesktop.org",
+			expectedOk:    true,
+		},
+		{
+			description:   "GitLab Pages",
+			inputHostname: "gitlab.io",
+			expectedOk:    false,
+		},
+		{
+			description:   "Host merely containing gitlab",
+			inputHostname: "mygitlab.example.com",
+			expectedOk:    false,
+		},
+		{
+			description:   "Self-managed instance without configuration",
+			inputHostname: "code.example.com",
+			expectedOk:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		if got := IsGitLabHost(tc.inputHostname); got != tc.expectedOk {
+			t.Errorf("test %q: IsGitLabHost(%q) was incorrect, got: %t, expected: %t", tc.description, tc.inputHostname, got, tc.expectedOk)
+		}
+	}
+}
+
+func TestExtractVersionInfoChangedFilesFetcher(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [
+		{"url": "https://github.com/foo/bar/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+		{"url": "https://github.com/foo/bar/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5"}]}}}`)
+	fetcher := func(gc GitCommit) ([]string, error) {
+		if gc.Commit == "4f1b083be43f351bc107541e7b0c9655a5d2c0bb" {
+			return []string{"src/parser.c"}, nil
+		}
+		return nil, errors.New("rate limited")
+	}
+	expectedChangedFiles := map[GitCommit][]string{
+		{Repo: "https://github.com/foo/bar", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"}: {"src/parser.c"},
+	}
+	expectedNote := "Unable to determine the files changed by cd4e934d0527e5010e373e7fed54ef5daefba2f5 in https://github.com/foo/bar: rate limited"
+
+	gotVersionInfo, gotNotes := ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{ChangedFilesFetcher: fetcher})
+	if diff := cmp.Diff(expectedChangedFiles, gotVersionInfo.ChangedFiles); diff != "" {
+		t.Errorf("ChangedFiles were incorrect: %s", diff)
+	}
+	if !slices.Contains(gotNotes, expectedNote) {
+		t.Errorf("notes %#v did not contain %q", gotNotes, expectedNote)
+	}
+}
+
+func TestNormalizeVersionPreReleaseSpellings(t *testing.T) {
+	tests := []struct {
+		description               string
+		inputVersion              string
+		expectedNormalizedVersion string
+	}{
+		{
+			description:               "Dot-separated marker",
+			inputVersion:              "1.0.0.rc1",
+			expectedNormalizedVersion: "1-0-0-rc1",
+		},
+		{
+			description:               "Dash-separated marker",
+			inputVersion:              "1.0.0-rc1",
+			expectedNormalizedVersion: "1-0-0-rc1",
+		},
+		{
+			description:               "Unseparated marker",
+			inputVersion:              "1.0.0rc1",
+			expectedNormalizedVersion: "1-0-0-rc1",
+		},
+		{
+			description:               "Marker separated from its number",
+			inputVersion:              "1.0.0-rc.1",
+			expectedNormalizedVersion: "1-0-0-rc1",
+		},
+		{
+			description:               "Beta separated from its number",
+			inputVersion:              "2.1.0-beta.2",
+			expectedNormalizedVersion: "2-1-0-beta2",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := NormalizeVersion(tc.inputVersion)
+		if err != nil {
+			t.Errorf("test %q: NormalizeVersion(%q) unexpectedly errored: %v", tc.description, tc.inputVersion, err)
+		}
+		if got != tc.expectedNormalizedVersion {
+			t.Errorf("test %q: NormalizeVersion(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputVersion, got, tc.expectedNormalizedVersion)
+		}
+	}
+}
+
+func TestExtractVersionInfoReferenceResolver(t *testing.T) {
+	var resolved []string
+	resolver := func(u string) (string, error) {
+		resolved = append(resolved, u)
+		switch u {
+		case "https://git.example.org/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb":
+			return "https://github.com/example/project/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb", nil
+		case "https://example.org/blog/security":
+			return "https://example.org/blog/security-2023", nil
+		}
+		return "", errors.New("not found")
+	}
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [
+		{"url": "https://git.example.org/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+		{"url": "https://example.org/blog/security"},
+		{"url": "https://example.org/missing"},
+		{"url": "https://github.com/example/other/commit/9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"}]}}}`)
+	expectedFixCommits := []GitCommit{
+		{Repo: "https://github.com/example/project", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+		{Repo: "https://github.com/example/other", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"},
+	}
+	expectedResolved := []string{
+		"https://git.example.org/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb",
+		"https://example.org/blog/security",
+		"https://example.org/missing",
+	}
+
+	var diag ExtractDiagnostics
+	gotVersionInfo, _ := ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{ReferenceResolver: resolver, Diagnostics: &diag})
+	if diff := cmp.Diff(expectedFixCommits, gotVersionInfo.FixCommits); diff != "" {
+		t.Errorf("FixCommits were incorrect: %s", diff)
+	}
+	if diff := cmp.Diff(expectedResolved, resolved); diff != "" {
+		t.Errorf("References resolved were incorrect: %s", diff)
+	}
+	if diag.References[0].URL != "https://git.example.org/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb" || diag.References[0].Outcome != CommitReference {
+		t.Errorf("Outcome of the redirected reference was incorrect, got: %+v", diag.References[0])
+	}
+}
+
+func TestExtractFixedVersionLists(t *testing.T) {
+	tests := []struct {
+		description        string
+		inputContent       string
+		inputValidVersions []string
+		expectedVersions   []AffectedVersion
+		expectedNotes      []string
+	}{
+		{
+			description:      "Inline list without an introduced version",
+			inputContent:     "## Summary\nA flaw was found.\n\n**Fixed Versions:** 1.2.3, 1.3.1, 2.0.0\n",
+			expectedVersions: []AffectedVersion{{Fixed: "1.2.3"}, {Fixed: "1.3.1"}, {Fixed: "2.0.0"}},
+		},
+		{
+			description:      "List items with an introduced version",
+			inputContent:     "Affected: >= 1.2.0\n\nPatched releases:\n- v1.2.3\n- v1.3.1\n* 2.0.0\n\nUpgrade now.",
+			expectedVersions: []AffectedVersion{{Introduced: "1.2.0", Fixed: "1.2.3"}, {Introduced: "1.3.0", Fixed: "1.3.1"}, {Introduced: "2.0.0", Fixed: "2.0.0"}},
+		},
+		{
+			description:        "Branches grouped by valid versions",
+			inputContent:       "Introduced: 1.2.1\nFixed in versions: 1.1.9, 1.2.3 and 1.3.1\n",
+			inputValidVersions: []string{"1.1.8", "1.1.9", "1.2.1", "1.2.2", "1.2.3", "1.3.0-rc1", "1.3.0", "1.3.1"},
+			expectedVersions:   []AffectedVersion{{Introduced: "1.2.1", Fixed: "1.2.3"}, {Introduced: "1.3.0-rc1", Fixed: "1.3.1"}},
+			expectedNotes:      []string{"Dropping listed fixed version 1.1.9, which precedes the introduced version 1.2.1"},
+		},
+		{
+			description:        "Invalid listed version",
+			inputContent:       "Fixed versions: 1.2.3, 1.2.4",
+			inputValidVersions: []string{"1.2.2", "1.2.3"},
+			expectedVersions:   []AffectedVersion{{Fixed: "1.2.3"}},
+			expectedNotes:      []string{"Listed fixed version 1.2.4 is not a valid version"},
+		},
+		{
+			description:  "Singular label is not a list",
+			inputContent: "Fixed: 1.2.3",
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersions, gotNotes := ExtractFixedVersionLists(tc.inputContent, tc.inputValidVersions)
+		if diff := cmp.Diff(tc.expectedVersions, gotVersions); diff != "" {
+			t.Errorf("test %q: ExtractFixedVersionLists() was incorrect: %s", tc.description, diff)
+		}
+		if diff := cmp.Diff(tc.expectedNotes, gotNotes); diff != "" {
+			t.Errorf("test %q: ExtractFixedVersionLists() notes were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoReferenceScrapers(t *testing.T) {
+	fetcher := func(u string) (string, error) {
+		return "Introduced: 1.0.0\nFixed: 1.2.3\nFixed Versions: 1.2.3, 2.0.1\n", nil
+	}
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [{"url": "https://example.org/advisories/2023-01"}]}}}`)
+	tests := []struct {
+		description      string
+		inputScrapers    []ReferenceScraper
+		expectedVersions []AffectedVersion
+	}{
+		{
+			description: "Without scrapers",
+			expectedVersions: []AffectedVersion{
+				{Fixed: "1.2.3", Source: SourceReferenceContent},
+				{Fixed: "2.0.1", Source: SourceReferenceContent},
+			},
+		},
+		{
+			description:   "With the fixed version list scraper",
+			inputScrapers: []ReferenceScraper{ExtractFixedVersionLists},
+			expectedVersions: []AffectedVersion{
+				{Introduced: "1.0.0", Fixed: "1.2.3", Source: SourceReferenceContent},
+				{Introduced: "2.0.0", Fixed: "2.0.1", Source: SourceReferenceContent},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{ReferenceFetcher: fetcher, ReferenceScrapers: tc.inputScrapers})
+		if diff := cmp.Diff(tc.expectedVersions, gotVersionInfo.AffectedVersions); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestRepoConsistencyNotes(t *testing.T) {
+	tests := []struct {
+		description   string
+		inputCommits  []GitCommit
+		inputCPEs     []string
+		expectedNotes []string
+	}{
+		{
+			description:  "Repository named after the vendor",
+			inputCommits: []GitCommit{{Repo: "https://github.com/apache/httpd", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"}},
+			inputCPEs:    []string{"cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*"},
+		},
+		{
+			description:  "Repository named after the product",
+			inputCommits: []GitCommit{{Repo: "https://github.com/torvalds/linux", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"}},
+			inputCPEs:    []string{"cpe:2.3:o:linux:linux_kernel:*:*:*:*:*:*:*:*"},
+		},
+		{
+			description:  "Product spelt differently",
+			inputCommits: []GitCommit{{Repo: "https://gitlab.com/libtiff/libtiff", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"}},
+			inputCPEs:    []string{"cpe:2.3:a:lib-tiff:LibTIFF:*:*:*:*:*:*:*:*"},
+		},
+		{
+			description: "Unrelated repository",
+			inputCommits: []GitCommit{
+				{Repo: "https://github.com/vim/vim", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+				{Repo: "https://github.com/vim/vim", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"},
+				{Repo: "https://github.com/openssl/openssl", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"},
+			},
+			inputCPEs:     []string{"cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*", "cpe:2.3:a:nodejs:node.js:*:*:*:*:*:*:*:*"},
+			expectedNotes: []string{"Fix commits are in https://github.com/vim/vim, which does not correspond to any CPE product (openssl:openssl, nodejs:node.js)"},
+		},
+		{
+			description:  "No CPEs",
+			inputCommits: []GitCommit{{Repo: "https://github.com/vim/vim", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotNotes := VersionInfo{FixCommits: tc.inputCommits}.RepoConsistencyNotes(tc.inputCPEs)
+		if diff := cmp.Diff(tc.expectedNotes, gotNotes); diff != "" {
+			t.Errorf("test %q: RepoConsistencyNotes() was incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoRepoConsistency(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [
+		{"url": "https://github.com/vim/vim/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb"}]}},
+		"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+			{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*", "versionEndExcluding": "1.1.1t"}]}]}}`)
+	expectedNote := "Fix commits are in https://github.com/vim/vim, which does not correspond to any CPE product (openssl:openssl)"
+
+	_, gotNotes := ExtractVersionInfo(cve, nil)
+	if !slices.Contains(gotNotes, expectedNote) {
+		t.Errorf("ExtractVersionInfo() notes were incorrect, got: %q, expected to contain: %q", gotNotes, expectedNote)
+	}
+}
+
+func TestNormalizeVersionPatchSuffixes(t *testing.T) {
+	tests := []struct {
+		description               string
+		inputVersion              string
+		expectedNormalizedVersion string
+	}{
+		{
+			description:               "OpenSSL letter release",
+			inputVersion:              "1.1.1k",
+			expectedNormalizedVersion: "1-1-1-k",
+		},
+		{
+			description:               "OpenSSL letter release after z",
+			inputVersion:              "1.0.2zh",
+			expectedNormalizedVersion: "1-0-2-zh",
+		},
+		{
+			description:               "OpenSSL release tag",
+			inputVersion:              "OpenSSL_1_1_1k",
+			expectedNormalizedVersion: "1-1-1-k",
+		},
+		{
+			description:               "OpenSSL release without a letter",
+			inputVersion:              "1.1.1",
+			expectedNormalizedVersion: "1-1-1",
+		},
+		{
+			description:               "FreeBSD patch level",
+			inputVersion:              "11.2-p3",
+			expectedNormalizedVersion: "11-2-p3",
+		},
+		{
+			description:               "FreeBSD release patch level",
+			inputVersion:              "11.2-RELEASE-p3",
+			expectedNormalizedVersion: "11-2-p3",
+		},
+		{
+			description:               "OpenSSH portable release",
+			inputVersion:              "9.3p1",
+			expectedNormalizedVersion: "9-3-p1",
+		},
+		{
+			description:               "Pre-release is not a patch suffix",
+			inputVersion:              "1.0.0-rc1",
+			expectedNormalizedVersion: "1-0-0-rc1",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := NormalizeVersion(tc.inputVersion)
+		if err != nil {
+			t.Errorf("test %q: NormalizeVersion(%q) unexpectedly errored: %v", tc.description, tc.inputVersion, err)
+		}
+		if got != tc.expectedNormalizedVersion {
+			t.Errorf("test %q: NormalizeVersion(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputVersion, got, tc.expectedNormalizedVersion)
+		}
+	}
+}
+
+func TestAffectedVersionNotes(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputCVEItem     string
+		expectedVersions []AffectedVersion
+	}{
+		{
+			description: "Fixed version inferred from versionEndIncluding",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:example:foo:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndIncluding": "1.1"}]}]}}`,
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.4", Source: SourceCPE,
+				Notes: []string{"Fixed version inferred as the version after versionEndIncluding 1.1"}}},
+		},
+		{
+			description: "Explicit versionEndExcluding",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:example:foo:*:*:*:*:*:*:*:*", "versionStartIncluding": "1.0", "versionEndExcluding": "1.4"}]}]}}`,
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.4", Source: SourceCPE}},
+		},
+		{
+			description: "No start version",
+			inputCVEItem: `{"configurations": {"nodes": [{"operator": "OR", "cpe_match": [
+				{"vulnerable": true, "cpe23Uri": "cpe:2.3:a:example:foo:*:*:*:*:*:*:*:*", "versionEndExcluding": "1.4"}]}]}}`,
+			expectedVersions: []AffectedVersion{{Introduced: "0", Fixed: "1.4", Source: SourceCPE,
+				Notes: []string{"No start version stated, introduced at 0"}}},
+		},
+		{
+			description: "Fixed version inferred from the description",
+			inputCVEItem: `{"cve": {"description": {"description_data": [
+				{"lang": "en", "value": "A flaw in Foo 1.0 through 1.1 allows attackers to do bad things."}]}}}`,
+			expectedVersions: []AffectedVersion{{Introduced: "1.0", Fixed: "1.4", Source: SourceDescription,
+				Notes: []string{"Fixed version inferred as the version after 1.1, the last stated to be affected"}}},
+		},
+		{
+			description: "Fixed version from a tag",
+			inputCVEItem: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/example/foo/releases/tag/v1.4"}]}}}`,
+			expectedVersions: []AffectedVersion{{Fixed: "1.4", Source: SourceReferenceTag,
+				Notes: []string{"Fixed version from the tag v1.4 of https://github.com/example/foo/releases/tag/v1.4"}}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, _ := ExtractVersionInfo(cveItemFromJSON(t, tc.inputCVEItem), []string{"1.0", "1.1", "1.4"})
+		if diff := cmp.Diff(tc.expectedVersions, gotVersionInfo.AffectedVersions); diff != "" {
+			t.Errorf("test %q: AffectedVersions were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestAffectedVersionSameRange(t *testing.T) {
+	a := AffectedVersion{Introduced: "1.0", Fixed: "1.4", Notes: []string{"Fixed version inferred"}}
+	if !a.SameRange(AffectedVersion{Introduced: "1.0", Fixed: "1.4"}) {
+		t.Errorf("SameRange() of versions differing only in their notes was incorrect, got: false, expected: true")
+	}
+	if a.SameRange(AffectedVersion{Introduced: "1.0", Fixed: "1.4", Source: SourceCPE}) {
+		t.Errorf("SameRange() of versions from different sources was incorrect, got: true, expected: false")
+	}
+}
+
+func TestParseGitDescribe(t *testing.T) {
+	tests := []struct {
+		description         string
+		inputVersion        string
+		expectedDescription GitDescription
+		expectedOk          bool
+	}{
+		{
+			description:         "Default abbreviation",
+			inputVersion:        "v1.2.3-3-g0632158",
+			expectedDescription: GitDescription{Tag: "v1.2.3", Distance: 3, Commit: "0632158"},
+			expectedOk:          true,
+		},
+		{
+			description:         "Longer abbreviation of a dirty tree, relative to a pre-release tag",
+			inputVersion:        "v2.0.0-rc1-45-g0632158c5dff-dirty",
+			expectedDescription: GitDescription{Tag: "v2.0.0-rc1", Distance: 45, Commit: "0632158c5dff"},
+			expectedOk:          true,
+		},
+		{
+			description:  "Plain version",
+			inputVersion: "1.2.3-45",
+			expectedOk:   false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, ok := ParseGitDescribe(tc.inputVersion)
+		if ok != tc.expectedOk {
+			t.Errorf("test %q: ParseGitDescribe(%q) was incorrect, got ok: %t, expected: %t", tc.description, tc.inputVersion, ok, tc.expectedOk)
+		}
+		if diff := cmp.Diff(tc.expectedDescription, got); diff != "" {
+			t.Errorf("test %q: ParseGitDescribe(%q) was incorrect: %s", tc.description, tc.inputVersion, diff)
+		}
+	}
+}
+
+func TestNormalizeVersionGitDescribe(t *testing.T) {
+	tests := []struct {
+		description               string
+		inputVersion              string
+		expectedNormalizedVersion string
+	}{
+		{
+			description:               "git describe --tags",
+			inputVersion:              "v1.2.3-3-g0632158",
+			expectedNormalizedVersion: "1-2-3-3",
+		},
+		{
+			description:               "git describe --tags --long --dirty --abbrev=12",
+			inputVersion:              "v1.2.3-0-g0632158c5dff-dirty",
+			expectedNormalizedVersion: "1-2-3-0",
+		},
+		{
+			description:               "Relative to an OpenSSL release tag",
+			inputVersion:              "OpenSSL_1_1_1k-12-g0632158",
+			expectedNormalizedVersion: "1-1-1-k-12",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := NormalizeVersion(tc.inputVersion)
+		if err != nil {
+			t.Errorf("test %q: NormalizeVersion(%q) unexpectedly errored: %v", tc.description, tc.inputVersion, err)
+		}
+		if got != tc.expectedNormalizedVersion {
+			t.Errorf("test %q: NormalizeVersion(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputVersion, got, tc.expectedNormalizedVersion)
+		}
+	}
+}
+
+func TestRepoWithForges(t *testing.T) {
+	forges := Forges{
+		"git.example.org":  ForgeGitea,
+		"code.example.net": ForgeGitLab,
+		"src.example.com":  ForgeCGit,
+		"www.example.com":  "",
+	}
+	tests := []struct {
+		description    string
+		inputURL       string
+		inputForges    Forges
+		expectedRepo   string
+		expectedCommit string
+		expectedOk     bool
+	}{
+		{
+			description:    "Gitea commit",
+			inputURL:       "https://git.example.org/owner/project/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			inputForges:    forges,
+			expectedRepo:   "https://git.example.org/owner/project",
+			expectedCommit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			expectedOk:     true,
+		},
+		{
+			description:    "GitLab commit in a subgroup",
+			inputURL:       "https://code.example.net/group/subgroup/project/-/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			inputForges:    forges,
+			expectedRepo:   "https://code.example.net/group/subgroup/project",
+			expectedCommit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			expectedOk:     true,
+		},
+		{
+			description:    "cgit commit",
+			inputURL:       "https://src.example.com/project.git/commit/?id=0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			inputForges:    forges,
+			expectedRepo:   "https://src.example.com/project.git",
+			expectedCommit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			expectedOk:     true,
+		},
+		{
+			description: "Host running no forge",
+			inputURL:    "https://www.example.com/owner/project/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			inputForges: forges,
+			expectedOk:  false,
+		},
+		{
+			description: "Gitea commit without forges",
+			inputURL:    "https://git.example.org/owner/project/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			inputForges: nil,
+			expectedOk:  false,
+		},
+		{
+			description:    "Known host",
+			inputURL:       "https://try.gitea.io/owner/project/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			inputForges:    nil,
+			expectedRepo:   "https://try.gitea.io/owner/project",
+			expectedCommit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			expectedOk:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		gotRepo, err := RepoWithForges(tc.inputURL, tc.inputForges)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: RepoWithForges(%q) was incorrect, got: %q (%v), expected ok: %t", tc.description, tc.inputURL, gotRepo, err, tc.expectedOk)
+			continue
+		}
+		gotCommit, _ := CommitWithForges(tc.inputURL, tc.inputForges)
+		if gotRepo != tc.expectedRepo || (tc.expectedOk && gotCommit != tc.expectedCommit) {
+			t.Errorf("test %q: RepoWithForges(%q), CommitWithForges(%q) were incorrect, got: %q, %q, expected: %q, %q", tc.description, tc.inputURL, tc.inputURL, gotRepo, gotCommit, tc.expectedRepo, tc.expectedCommit)
+		}
+	}
+}
+
+func TestExtractVersionInfoForgeProber(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [
+		{"url": "https://git.example.org/owner/project/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70"},
+		{"url": "https://git.example.org/owner/project/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+		{"url": "https://down.example.com/owner/project/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+		{"url": "https://github.com/owner/project/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8"}
+	]}}}`)
+	var probed []string
+	prober := func(hostname string) (Forge, error) {
+		probed = append(probed, hostname)
+		if hostname == "down.example.com" {
+			return "", errors.New("connection refused")
+		}
+		return ForgeGitea, nil
+	}
+	tests := []struct {
+		description        string
+		inputOptions       ExtractOptions
+		expectedFixCommits []GitCommit
+		expectedProbed     []string
+		expectedNote       string
+	}{
+		{
+			description:  "Without a prober",
+			inputOptions: ExtractOptions{},
+			expectedFixCommits: []GitCommit{
+				{Repo: "https://github.com/owner/project", Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+			},
+		},
+		{
+			description:  "Unknown hosts are probed once each",
+			inputOptions: ExtractOptions{ForgeProber: prober},
+			expectedFixCommits: []GitCommit{
+				{Repo: "https://git.example.org/owner/project", Commit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70"},
+				{Repo: "https://git.example.org/owner/project", Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+				{Repo: "https://github.com/owner/project", Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+			},
+			expectedProbed: []string{"git.example.org", "down.example.com"},
+			expectedNote:   "Unable to detect the forge run by down.example.com: connection refused",
+		},
+	}
+
+	for _, tc := range tests {
+		probed = nil
+		got, notes := ExtractVersionInfoWithOptions(cve, nil, tc.inputOptions)
+		if diff := cmp.Diff(tc.expectedFixCommits, got.FixCommits); diff != "" {
+			t.Errorf("test %q: FixCommits were incorrect: %s", tc.description, diff)
+		}
+		if diff := cmp.Diff(tc.expectedProbed, probed); diff != "" {
+			t.Errorf("test %q: hosts probed were incorrect: %s", tc.description, diff)
+		}
+		if tc.expectedNote != "" && !slices.Contains(notes, tc.expectedNote) {
+			t.Errorf("test %q: notes %q did not contain %q", tc.description, notes, tc.expectedNote)
+		}
+	}
+}
+
+func TestExtractVersionInfoForgeProberReferences(t *testing.T) {
+	cve := cveItemFromJSON(t, `{"cve": {"references": {"reference_data": [
+		{"url": "https://git.example.org/owner/project"},
+		{"url": "https://project.example.com/source"}
+	]}}}`)
+	prober := func(hostname string) (Forge, error) {
+		if hostname == "git.example.org" {
+			return ForgeGitea, nil
+		}
+		return "", nil
+	}
+	var resolved []string
+	resolver := func(u string) (string, error) {
+		resolved = append(resolved, u)
+		return "https://git.example.org/owner/other", nil
+	}
+	expectedOutcomes := []ReferenceOutcome{
+		{URL: "https://git.example.org/owner/project", Outcome: RepositoryReference},
+		{URL: "https://project.example.com/source", Outcome: RepositoryReference},
+	}
+
+	var diag ExtractDiagnostics
+	ExtractVersionInfoWithOptions(cve, nil, ExtractOptions{ForgeProber: prober, ReferenceResolver: resolver, Diagnostics: &diag})
+	if diff := cmp.Diff(expectedOutcomes, diag.References); diff != "" {
+		t.Errorf("ReferenceOutcomes were incorrect: %s", diff)
+	}
+	// The repository on the probed host is supported as it is, so only the other is resolved.
+	if diff := cmp.Diff([]string{"https://project.example.com/source"}, resolved); diff != "" {
+		t.Errorf("References resolved were incorrect: %s", diff)
+	}
+}
+
+func TestExtractVersionInfoIntroducedCommitResolver(t *testing.T) {
+	resolver := func(fix GitCommit) (string, error) {
+		switch fix.Commit {
+		case "4f1b083be43f351bc107541e7b0c9655a5d2c0bb":
+			return "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e", nil
+		case "cd4e934d0527e5010e373e7fed54ef5daefba2f5":
+			return "", nil
+		}
+		return "", errors.New("repository not found")
+	}
+	tests := []struct {
+		description               string
+		inputCVEJSON              string
+		expectedIntroducedCommits []GitCommit
+		expectedNote              string
+	}{
+		{
+			description: "Introducing commit found",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/vim/vim/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb"}]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/vim/vim", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"}},
+			expectedNote:              "Using commit 9ebe80595afe4fdd1e2c74358d6a9421f4ce130e in https://github.com/vim/vim, which introduced what 4f1b083be43f351bc107541e7b0c9655a5d2c0bb fixed, as introduced",
+		},
+		{
+			description: "Introduced at the first commit",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/google/osv/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5"}]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/google/osv", Commit: "0"}},
+			expectedNote:              "No commit found introducing what cd4e934d0527e5010e373e7fed54ef5daefba2f5 fixed in https://github.com/google/osv, treating it as introduced at the first commit",
+		},
+		{
+			description: "Resolver failure",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/example/gone/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8"}]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/example/gone", Commit: "0"}},
+			expectedNote:              "Unable to determine the commit introducing what b1351c15946349f9daa7e5297fb2ac6f3139e4a8 fixed in https://github.com/example/gone, treating it as introduced at the first commit: repository not found",
+		},
+		{
+			description: "Introducing commit already referenced",
+			inputCVEJSON: `{"cve": {"references": {"reference_data": [
+				{"url": "https://github.com/vim/vim/commit/4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+				{"url": "https://github.com/vim/vim/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8", "tags": ["Introduced"]}]}}}`,
+			expectedIntroducedCommits: []GitCommit{{Repo: "https://github.com/vim/vim", Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8"}},
+		},
+	}
+
+	for _, tc := range tests {
+		gotVersionInfo, gotNotes := ExtractVersionInfoWithOptions(cveItemFromJSON(t, tc.inputCVEJSON), nil, ExtractOptions{IntroducedCommitResolver: resolver})
+		if diff := cmp.Diff(tc.expectedIntroducedCommits, gotVersionInfo.IntroducedCommits); diff != "" {
+			t.Errorf("test %q: IntroducedCommits were incorrect: %s", tc.description, diff)
+		}
+		if tc.expectedNote != "" && !slices.Contains(gotNotes, tc.expectedNote) {
+			t.Errorf("test %q: notes were incorrect, got: %q, expected to contain: %q", tc.description, gotNotes, tc.expectedNote)
+		}
+	}
+}
+
+func TestCommitURL(t *testing.T) {
+	tests := []struct {
+		description string
+		inputCommit GitCommit
+		expectedURL string
+		expectedOk  bool
+	}{
+		{
+			description: "GitHub",
+			inputCommit: GitCommit{Repo: "https://github.com/MariaDB/server", Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+			expectedURL: "https://github.com/MariaDB/server/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8",
+			expectedOk:  true,
+		},
+		{
+			description: "GitLab project in a subgroup",
+			inputCommit: GitCommit{Repo: "https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium", Commit: "4367a20cc4"},
+			expectedURL: "https://gitlab.com/gitlab-org/security-products/analyzers/gemnasium/-/commit/4367a20cc4",
+			expectedOk:  true,
+		},
+		{
+			description: "Self-managed GitLab",
+			inputCommit: GitCommit{Repo: "https://gitlab.freedesktop.org/virgl/virglrenderer", Commit: "b05bb61f454eeb8a85164c8a31510aeb9d79129c"},
+			expectedURL: "https://gitlab.freedesktop.org/virgl/virglrenderer/-/commit/b05bb61f454eeb8a85164c8a31510aeb9d79129c",
+			expectedOk:  true,
+		},
+		{
+			description: "Bitbucket",
+			inputCommit: GitCommit{Repo: "https://bitbucket.org/openpyxl/openpyxl", Commit: "3b4905f428e1"},
+			expectedURL: "https://bitbucket.org/openpyxl/openpyxl/commits/3b4905f428e1",
+			expectedOk:  true,
+		},
+		{
+			description: "Gitea",
+			inputCommit: GitCommit{Repo: "https://codeberg.org/forgejo/forgejo", Commit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70"},
+			expectedURL: "https://codeberg.org/forgejo/forgejo/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			expectedOk:  true,
+		},
+		{
+			description: "Gitea clone URL",
+			inputCommit: GitCommit{Repo: "https://codeberg.org/forgejo/forgejo.git", Commit: "0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70"},
+			expectedURL: "https://codeberg.org/forgejo/forgejo/commit/0cd1b8ecfa6fd3ff5c3e0b8e6b8ccc7d8c4f9d70",
+			expectedOk:  true,
+		},
+		{
+			description: "pagure.io",
+			inputCommit: GitCommit{Repo: "https://pagure.io/libaio", Commit: "d025927efa75a0d138d2ea67f5b1a3ee59eb8ede"},
+			expectedURL: "https://pagure.io/libaio/c/d025927efa75a0d138d2ea67f5b1a3ee59eb8ede",
+			expectedOk:  true,
+		},
+		{
+			description: "SourceForge",
+			inputCommit: GitCommit{Repo: "https://sourceforge.net/p/libpng/code", Commit: "a901eb3ce6087e0afeef988247f1a1aa208cb54d"},
+			expectedURL: "https://sourceforge.net/p/libpng/code/ci/a901eb3ce6087e0afeef988247f1a1aa208cb54d/",
+			expectedOk:  true,
+		},
+		{
+			description: "Gitiles",
+			inputCommit: GitCommit{Repo: "https://chromium.googlesource.com/chromium/src", Commit: "8f4d6a1d5e9c6b4c2ee0b5bd1e5a4ec5c6a0d0f1"},
+			expectedURL: "https://chromium.googlesource.com/chromium/src/+/8f4d6a1d5e9c6b4c2ee0b5bd1e5a4ec5c6a0d0f1",
+			expectedOk:  true,
+		},
+		{
+			description: "cgit",
+			inputCommit: GitCommit{Repo: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git", Commit: "817b8b9c5396d2b2d92311b46719aad5d3339dbe"},
+			expectedURL: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/commit/?id=817b8b9c5396d2b2d92311b46719aad5d3339dbe",
+			expectedOk:  true,
+		},
+		{
+			description: "cgit under /cgit",
+			inputCommit: GitCommit{Repo: "https://git.dpkg.org/cgit/dpkg/dpkg.git", Commit: "faa4c92debe45412bfcf8a44f26e827800bb24be"},
+			expectedURL: "https://git.dpkg.org/cgit/dpkg/dpkg.git/commit/?id=faa4c92debe45412bfcf8a44f26e827800bb24be",
+			expectedOk:  true,
+		},
+		{
+			description: "GitWeb",
+			inputCommit: GitCommit{Repo: "https://sourceware.org/git/glibc.git", Commit: "6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c"},
+			expectedURL: "https://sourceware.org/git/?p=glibc.git;a=commit;h=6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c",
+			expectedOk:  true,
+		},
+		{
+			description: "GitWeb CGI",
+			inputCommit: GitCommit{Repo: "https://git.gnupg.org/libksba.git", Commit: "f61a5ea4e0f6a80fd4b28ef0174bee77793cf070"},
+			expectedURL: "https://git.gnupg.org/cgi-bin/gitweb.cgi?p=libksba.git;a=commit;h=f61a5ea4e0f6a80fd4b28ef0174bee77793cf070",
+			expectedOk:  true,
+		},
+		{
+			description: "Unsupported host",
+			inputCommit: GitCommit{Repo: "https://git.example.com/project.git", Commit: "6f1a1f7ba5d4fa1b5f16d2cbf2b3bb7d1e6a7c6c"},
+			expectedOk:  false,
+		},
+		{
+			description: "Not a commit hash",
+			inputCommit: GitCommit{Repo: "https://github.com/MariaDB/server", Commit: "main"},
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := CommitURL(tc.inputCommit)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: CommitURL(%+v) was incorrect, got: %q (%v), expected ok: %t", tc.description, tc.inputCommit, got, err, tc.expectedOk)
+			continue
+		}
+		if got != tc.expectedURL {
+			t.Errorf("test %q: CommitURL(%+v) was incorrect, got: %q, expected: %q", tc.description, tc.inputCommit, got, tc.expectedURL)
+		}
+		if !tc.expectedOk {
+			continue
+		}
+		// The URL leads back to the commit, in the repository (less any ".git" suffix omitted by its pages).
+		if repo, err := Repo(got); err != nil || (repo != tc.inputCommit.Repo && repo != strings.TrimSuffix(tc.inputCommit.Repo, ".git")) {
+			t.Errorf("test %q: Repo(%q) was incorrect, got: %q (%v), expected: %q", tc.description, got, repo, err, tc.inputCommit.Repo)
+		}
+		if commit, err := Commit(got); err != nil || commit != tc.inputCommit.Commit {
+			t.Errorf("test %q: Commit(%q) was incorrect, got: %q (%v), expected: %q", tc.description, got, commit, err, tc.inputCommit.Commit)
+		}
+	}
+}
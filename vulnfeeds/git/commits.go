@@ -0,0 +1,545 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// HTTPClient is the subset of *http.Client used to talk to repository host APIs.
+// It exists so tests can substitute canned responses for network access.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// githubAPIBase returns the base URL of the REST API of a GitHub (or GitHub Enterprise) host.
+func githubAPIBase(hostname string) string {
+	if hostname == "github.com" {
+		return "https://api.github.com"
+	}
+	return fmt.Sprintf("https://%s/api/v3", hostname)
+}
+
+// hostAPI identifies the API of a repository host.
+type hostAPI int
+
+const (
+	githubAPI hostAPI = iota + 1
+	gitlabAPI
+	bitbucketAPI
+)
+
+// apiRepo returns the API of the host of repo, and the base URL of the repository's resources in that API
+// (e.g. "https://api.github.com/repos/owner/name"), if the host has a supported API.
+func apiRepo(repo string) (hostAPI, string, bool) {
+	u, err := url.Parse(repo)
+	if err != nil {
+		return 0, "", false
+	}
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if repoPath == "" {
+		return 0, "", false
+	}
+	switch {
+	case cves.IsGitHubHost(u.Hostname()):
+		return githubAPI, fmt.Sprintf("%s/repos/%s", githubAPIBase(u.Hostname()), repoPath), true
+	case u.Hostname() == "bitbucket.org":
+		return bitbucketAPI, fmt.Sprintf("https://api.bitbucket.org/2.0/repositories/%s", repoPath), true
+	case cves.IsGitLabHost(u.Hostname()):
+		// GitLab projects may be nested in subgroups, so the whole path is the (escaped) project ID.
+		return gitlabAPI, fmt.Sprintf("https://%s/api/v4/projects/%s", u.Hostname(), url.QueryEscape(repoPath)), true
+	}
+	return 0, "", false
+}
+
+// commitAPIURL returns the host API URL describing the commit in gc, if the host has a supported API.
+func commitAPIURL(gc cves.GitCommit) (string, bool) {
+	api, base, ok := apiRepo(gc.Repo)
+	if !ok {
+		return "", false
+	}
+	switch api {
+	case githubAPI:
+		return base + "/commits/" + gc.Commit, true
+	case bitbucketAPI:
+		return base + "/commit/" + gc.Commit, true
+	case gitlabAPI:
+		return base + "/repository/commits/" + gc.Commit, true
+	}
+	return "", false
+}
+
+// commitFetcher reports whether the commit with the (full) hash exists in repo, by fetching it.
+type commitFetcher func(repo string, hash plumbing.Hash) (bool, error)
+
+// VerifyCommit returns whether the commit in gc exists in its repository.
+// Repositories on hosts with a supported API (GitHub, GitLab, Bitbucket) are queried using client,
+// otherwise only the commit itself is fetched into memory (a shallow fetch of a single object), which
+// requires a full commit hash and a server allowing commits to be fetched by hash.
+func VerifyCommit(gc cves.GitCommit, client HTTPClient) (bool, error) {
+	return verifyCommit(gc, client, fetchCommit)
+}
+
+// verifyCommit implements VerifyCommit, using fetch for repositories on hosts without a supported API.
+func verifyCommit(gc cves.GitCommit, client HTTPClient, fetch commitFetcher) (bool, error) {
+	if gc.Repo == "" || gc.Commit == "" {
+		return false, fmt.Errorf("incomplete commit %+v", gc)
+	}
+	apiURL, ok := commitAPIURL(gc)
+	if !ok {
+		if !plumbing.IsHash(gc.Commit) {
+			return false, fmt.Errorf("unsupported host: %s has no supported API to verify the abbreviated commit %s", gc.Repo, gc.Commit)
+		}
+		return fetch(gc.Repo, plumbing.NewHash(gc.Commit))
+	}
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	// GitHub returns 422 for a syntactically invalid commit hash.
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected status %q from %s", resp.Status, apiURL)
+}
+
+// fetchHistory fetches the commit hash of repo into memory, along with its history up to depth commits
+// (or all of it, for a depth of 0). Servers that don't allow fetching commits by hash are an (unsupported
+// host) error.
+func fetchHistory(repo string, hash plumbing.Hash, depth int) (*git.Repository, error) {
+	r, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := r.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{repo}})
+	if err != nil {
+		return nil, err
+	}
+	err = remote.Fetch(&git.FetchOptions{
+		RefSpecs: []config.RefSpec{config.RefSpec(hash.String() + ":refs/heads/fetched")},
+		Depth:    depth,
+		Tags:     git.NoTags,
+	})
+	if errors.Is(err, git.ErrExactSHA1NotSupported) {
+		return nil, fmt.Errorf("unsupported host: %s doesn't allow fetching commit %s: %w", repo, hash, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// fetchCommit fetches only the commit hash of repo into memory, with a depth of 1.
+func fetchCommit(repo string, hash plumbing.Hash) (bool, error) {
+	_, err := fetchHistory(repo, hash, 1)
+	switch {
+	case err == nil:
+		return true, nil
+	// Servers refuse to send objects that aren't reachable from one of their references.
+	case strings.Contains(err.Error(), "not our ref"):
+		return false, nil
+	}
+	return false, err
+}
+
+// CommitVerifier returns a cves.CommitVerifier that uses VerifyCommit with client,
+// retrying transient failures.
+func CommitVerifier(client HTTPClient) cves.CommitVerifier {
+	client = withRetries(client)
+	return func(gc cves.GitCommit) (bool, error) {
+		return VerifyCommit(gc, client)
+	}
+}
+
+// tagAPIURL returns the host API URL describing the tag of repo, if the host has a supported API.
+func tagAPIURL(repo, tag string) (string, bool) {
+	api, base, ok := apiRepo(repo)
+	if !ok {
+		return "", false
+	}
+	switch api {
+	case githubAPI:
+		return base + "/commits/" + url.PathEscape(tag), true
+	case bitbucketAPI:
+		return base + "/refs/tags/" + url.PathEscape(tag), true
+	case gitlabAPI:
+		return base + "/repository/tags/" + url.PathEscape(tag), true
+	}
+	return "", false
+}
+
+// tagAPIResponse holds the commit a tag points to, as described by each of the supported host APIs.
+type tagAPIResponse struct {
+	SHA    string `json:"sha"` // GitHub
+	Commit struct {
+		ID string `json:"id"`
+	} `json:"commit"` // GitLab
+	Target struct {
+		Hash string `json:"hash"`
+	} `json:"target"` // Bitbucket
+}
+
+// TagToCommit returns the hash of the commit the (annotated or lightweight) tag of repo points to.
+// Repositories on hosts with a supported API (GitHub, GitLab, Bitbucket) are queried using client,
+// otherwise the tag alone is fetched into memory and resolved there.
+func TagToCommit(repo, tag string, client HTTPClient) (string, error) {
+	if repo == "" || tag == "" {
+		return "", fmt.Errorf("incomplete tag %q of %q", tag, repo)
+	}
+	apiURL, ok := tagAPIURL(repo, tag)
+	if !ok {
+		return tagToCommitByClone(repo, tag)
+	}
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		return "", fmt.Errorf("tag %q not found in %s", tag, repo)
+	default:
+		return "", fmt.Errorf("unexpected status %q from %s", resp.Status, apiURL)
+	}
+	var tagResponse tagAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tagResponse); err != nil {
+		return "", fmt.Errorf("unable to parse response from %s: %w", apiURL, err)
+	}
+	for _, commit := range []string{tagResponse.SHA, tagResponse.Commit.ID, tagResponse.Target.Hash} {
+		if commit != "" {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("no commit for tag %q in response from %s", tag, apiURL)
+}
+
+// tagToCommitByClone fetches only the tag of repo into memory, and resolves it to the commit it points to.
+func tagToCommitByClone(repo, tag string) (string, error) {
+	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:           repo,
+		ReferenceName: plumbing.NewTagReferenceName(tag),
+		SingleBranch:  true,
+		Depth:         1,
+		NoCheckout:    true,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash, err := r.ResolveRevision(plumbing.Revision(plumbing.NewTagReferenceName(tag)))
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// TagResolver returns a cves.TagResolver that uses TagToCommit with client,
+// retrying transient failures.
+func TagResolver(client HTTPClient) cves.TagResolver {
+	client = withRetries(client)
+	return func(repo, tag string) (string, error) {
+		return TagToCommit(repo, tag, client)
+	}
+}
+
+// mergeRequestAPIResponse holds the state and resulting commits of a GitLab merge request.
+type mergeRequestAPIResponse struct {
+	State           string `json:"state"`
+	MergeCommitSHA  string `json:"merge_commit_sha"`
+	SquashCommitSHA string `json:"squash_commit_sha"`
+	SHA             string `json:"sha"`
+}
+
+// MergeRequestCommit returns the hash of the commit the GitLab merge request of repo was merged as,
+// queried using client. Merge requests that haven't been merged are an error.
+func MergeRequestCommit(repo string, mergeRequest int, client HTTPClient) (string, error) {
+	api, base, ok := apiRepo(repo)
+	if !ok || api != gitlabAPI {
+		return "", fmt.Errorf("%s is not a GitLab repository", repo)
+	}
+	apiURL := fmt.Sprintf("%s/merge_requests/%d", base, mergeRequest)
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("merge request !%d not found in %s", mergeRequest, repo)
+	default:
+		return "", fmt.Errorf("unexpected status %q from %s", resp.Status, apiURL)
+	}
+	var mr mergeRequestAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&mr); err != nil {
+		return "", fmt.Errorf("unable to parse response from %s: %w", apiURL, err)
+	}
+	if mr.State != "merged" {
+		return "", fmt.Errorf("merge request !%d of %s is %s, not merged", mergeRequest, repo, mr.State)
+	}
+	// Fast-forward merges have no merge commit, leaving the (squashed or head) commit of the
+	// merge request itself on the target branch.
+	for _, commit := range []string{mr.MergeCommitSHA, mr.SquashCommitSHA, mr.SHA} {
+		if commit != "" {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("no commit for merged merge request !%d of %s", mergeRequest, repo)
+}
+
+// MergeRequestResolver returns a cves.MergeRequestResolver that uses MergeRequestCommit with client,
+// retrying transient failures.
+func MergeRequestResolver(client HTTPClient) cves.MergeRequestResolver {
+	client = withRetries(client)
+	return func(repo string, mergeRequest int) (string, error) {
+		return MergeRequestCommit(repo, mergeRequest, client)
+	}
+}
+
+// changedFilesAPIResponse is the subset of the GitHub commit, GitLab commit diff and Bitbucket diffstat
+// API responses naming the files changed by a commit.
+type changedFilesAPIResponse struct {
+	// GitHub
+	Files []struct {
+		Filename string `json:"filename"`
+	} `json:"files"`
+	// Bitbucket
+	Values []struct {
+		New *struct {
+			Path string `json:"path"`
+		} `json:"new"`
+		Old *struct {
+			Path string `json:"path"`
+		} `json:"old"`
+	} `json:"values"`
+}
+
+// gitlabDiffAPIResponse is the subset of a GitLab commit diff API response naming the files changed.
+type gitlabDiffAPIResponse []struct {
+	NewPath string `json:"new_path"`
+	OldPath string `json:"old_path"`
+}
+
+// ChangedFiles returns the paths of the files changed by the commit in gc, as reported by the host API
+// (GitHub, GitLab and Bitbucket are supported) using client. Deleted files are reported by their old path.
+// GitHub reports at most the first 300 files of a commit.
+func ChangedFiles(gc cves.GitCommit, client HTTPClient) ([]string, error) {
+	if gc.Repo == "" || gc.Commit == "" {
+		return nil, fmt.Errorf("incomplete commit %+v", gc)
+	}
+	api, base, ok := apiRepo(gc.Repo)
+	if !ok {
+		return nil, fmt.Errorf("no supported API for %s", gc.Repo)
+	}
+	var apiURL string
+	switch api {
+	case githubAPI:
+		apiURL = base + "/commits/" + gc.Commit
+	case bitbucketAPI:
+		apiURL = base + "/diffstat/" + gc.Commit
+	case gitlabAPI:
+		apiURL = base + "/repository/commits/" + gc.Commit + "/diff"
+	}
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		return nil, fmt.Errorf("commit %s not found in %s", gc.Commit, gc.Repo)
+	default:
+		return nil, fmt.Errorf("unexpected status %q from %s", resp.Status, apiURL)
+	}
+
+	files := []string{}
+	if api == gitlabAPI {
+		var diffs gitlabDiffAPIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&diffs); err != nil {
+			return nil, fmt.Errorf("unable to parse response from %s: %w", apiURL, err)
+		}
+		for _, diff := range diffs {
+			path := diff.NewPath
+			if path == "" {
+				path = diff.OldPath
+			}
+			files = append(files, path)
+		}
+		return files, nil
+	}
+	var changed changedFilesAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&changed); err != nil {
+		return nil, fmt.Errorf("unable to parse response from %s: %w", apiURL, err)
+	}
+	for _, file := range changed.Files {
+		files = append(files, file.Filename)
+	}
+	for _, value := range changed.Values {
+		switch {
+		case value.New != nil:
+			files = append(files, value.New.Path)
+		case value.Old != nil:
+			files = append(files, value.Old.Path)
+		}
+	}
+	return files, nil
+}
+
+// ChangedFilesFetcher returns a cves.ChangedFilesFetcher that uses ChangedFiles with client,
+// retrying transient failures.
+func ChangedFilesFetcher(client HTTPClient) cves.ChangedFilesFetcher {
+	client = withRetries(client)
+	return func(gc cves.GitCommit) ([]string, error) {
+		return ChangedFiles(gc, client)
+	}
+}
+
+// incidentalFilePattern matches the paths of files a fix commonly changes alongside the vulnerable code:
+// release notes, documentation, build files and tests.
+var incidentalFilePattern = regexp.MustCompile(`(?i)` +
+	`(^|/)(news|changes|changelog|history|release[-_]?notes|readme|authors|makefile|cmakelists\.txt|configure|meson\.build|package\.json|go\.(mod|sum))(\.[a-z]+)?$` +
+	`|\.(md|rst|adoc|txt)$` +
+	`|(^|/)(docs?|tests?|testdata)/` +
+	`|[-_.]test\.[a-z]+$`)
+
+// IntroducingCommit returns a plausible commit introducing the vulnerability fixed by fix, for use as a
+// cves.IntroducedCommitResolver: the commit creating the newest of the files fix modifies, disregarding
+// incidental ones (see incidentalFilePattern), in the history before fix. The vulnerable code can only
+// have existed in full once all of those files did. Returns "" (the vulnerability is taken to have been
+// present since the first commit) if fix modifies no such file, or is itself the first commit.
+// The history of fix (but not of other branches) is fetched into memory, which requires a full commit
+// hash and a server allowing commits to be fetched by hash, and is expensive for large repositories.
+func IntroducingCommit(fix cves.GitCommit) (string, error) {
+	if !plumbing.IsHash(fix.Commit) {
+		return "", fmt.Errorf("unable to fetch the history of abbreviated commit %s in %s", fix.Commit, fix.Repo)
+	}
+	hash := plumbing.NewHash(fix.Commit)
+	repo, err := fetchHistory(fix.Repo, hash, 0)
+	if err != nil {
+		return "", err
+	}
+	return introducingCommit(repo, hash)
+}
+
+// introducingCommit returns the latest commit creating one of the files modified (other than incidentally)
+// by the commit fix of repo, in the history of its first parent, or "" if there is none.
+func introducingCommit(repo *git.Repository, fix plumbing.Hash) (string, error) {
+	fixCommit, err := repo.CommitObject(fix)
+	if err != nil {
+		return "", err
+	}
+	if fixCommit.NumParents() == 0 {
+		return "", nil
+	}
+	parent, err := fixCommit.Parent(0)
+	if err != nil {
+		return "", err
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return "", err
+	}
+	fixTree, err := fixCommit.Tree()
+	if err != nil {
+		return "", err
+	}
+	changes, err := object.DiffTree(parentTree, fixTree)
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	for _, change := range changes {
+		// Files the fix adds weren't vulnerable.
+		if change.From.Name == "" || incidentalFilePattern.MatchString(change.From.Name) {
+			continue
+		}
+		files = append(files, change.From.Name)
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	// Walking back from the parent, the first commit creating one of the files creates the newest of them.
+	// Files merged in from other branches are taken to be created by the merge.
+	for commit := parent; ; {
+		tree, err := commit.Tree()
+		if err != nil {
+			return "", err
+		}
+		var previousTree *object.Tree
+		var previous *object.Commit
+		if commit.NumParents() > 0 {
+			if previous, err = commit.Parent(0); err != nil {
+				return "", err
+			}
+			if previousTree, err = previous.Tree(); err != nil {
+				return "", err
+			}
+		}
+		for _, file := range files {
+			if !treeHasFile(tree, file) {
+				continue
+			}
+			if previousTree == nil || !treeHasFile(previousTree, file) {
+				return commit.Hash.String(), nil
+			}
+		}
+		if previous == nil {
+			return "", nil
+		}
+		commit = previous
+	}
+}
+
+// treeHasFile reports whether the file at path exists in tree.
+func treeHasFile(tree *object.Tree, path string) bool {
+	_, err := tree.FindEntry(path)
+	return err == nil
+}
@@ -0,0 +1,533 @@
+package git
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// fakeHTTPClient returns canned responses keyed on the requested URL.
+type fakeHTTPClient struct {
+	responses map[string]int
+	// bodies optionally holds the response body for a URL, defaulting to an empty JSON object.
+	bodies map[string]string
+	// locations optionally holds the Location header of the response for a URL.
+	locations map[string]string
+}
+
+func (c *fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	status, ok := c.responses[req.URL.String()]
+	if !ok {
+		return nil, errors.New("unexpected request for " + req.URL.String())
+	}
+	body, ok := c.bodies[req.URL.String()]
+	if !ok {
+		body = "{}"
+	}
+	header := make(http.Header)
+	if location, ok := c.locations[req.URL.String()]; ok {
+		header.Set("Location", location)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestVerifyCommit(t *testing.T) {
+	client := &fakeHTTPClient{
+		responses: map[string]int{
+			"https://api.github.com/repos/vim/vim/commits/4f1b083be43f351bc107541e7b0c9655a5d2c0bb":                                         http.StatusOK,
+			"https://api.github.com/repos/vim/vim/commits/deadbeef":                                                                         http.StatusUnprocessableEntity,
+			"https://gitlab.com/api/v4/projects/gitlab-org%2Fsecurity%2Fgitaly/repository/commits/9ebe80595afe4fdd1e2c74358d6a9421f4ce130e": http.StatusNotFound,
+			"https://api.bitbucket.org/2.0/repositories/openpyxl/openpyxl/commit/3b4905f428e1":                                              http.StatusOK,
+			"https://api.github.com/repos/google/osv.dev/commits/cd4e934d0527e5010e373e7fed54ef5daefba2f5":                                  http.StatusForbidden,
+		},
+	}
+	tests := []struct {
+		description    string
+		inputCommit    cves.GitCommit
+		expectedResult bool
+		expectedOk     bool
+	}{
+		{
+			description:    "Existing GitHub commit",
+			inputCommit:    cves.GitCommit{Repo: "https://github.com/vim/vim", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+			expectedResult: true,
+			expectedOk:     true,
+		},
+		{
+			description:    "Invalid GitHub commit",
+			inputCommit:    cves.GitCommit{Repo: "https://github.com/vim/vim", Commit: "deadbeef"},
+			expectedResult: false,
+			expectedOk:     true,
+		},
+		{
+			description:    "Non-existent commit in a GitLab subgroup",
+			inputCommit:    cves.GitCommit{Repo: "https://gitlab.com/gitlab-org/security/gitaly", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"},
+			expectedResult: false,
+			expectedOk:     true,
+		},
+		{
+			description:    "Existing Bitbucket commit",
+			inputCommit:    cves.GitCommit{Repo: "https://bitbucket.org/openpyxl/openpyxl", Commit: "3b4905f428e1"},
+			expectedResult: true,
+			expectedOk:     true,
+		},
+		{
+			description:    "Unexpected API response",
+			inputCommit:    cves.GitCommit{Repo: "https://github.com/google/osv.dev", Commit: "cd4e934d0527e5010e373e7fed54ef5daefba2f5"},
+			expectedResult: false,
+			expectedOk:     false,
+		},
+		{
+			description:    "Incomplete commit",
+			inputCommit:    cves.GitCommit{Repo: "https://github.com/google/osv.dev"},
+			expectedResult: false,
+			expectedOk:     false,
+		},
+		{
+			description:    "Existing commit fetched from a host without an API",
+			inputCommit:    cves.GitCommit{Repo: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git", Commit: "817b8b9c5396d2b2d92311b46719aad5d3339dbe"},
+			expectedResult: true,
+			expectedOk:     true,
+		},
+		{
+			description:    "Non-existent commit fetched from a host without an API",
+			inputCommit:    cves.GitCommit{Repo: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git", Commit: "0000000000000000000000000000000000000000"},
+			expectedResult: false,
+			expectedOk:     true,
+		},
+		{
+			description:    "Server not allowing fetching by hash",
+			inputCommit:    cves.GitCommit{Repo: "https://git.savannah.gnu.org/git/emacs.git", Commit: "817b8b9c5396d2b2d92311b46719aad5d3339dbe"},
+			expectedResult: false,
+			expectedOk:     false,
+		},
+		{
+			description:    "Abbreviated commit on a host without an API",
+			inputCommit:    cves.GitCommit{Repo: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git", Commit: "817b8b9c5396"},
+			expectedResult: false,
+			expectedOk:     false,
+		},
+	}
+	// fetch stands in for fetching commits from repositories on hosts without a supported API.
+	fetch := func(repo string, hash plumbing.Hash) (bool, error) {
+		if strings.HasPrefix(repo, "https://git.savannah.gnu.org/") {
+			return false, git.ErrExactSHA1NotSupported
+		}
+		return hash.String() == "817b8b9c5396d2b2d92311b46719aad5d3339dbe", nil
+	}
+
+	for _, tc := range tests {
+		got, err := verifyCommit(tc.inputCommit, client, fetch)
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: VerifyCommit(%#v) unexpectedly failed: %#v", tc.description, tc.inputCommit, err)
+		}
+		if err == nil && !tc.expectedOk {
+			t.Errorf("test %q: VerifyCommit(%#v) unexpectedly succeeded", tc.description, tc.inputCommit)
+		}
+		if got != tc.expectedResult {
+			t.Errorf("test %q: VerifyCommit(%#v) was incorrect, got: %t, expected: %t", tc.description, tc.inputCommit, got, tc.expectedResult)
+		}
+	}
+}
+
+func TestTagToCommit(t *testing.T) {
+	client := &fakeHTTPClient{
+		responses: map[string]int{
+			"https://api.github.com/repos/vim/vim/commits/v9.0.1234":                                    http.StatusOK,
+			"https://api.github.com/repos/vim/vim/commits/v0.0.0":                                       http.StatusUnprocessableEntity,
+			"https://gitlab.com/api/v4/projects/gitlab-org%2Fsecurity%2Fgitaly/repository/tags/v15.1.0": http.StatusOK,
+			"https://api.bitbucket.org/2.0/repositories/openpyxl/openpyxl/refs/tags/3.0.4":              http.StatusOK,
+			"https://api.github.com/repos/google/osv.dev/commits/v0.1":                                  http.StatusOK,
+			"https://api.github.com/repos/google/osv.dev/commits/release%2F2023":                        http.StatusForbidden,
+		},
+		bodies: map[string]string{
+			"https://api.github.com/repos/vim/vim/commits/v9.0.1234":                                    `{"sha": "4f1b083be43f351bc107541e7b0c9655a5d2c0bb", "commit": {"message": "patch 9.0.1234"}}`,
+			"https://gitlab.com/api/v4/projects/gitlab-org%2Fsecurity%2Fgitaly/repository/tags/v15.1.0": `{"name": "v15.1.0", "commit": {"id": "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"}}`,
+			"https://api.bitbucket.org/2.0/repositories/openpyxl/openpyxl/refs/tags/3.0.4":              `{"name": "3.0.4", "target": {"hash": "3b4905f428e1"}}`,
+		},
+	}
+	tests := []struct {
+		description    string
+		inputRepo      string
+		inputTag       string
+		expectedCommit string
+		expectedOk     bool
+	}{
+		{
+			description:    "GitHub tag",
+			inputRepo:      "https://github.com/vim/vim",
+			inputTag:       "v9.0.1234",
+			expectedCommit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb",
+			expectedOk:     true,
+		},
+		{
+			description: "Non-existent GitHub tag",
+			inputRepo:   "https://github.com/vim/vim",
+			inputTag:    "v0.0.0",
+			expectedOk:  false,
+		},
+		{
+			description:    "Tag in a GitLab subgroup",
+			inputRepo:      "https://gitlab.com/gitlab-org/security/gitaly",
+			inputTag:       "v15.1.0",
+			expectedCommit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e",
+			expectedOk:     true,
+		},
+		{
+			description:    "Bitbucket tag",
+			inputRepo:      "https://bitbucket.org/openpyxl/openpyxl",
+			inputTag:       "3.0.4",
+			expectedCommit: "3b4905f428e1",
+			expectedOk:     true,
+		},
+		{
+			description: "Response without a commit",
+			inputRepo:   "https://github.com/google/osv.dev",
+			inputTag:    "v0.1",
+			expectedOk:  false,
+		},
+		{
+			description: "Unexpected API response for an escaped tag",
+			inputRepo:   "https://github.com/google/osv.dev",
+			inputTag:    "release/2023",
+			expectedOk:  false,
+		},
+		{
+			description: "Incomplete tag",
+			inputRepo:   "https://github.com/google/osv.dev",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := TagToCommit(tc.inputRepo, tc.inputTag, client)
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: TagToCommit(%q, %q) unexpectedly failed: %#v", tc.description, tc.inputRepo, tc.inputTag, err)
+		}
+		if err == nil && !tc.expectedOk {
+			t.Errorf("test %q: TagToCommit(%q, %q) unexpectedly succeeded", tc.description, tc.inputRepo, tc.inputTag)
+		}
+		if got != tc.expectedCommit {
+			t.Errorf("test %q: TagToCommit(%q, %q) was incorrect, got: %q, expected: %q", tc.description, tc.inputRepo, tc.inputTag, got, tc.expectedCommit)
+		}
+	}
+}
+
+func TestMergeRequestCommit(t *testing.T) {
+	client := &fakeHTTPClient{
+		responses: map[string]int{
+			"https://gitlab.com/api/v4/projects/libtiff%2Flibtiff/merge_requests/378":             http.StatusOK,
+			"https://gitlab.com/api/v4/projects/libtiff%2Flibtiff/merge_requests/379":             http.StatusOK,
+			"https://gitlab.com/api/v4/projects/libtiff%2Flibtiff/merge_requests/380":             http.StatusOK,
+			"https://gitlab.com/api/v4/projects/libtiff%2Flibtiff/merge_requests/381":             http.StatusNotFound,
+			"https://gitlab.com/api/v4/projects/gitlab-org%2Fsecurity%2Fgitaly/merge_requests/12": http.StatusOK,
+			"https://gitlab.gnome.org/api/v4/projects/GNOME%2Fglib/merge_requests/4":              http.StatusForbidden,
+		},
+		bodies: map[string]string{
+			"https://gitlab.com/api/v4/projects/libtiff%2Flibtiff/merge_requests/378":             `{"iid": 378, "state": "merged", "sha": "c0ffee", "merge_commit_sha": "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"}`,
+			"https://gitlab.com/api/v4/projects/libtiff%2Flibtiff/merge_requests/379":             `{"iid": 379, "state": "merged", "sha": "4f1b083be43f351bc107541e7b0c9655a5d2c0bb", "merge_commit_sha": null}`,
+			"https://gitlab.com/api/v4/projects/libtiff%2Flibtiff/merge_requests/380":             `{"iid": 380, "state": "opened", "sha": "4f1b083be43f351bc107541e7b0c9655a5d2c0bb", "merge_commit_sha": null}`,
+			"https://gitlab.com/api/v4/projects/gitlab-org%2Fsecurity%2Fgitaly/merge_requests/12": `{"iid": 12, "state": "merged", "sha": "c0ffee", "squash_commit_sha": "cd4e934d0527e5010e373e7fed54ef5daefba2f5"}`,
+		},
+	}
+	tests := []struct {
+		description       string
+		inputRepo         string
+		inputMergeRequest int
+		expectedCommit    string
+		expectedOk        bool
+	}{
+		{
+			description:       "Merged with a merge commit",
+			inputRepo:         "https://gitlab.com/libtiff/libtiff",
+			inputMergeRequest: 378,
+			expectedCommit:    "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e",
+			expectedOk:        true,
+		},
+		{
+			description:       "Fast-forward merged",
+			inputRepo:         "https://gitlab.com/libtiff/libtiff",
+			inputMergeRequest: 379,
+			expectedCommit:    "4f1b083be43f351bc107541e7b0c9655a5d2c0bb",
+			expectedOk:        true,
+		},
+		{
+			description:       "Squashed in a subgroup",
+			inputRepo:         "https://gitlab.com/gitlab-org/security/gitaly",
+			inputMergeRequest: 12,
+			expectedCommit:    "cd4e934d0527e5010e373e7fed54ef5daefba2f5",
+			expectedOk:        true,
+		},
+		{
+			description:       "Unmerged",
+			inputRepo:         "https://gitlab.com/libtiff/libtiff",
+			inputMergeRequest: 380,
+			expectedOk:        false,
+		},
+		{
+			description:       "Non-existent",
+			inputRepo:         "https://gitlab.com/libtiff/libtiff",
+			inputMergeRequest: 381,
+			expectedOk:        false,
+		},
+		{
+			description:       "Unexpected API response",
+			inputRepo:         "https://gitlab.gnome.org/GNOME/glib",
+			inputMergeRequest: 4,
+			expectedOk:        false,
+		},
+		{
+			description:       "Not a GitLab repository",
+			inputRepo:         "https://github.com/vim/vim",
+			inputMergeRequest: 1,
+			expectedOk:        false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := MergeRequestCommit(tc.inputRepo, tc.inputMergeRequest, client)
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: MergeRequestCommit(%q, %d) unexpectedly failed: %#v", tc.description, tc.inputRepo, tc.inputMergeRequest, err)
+		}
+		if err == nil && !tc.expectedOk {
+			t.Errorf("test %q: MergeRequestCommit(%q, %d) unexpectedly succeeded", tc.description, tc.inputRepo, tc.inputMergeRequest)
+		}
+		if got != tc.expectedCommit {
+			t.Errorf("test %q: MergeRequestCommit(%q, %d) was incorrect, got: %q, expected: %q", tc.description, tc.inputRepo, tc.inputMergeRequest, got, tc.expectedCommit)
+		}
+	}
+}
+
+func TestVerifyCommitGitHubEnterprise(t *testing.T) {
+	defer func(hosts []string) { cves.GitHubHosts = hosts }(cves.GitHubHosts)
+	cves.GitHubHosts = []string{"github.acme.com"}
+	client := &fakeHTTPClient{
+		responses: map[string]int{
+			"https://github.acme.com/api/v3/repos/platform/api/commits/b1351c15946349f9daa7e5297fb2ac6f3139e4a8": http.StatusOK,
+		},
+	}
+	gc := cves.GitCommit{Repo: "https://github.acme.com/platform/api", Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8"}
+	if got, err := VerifyCommit(gc, client); err != nil || !got {
+		t.Errorf("VerifyCommit(%#v) was incorrect, got: %t (%v), expected: true", gc, got, err)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	client := &fakeHTTPClient{
+		responses: map[string]int{
+			"https://api.github.com/repos/vim/vim/commits/4f1b083be43f351bc107541e7b0c9655a5d2c0bb":                                              http.StatusOK,
+			"https://gitlab.com/api/v4/projects/gitlab-org%2Fsecurity%2Fgitaly/repository/commits/9ebe80595afe4fdd1e2c74358d6a9421f4ce130e/diff": http.StatusOK,
+			"https://api.bitbucket.org/2.0/repositories/openpyxl/openpyxl/diffstat/3b4905f428e1":                                                 http.StatusOK,
+			"https://api.github.com/repos/vim/vim/commits/deadbeef":                                                                              http.StatusUnprocessableEntity,
+		},
+		bodies: map[string]string{
+			"https://api.github.com/repos/vim/vim/commits/4f1b083be43f351bc107541e7b0c9655a5d2c0bb":                                              `{"sha": "4f1b083be43f351bc107541e7b0c9655a5d2c0bb", "files": [{"filename": "src/normal.c"}, {"filename": "src/testdir/test_normal.vim"}]}`,
+			"https://gitlab.com/api/v4/projects/gitlab-org%2Fsecurity%2Fgitaly/repository/commits/9ebe80595afe4fdd1e2c74358d6a9421f4ce130e/diff": `[{"old_path": "internal/git/ref.go", "new_path": "internal/git/ref.go"}, {"old_path": "old.go", "new_path": "", "deleted_file": true}]`,
+			"https://api.bitbucket.org/2.0/repositories/openpyxl/openpyxl/diffstat/3b4905f428e1":                                                 `{"values": [{"new": {"path": "openpyxl/reader/excel.py"}, "old": {"path": "openpyxl/reader/excel.py"}}, {"new": null, "old": {"path": "openpyxl/removed.py"}}]}`,
+		},
+	}
+	tests := []struct {
+		description   string
+		inputCommit   cves.GitCommit
+		expectedFiles []string
+		expectedOk    bool
+	}{
+		{
+			description:   "GitHub commit",
+			inputCommit:   cves.GitCommit{Repo: "https://github.com/vim/vim", Commit: "4f1b083be43f351bc107541e7b0c9655a5d2c0bb"},
+			expectedFiles: []string{"src/normal.c", "src/testdir/test_normal.vim"},
+			expectedOk:    true,
+		},
+		{
+			description:   "GitLab commit in a subgroup, deleting a file",
+			inputCommit:   cves.GitCommit{Repo: "https://gitlab.com/gitlab-org/security/gitaly", Commit: "9ebe80595afe4fdd1e2c74358d6a9421f4ce130e"},
+			expectedFiles: []string{"internal/git/ref.go", "old.go"},
+			expectedOk:    true,
+		},
+		{
+			description:   "Bitbucket commit, deleting a file",
+			inputCommit:   cves.GitCommit{Repo: "https://bitbucket.org/openpyxl/openpyxl", Commit: "3b4905f428e1"},
+			expectedFiles: []string{"openpyxl/reader/excel.py", "openpyxl/removed.py"},
+			expectedOk:    true,
+		},
+		{
+			description: "Non-existent commit",
+			inputCommit: cves.GitCommit{Repo: "https://github.com/vim/vim", Commit: "deadbeef"},
+			expectedOk:  false,
+		},
+		{
+			description: "Unsupported host",
+			inputCommit: cves.GitCommit{Repo: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git", Commit: "817b8b9c5396d2b2d92311b46719aad5d3339dbe"},
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := ChangedFiles(tc.inputCommit, client)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: ChangedFiles(%+v) error was incorrect, got: %v, expected ok: %t", tc.description, tc.inputCommit, err, tc.expectedOk)
+		}
+		if diff := cmp.Diff(tc.expectedFiles, got); diff != "" {
+			t.Errorf("test %q: ChangedFiles(%+v) was incorrect: %s", tc.description, tc.inputCommit, diff)
+		}
+	}
+}
+
+func TestIntroducingCommit(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("Failed to create a repository: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get the worktree: %v", err)
+	}
+	when := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(files map[string]string) plumbing.Hash {
+		t.Helper()
+		for name, content := range files {
+			f, err := worktree.Filesystem.Create(name)
+			if err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+			f.Write([]byte(content))
+			f.Close()
+			if _, err := worktree.Add(name); err != nil {
+				t.Fatalf("Failed to add %s: %v", name, err)
+			}
+		}
+		when = when.Add(time.Hour)
+		signature := &object.Signature{Name: "Test", Email: "test@example.com", When: when}
+		hash, err := worktree.Commit("Commit", &git.CommitOptions{Author: signature, Committer: signature})
+		if err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+		return hash
+	}
+	root := commit(map[string]string{"README": "readme", "util.c": "util()"})
+	parser := commit(map[string]string{"parser.c": "parse()"})
+	commit(map[string]string{"parser.c": "parse() { unsafe }"})
+	lexer := commit(map[string]string{"lexer.c": "lex()"})
+	commit(map[string]string{"CHANGES.md": "1.0"})
+	fix := commit(map[string]string{"parser.c": "parse() { safe }", "parser_test.c": "test()"})
+	fixWithNotes := commit(map[string]string{"parser.c": "parse() { safer }", "README": "fixed", "CHANGES.md": "1.1"})
+	fixWithOldFile := commit(map[string]string{"parser.c": "parse() { safest }", "util.c": "util() { safe }"})
+	fixOfTwoFiles := commit(map[string]string{"parser.c": "parse() { safe again }", "lexer.c": "lex() { safe }"})
+	notesOnly := commit(map[string]string{"CHANGES.md": "1.2"})
+	addsOnly := commit(map[string]string{"fuzz.c": "fuzz()"})
+
+	tests := []struct {
+		description    string
+		inputFix       plumbing.Hash
+		expectedCommit string
+	}{
+		{
+			description:    "Fix modifying a file",
+			inputFix:       fix,
+			expectedCommit: parser.String(),
+		},
+		{
+			description:    "Fix also changing release notes and documentation",
+			inputFix:       fixWithNotes,
+			expectedCommit: parser.String(),
+		},
+		{
+			description:    "Fix also modifying an older file",
+			inputFix:       fixWithOldFile,
+			expectedCommit: parser.String(),
+		},
+		{
+			description:    "Fix modifying files created by different commits",
+			inputFix:       fixOfTwoFiles,
+			expectedCommit: lexer.String(),
+		},
+		{
+			description: "Fix only changing release notes",
+			inputFix:    notesOnly,
+		},
+		{
+			description: "Fix only adding files",
+			inputFix:    addsOnly,
+		},
+		{
+			description: "First commit",
+			inputFix:    root,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := introducingCommit(repo, tc.inputFix)
+		if err != nil {
+			t.Errorf("test %q: introducingCommit() unexpectedly errored: %v", tc.description, err)
+		}
+		if got != tc.expectedCommit {
+			t.Errorf("test %q: introducingCommit() was incorrect, got: %q, expected: %q", tc.description, got, tc.expectedCommit)
+		}
+	}
+}
+
+func TestAPIRepo(t *testing.T) {
+	tests := []struct {
+		description  string
+		inputRepo    string
+		expectedAPI  hostAPI
+		expectedBase string
+		expectedOk   bool
+	}{
+		{
+			description:  "GitHub",
+			inputRepo:    "https://github.com/vim/vim.git",
+			expectedAPI:  githubAPI,
+			expectedBase: "https://api.github.com/repos/vim/vim",
+			expectedOk:   true,
+		},
+		{
+			description:  "GitLab subgroup",
+			inputRepo:    "https://gitlab.com/gitlab-org/security/gitaly/",
+			expectedAPI:  gitlabAPI,
+			expectedBase: "https://gitlab.com/api/v4/projects/gitlab-org%2Fsecurity%2Fgitaly",
+			expectedOk:   true,
+		},
+		{
+			description:  "Bitbucket",
+			inputRepo:    "https://bitbucket.org/openpyxl/openpyxl",
+			expectedAPI:  bitbucketAPI,
+			expectedBase: "https://api.bitbucket.org/2.0/repositories/openpyxl/openpyxl",
+			expectedOk:   true,
+		},
+		{
+			description: "No repository path",
+			inputRepo:   "https://github.com/",
+			expectedOk:  false,
+		},
+		{
+			description: "Host without a supported API",
+			inputRepo:   "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		gotAPI, gotBase, gotOk := apiRepo(tc.inputRepo)
+		if gotAPI != tc.expectedAPI || gotBase != tc.expectedBase || gotOk != tc.expectedOk {
+			t.Errorf("test %q: apiRepo(%q) was incorrect, got: %d, %q, %t, expected: %d, %q, %t", tc.description, tc.inputRepo, gotAPI, gotBase, gotOk, tc.expectedAPI, tc.expectedBase, tc.expectedOk)
+		}
+	}
+}
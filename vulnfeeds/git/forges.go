@@ -0,0 +1,111 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// The meta tags identifying the forge that rendered a page, e.g.
+// <meta name="generator" content="cgit v1.2.3"/> or <meta property="og:site_name" content="GitLab">.
+var forgeMetaPattern = regexp.MustCompile(`(?i)<meta\s+(?:name=["'](?:generator|keywords)["']|property=["']og:site_name["'])\s+content=["']([^"']*)["']`)
+
+// forgeOfMeta returns the forge named by the content of a meta tag matched by forgeMetaPattern.
+func forgeOfMeta(content string) cves.Forge {
+	content = strings.ToLower(content)
+	switch {
+	case strings.HasPrefix(content, "cgit"):
+		return cves.ForgeCGit
+	case content == "gitlab":
+		return cves.ForgeGitLab
+	case strings.Contains(content, "gitea") || strings.Contains(content, "forgejo"):
+		return cves.ForgeGitea
+	}
+	return ""
+}
+
+// get requests u with client, returning the response status and (up to 1MiB of) body.
+func get(u string, client HTTPClient) (int, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, body, err
+}
+
+// ProbeForge detects the forge a host runs, using client: Gitea (and Forgejo) and GitLab by the version
+// endpoints of their APIs, and cgit (which has no API) by the generator meta tag of its index page.
+// Returns "" if the host runs none of them.
+func ProbeForge(hostname string, client HTTPClient) (cves.Forge, error) {
+	var version struct {
+		Version  *string `json:"version"`
+		Revision *string `json:"revision"`
+		Message  string  `json:"message"`
+	}
+	status, body, err := get(fmt.Sprintf("https://%s/api/v1/version", hostname), client)
+	if err != nil {
+		return "", err
+	}
+	if status == http.StatusOK && json.Unmarshal(body, &version) == nil && version.Version != nil && version.Revision == nil {
+		return cves.ForgeGitea, nil
+	}
+
+	// GitLab only reports its version to authenticated users, but says as much.
+	version.Version, version.Revision, version.Message = nil, nil, ""
+	status, body, err = get(fmt.Sprintf("https://%s/api/v4/version", hostname), client)
+	if err != nil {
+		return "", err
+	}
+	if json.Unmarshal(body, &version) == nil &&
+		((status == http.StatusOK && version.Version != nil && version.Revision != nil) ||
+			(status == http.StatusUnauthorized && version.Message == "401 Unauthorized")) {
+		return cves.ForgeGitLab, nil
+	}
+
+	status, body, err = get(fmt.Sprintf("https://%s/", hostname), client)
+	if err != nil {
+		return "", err
+	}
+	if status == http.StatusOK {
+		for _, match := range forgeMetaPattern.FindAllSubmatch(body, -1) {
+			if forge := forgeOfMeta(string(match[1])); forge != "" {
+				return forge, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// ForgeProber returns a cves.ForgeProber that uses ProbeForge with client, e.g. for cves.ExtractOptions,
+// retrying transient failures.
+func ForgeProber(client HTTPClient) cves.ForgeProber {
+	client = withRetries(client)
+	return func(hostname string) (cves.Forge, error) {
+		return ProbeForge(hostname, client)
+	}
+}
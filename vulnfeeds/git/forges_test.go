@@ -0,0 +1,89 @@
+package git
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func TestProbeForge(t *testing.T) {
+	client := &fakeHTTPClient{
+		responses: map[string]int{
+			"https://try.gitea.io/api/v1/version":          http.StatusOK,
+			"https://gitlab.example.com/api/v1/version":    http.StatusNotFound,
+			"https://gitlab.example.com/api/v4/version":    http.StatusUnauthorized,
+			"https://git.zx2c4.com/api/v1/version":         http.StatusNotFound,
+			"https://git.zx2c4.com/api/v4/version":         http.StatusNotFound,
+			"https://git.zx2c4.com/":                       http.StatusOK,
+			"https://www.example.com/api/v1/version":       http.StatusNotFound,
+			"https://www.example.com/api/v4/version":       http.StatusNotFound,
+			"https://www.example.com/":                     http.StatusOK,
+			"https://versioned.example.com/api/v1/version": http.StatusOK,
+			"https://versioned.example.com/api/v4/version": http.StatusNotFound,
+			"https://versioned.example.com/":               http.StatusNotFound,
+		},
+		bodies: map[string]string{
+			"https://try.gitea.io/api/v1/version":       `{"version":"1.21.0+dev-123-gabcdef1"}`,
+			"https://gitlab.example.com/api/v4/version": `{"message":"401 Unauthorized"}`,
+			"https://git.zx2c4.com/": `<!DOCTYPE html>
+<html lang='en'>
+<head>
+<title>Jason A. Donenfeld's Git Repositories</title>
+<meta name='generator' content='cgit v1.2.3-korg'/>
+</head>`,
+			"https://www.example.com/":                     `<html><head><meta name="generator" content="WordPress 6.3"></head></html>`,
+			"https://versioned.example.com/api/v1/version": `{"version":"2.0","revision":"abc"}`,
+		},
+	}
+	tests := []struct {
+		description   string
+		inputHostname string
+		expectedForge cves.Forge
+		expectedOk    bool
+	}{
+		{
+			description:   "Gitea",
+			inputHostname: "try.gitea.io",
+			expectedForge: cves.ForgeGitea,
+			expectedOk:    true,
+		},
+		{
+			description:   "GitLab",
+			inputHostname: "gitlab.example.com",
+			expectedForge: cves.ForgeGitLab,
+			expectedOk:    true,
+		},
+		{
+			description:   "cgit",
+			inputHostname: "git.zx2c4.com",
+			expectedForge: cves.ForgeCGit,
+			expectedOk:    true,
+		},
+		{
+			description:   "Not a forge",
+			inputHostname: "www.example.com",
+			expectedOk:    true,
+		},
+		{
+			description:   "Unrelated version endpoint",
+			inputHostname: "versioned.example.com",
+			expectedOk:    true,
+		},
+		{
+			description:   "Unreachable",
+			inputHostname: "unreachable.example.com",
+			expectedOk:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := ProbeForge(tc.inputHostname, client)
+		if (err == nil) != tc.expectedOk {
+			t.Errorf("test %q: ProbeForge(%q) unexpectedly errored: %v", tc.description, tc.inputHostname, err)
+		}
+		if got != tc.expectedForge {
+			t.Errorf("test %q: ProbeForge(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputHostname, got, tc.expectedForge)
+		}
+	}
+}
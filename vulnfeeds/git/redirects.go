@@ -0,0 +1,75 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// MaxRedirects is the number of redirects ResolveRedirects follows before giving up.
+const MaxRedirects = 10
+
+// ResolveRedirects returns the URL u finally leads to, following the redirects of HEAD requests made
+// with client. Redirects are followed by ResolveRedirects itself, so client may (like http.DefaultClient)
+// or may not follow them; either way, no more than MaxRedirects are followed.
+func ResolveRedirects(u string, client HTTPClient) (string, error) {
+	current, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	for redirects := 0; ; redirects++ {
+		req, err := http.NewRequest(http.MethodHead, current.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			if resp.StatusCode >= 400 {
+				return "", fmt.Errorf("unexpected status %q from %s", resp.Status, current)
+			}
+			// A client that follows redirects itself reports the request that was finally made.
+			if resp.Request != nil && resp.Request.URL != nil {
+				return resp.Request.URL.String(), nil
+			}
+			return current.String(), nil
+		}
+		if redirects == MaxRedirects {
+			return "", fmt.Errorf("stopped after %d redirects from %s", MaxRedirects, u)
+		}
+		next, err := current.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("invalid redirect from %s to %q: %w", current, location, err)
+		}
+		current = next
+	}
+}
+
+// ReferenceResolver returns a cves.ReferenceResolver that uses ResolveRedirects with client,
+// retrying transient failures.
+func ReferenceResolver(client HTTPClient) cves.ReferenceResolver {
+	client = withRetries(client)
+	return func(u string) (string, error) {
+		return ResolveRedirects(u, client)
+	}
+}
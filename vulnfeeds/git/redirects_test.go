@@ -0,0 +1,101 @@
+package git
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResolveRedirects(t *testing.T) {
+	client := &fakeHTTPClient{
+		responses: map[string]int{
+			"https://example.org/project":         http.StatusMovedPermanently,
+			"https://example.org/git":             http.StatusFound,
+			"https://github.com/example/project":  http.StatusOK,
+			"https://short.example/abc":           http.StatusFound,
+			"https://example.org/missing":         http.StatusNotFound,
+			"https://loop.example/a":              http.StatusFound,
+			"https://loop.example/b":              http.StatusFound,
+			"https://gitlab.com/example/project/": http.StatusOK,
+		},
+		locations: map[string]string{
+			"https://example.org/project": "/git",
+			"https://example.org/git":     "https://github.com/example/project",
+			"https://short.example/abc":   "https://gitlab.com/example/project/",
+			"https://loop.example/a":      "https://loop.example/b",
+			"https://loop.example/b":      "https://loop.example/a",
+		},
+	}
+	tests := []struct {
+		description    string
+		inputURL       string
+		expectedResult string
+		expectedOk     bool
+	}{
+		{
+			description:    "Chain of redirects, including a relative one",
+			inputURL:       "https://example.org/project",
+			expectedResult: "https://github.com/example/project",
+			expectedOk:     true,
+		},
+		{
+			description:    "Single redirect",
+			inputURL:       "https://short.example/abc",
+			expectedResult: "https://gitlab.com/example/project/",
+			expectedOk:     true,
+		},
+		{
+			description:    "No redirect",
+			inputURL:       "https://github.com/example/project",
+			expectedResult: "https://github.com/example/project",
+			expectedOk:     true,
+		},
+		{
+			description: "Not found",
+			inputURL:    "https://example.org/missing",
+			expectedOk:  false,
+		},
+		{
+			description: "Redirect loop",
+			inputURL:    "https://loop.example/a",
+			expectedOk:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := ResolveRedirects(tc.inputURL, client)
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: ResolveRedirects(%q) unexpectedly errored: %v", tc.description, tc.inputURL, err)
+			continue
+		}
+		if err == nil && !tc.expectedOk {
+			t.Errorf("test %q: ResolveRedirects(%q) unexpectedly succeeded with %q", tc.description, tc.inputURL, got)
+			continue
+		}
+		if got != tc.expectedResult {
+			t.Errorf("test %q: ResolveRedirects(%q) was incorrect, got: %q, expected: %q", tc.description, tc.inputURL, got, tc.expectedResult)
+		}
+	}
+}
+
+func TestResolveRedirectsFollowingClient(t *testing.T) {
+	// A client that follows redirects itself reports the final request in the response.
+	finalURL := "https://github.com/example/project"
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		final, _ := http.NewRequest(http.MethodHead, finalURL, nil)
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: http.NoBody, Request: final}, nil
+	})
+	got, err := ResolveRedirects("https://example.org/project", client)
+	if err != nil {
+		t.Fatalf("ResolveRedirects() unexpectedly errored: %v", err)
+	}
+	if got != finalURL {
+		t.Errorf("ResolveRedirects() was incorrect, got: %q, expected: %q", got, finalURL)
+	}
+}
+
+// clientFunc is an HTTPClient implemented by a function.
+type clientFunc func(req *http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The retry budget used by NewRetryingClient.
+const (
+	DefaultMaxAttempts = 4
+	DefaultBaseDelay   = time.Second
+	DefaultMaxDelay    = time.Minute
+)
+
+// RetryingClient is an HTTPClient that retries requests failing transiently (network errors,
+// rate limiting and server errors) with exponential backoff, honoring any Retry-After header.
+// The zero value makes a single attempt with http.DefaultClient.
+type RetryingClient struct {
+	// The client making the requests. If nil, http.DefaultClient is used.
+	Client HTTPClient
+	// The maximum number of attempts made for a request, including the first one.
+	MaxAttempts int
+	// The delay before the first retry, doubled for each subsequent retry up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Used to wait between attempts, so tests can avoid actually waiting. If nil, time.Sleep is used.
+	Sleep func(time.Duration)
+}
+
+// NewRetryingClient returns a RetryingClient wrapping client, with the default retry budget.
+func NewRetryingClient(client HTTPClient) *RetryingClient {
+	return &RetryingClient{
+		Client:      client,
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+		Sleep:       time.Sleep,
+	}
+}
+
+// withRetries returns client wrapped in a RetryingClient with the default retry budget, unless it
+// already is one, for the adapters providing the cves hooks. A nil client is taken to be http.DefaultClient.
+func withRetries(client HTTPClient) HTTPClient {
+	if retrying, ok := client.(*RetryingClient); ok {
+		if retrying != nil {
+			return retrying
+		}
+		client = nil
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return NewRetryingClient(client)
+}
+
+// Do sends req, retrying it while the response is transient and attempts remain.
+// Requests with a body are only retried if the body can be replayed (i.e. req.GetBody is set).
+// The response of the final attempt is returned as is.
+func (c *RetryingClient) Do(req *http.Request) (*http.Response, error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	sleep := c.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	delay := c.BaseDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if attempt >= c.MaxAttempts || !retryable(resp, err) {
+			return resp, err
+		}
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+		wait := delay
+		if retryAfter, ok := retryAfterDelay(resp); ok {
+			wait = retryAfter
+		}
+		if wait > c.MaxDelay {
+			wait = c.MaxDelay
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		sleep(wait)
+		delay *= 2
+	}
+}
+
+// retryable reports whether the outcome of a request is transient and worth retrying.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= http.StatusInternalServerError:
+		return true
+	// GitHub signals exceeding its (secondary) rate limits with a 403.
+	case resp.StatusCode == http.StatusForbidden:
+		_, hasRetryAfter := retryAfterDelay(resp)
+		return hasRetryAfter || resp.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return false
+}
+
+// retryAfterDelay returns the delay requested by the Retry-After header of resp, if any.
+// The header may be given in seconds or as an HTTP date.
+func retryAfterDelay(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	retryAfter := resp.Header.Get("Retry-After")
+	if retryAfter == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,189 @@
+package git
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// sequenceHTTPClient returns the canned responses in order, one per request.
+type sequenceHTTPClient struct {
+	responses []*http.Response
+	requests  int
+}
+
+func (c *sequenceHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if c.requests >= len(c.responses) {
+		return nil, errors.New("unexpected request for " + req.URL.String())
+	}
+	resp := c.responses[c.requests]
+	c.requests++
+	if resp == nil {
+		return nil, errors.New("connection reset")
+	}
+	return resp, nil
+}
+
+func response(status int, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("{}")),
+	}
+}
+
+func TestRetryingClient(t *testing.T) {
+	tests := []struct {
+		description      string
+		inputResponses   []*http.Response
+		expectedStatus   int
+		expectedOk       bool
+		expectedRequests int
+		expectedDelays   []time.Duration
+	}{
+		{
+			description:      "Success on the first attempt",
+			inputResponses:   []*http.Response{response(http.StatusOK, nil)},
+			expectedStatus:   http.StatusOK,
+			expectedOk:       true,
+			expectedRequests: 1,
+		},
+		{
+			description:      "Server errors with exponential backoff",
+			inputResponses:   []*http.Response{response(http.StatusBadGateway, nil), nil, response(http.StatusOK, nil)},
+			expectedStatus:   http.StatusOK,
+			expectedOk:       true,
+			expectedRequests: 3,
+			expectedDelays:   []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			description:      "Rate limited with Retry-After",
+			inputResponses:   []*http.Response{response(http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}}), response(http.StatusOK, nil)},
+			expectedStatus:   http.StatusOK,
+			expectedOk:       true,
+			expectedRequests: 2,
+			expectedDelays:   []time.Duration{30 * time.Second},
+		},
+		{
+			description:      "GitHub rate limit exhausted",
+			inputResponses:   []*http.Response{response(http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"0"}}), response(http.StatusNotFound, nil)},
+			expectedStatus:   http.StatusNotFound,
+			expectedOk:       true,
+			expectedRequests: 2,
+			expectedDelays:   []time.Duration{time.Second},
+		},
+		{
+			description:      "Forbidden without rate limiting is not retried",
+			inputResponses:   []*http.Response{response(http.StatusForbidden, nil)},
+			expectedStatus:   http.StatusForbidden,
+			expectedOk:       true,
+			expectedRequests: 1,
+		},
+		{
+			description:      "Attempts exhausted",
+			inputResponses:   []*http.Response{response(http.StatusServiceUnavailable, nil), response(http.StatusServiceUnavailable, nil), response(http.StatusServiceUnavailable, http.Header{"Retry-After": {"3600"}}), nil},
+			expectedOk:       false,
+			expectedRequests: 4,
+			expectedDelays:   []time.Duration{time.Second, 2 * time.Second, time.Minute},
+		},
+	}
+
+	for _, tc := range tests {
+		fake := &sequenceHTTPClient{responses: tc.inputResponses}
+		var gotDelays []time.Duration
+		client := NewRetryingClient(fake)
+		client.Sleep = func(d time.Duration) { gotDelays = append(gotDelays, d) }
+		req, err := http.NewRequest(http.MethodGet, "https://api.github.com/repos/google/osv.dev", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil && tc.expectedOk {
+			t.Errorf("test %q: Do() unexpectedly failed: %v", tc.description, err)
+		}
+		if err == nil && !tc.expectedOk {
+			t.Errorf("test %q: Do() unexpectedly succeeded", tc.description)
+		}
+		if err == nil && resp.StatusCode != tc.expectedStatus {
+			t.Errorf("test %q: Do() returned status %d, expected: %d", tc.description, resp.StatusCode, tc.expectedStatus)
+		}
+		if fake.requests != tc.expectedRequests {
+			t.Errorf("test %q: Do() made %d requests, expected: %d", tc.description, fake.requests, tc.expectedRequests)
+		}
+		if diff := cmp.Diff(tc.expectedDelays, gotDelays); diff != "" {
+			t.Errorf("test %q: Do() delays were incorrect: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestRetryingClientZeroValue(t *testing.T) {
+	fake := &sequenceHTTPClient{responses: []*http.Response{response(http.StatusServiceUnavailable, nil), response(http.StatusOK, nil)}}
+	client := &RetryingClient{Client: fake, MaxAttempts: 2}
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/repos/google/osv.dev", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without a Sleep, the (zero) delay is waited for with time.Sleep.
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() unexpectedly failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || fake.requests != 2 {
+		t.Errorf("Do() returned status %d after %d requests, expected: %d after 2", resp.StatusCode, fake.requests, http.StatusOK)
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	fake := &sequenceHTTPClient{}
+	retrying := &RetryingClient{Client: fake}
+	var nilRetrying *RetryingClient
+	tests := []struct {
+		description    string
+		inputClient    HTTPClient
+		expectedClient HTTPClient
+	}{
+		{
+			description:    "Client is wrapped",
+			inputClient:    fake,
+			expectedClient: fake,
+		},
+		{
+			description:    "RetryingClient is used as is",
+			inputClient:    retrying,
+			expectedClient: fake,
+		},
+		{
+			description:    "No client",
+			inputClient:    nil,
+			expectedClient: http.DefaultClient,
+		},
+		{
+			description:    "Nil RetryingClient",
+			inputClient:    nilRetrying,
+			expectedClient: http.DefaultClient,
+		},
+	}
+
+	for _, tc := range tests {
+		got, ok := withRetries(tc.inputClient).(*RetryingClient)
+		if !ok || got == nil {
+			t.Errorf("test %q: withRetries() didn't return a RetryingClient", tc.description)
+			continue
+		}
+		if got.Client != tc.expectedClient {
+			t.Errorf("test %q: withRetries() wrapped %v, expected: %v", tc.description, got.Client, tc.expectedClient)
+		}
+		if tc.inputClient == retrying && got != retrying {
+			t.Errorf("test %q: withRetries() wrapped the RetryingClient again", tc.description)
+		}
+	}
+}
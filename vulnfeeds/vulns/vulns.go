@@ -19,9 +19,11 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
+	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v2"
 
 	"github.com/google/osv/vulnfeeds/cves"
@@ -77,6 +79,9 @@ func timestampToRFC3339(timestamp string) (string, error) {
 	return t.Format(time.RFC3339), nil
 }
 
+// Matches a GitHub Security Advisory ID, e.g. GHSA-fr26-qjc8-mvjx.
+var ghsaIDPattern = regexp.MustCompile(`^GHSA(-[23456789cfghjmpqrvwx]{4}){3}$`)
+
 // For a given URL, infer the OSV schema's reference type of it.
 // See https://ossf.github.io/osv-schema/#references-field
 // Uses the tags first before resorting to inference by shape.
@@ -100,6 +105,11 @@ func ClassifyReferenceLink(link string, tag string) string {
 		return "WEB"
 	}
 
+	// Example: https://gitlab.com/gitlab-org/cves/-/blob/master/2022/CVE-2022-2501.json
+	if _, ok := cves.GitLabAdvisoryJSON(link); ok {
+		return "ADVISORY"
+	}
+
 	pathParts := strings.Split(u.Path, "/")
 
 	// Index 0 will always be "", so the length must be at least 2 to be relevant
@@ -112,10 +122,16 @@ func ClassifyReferenceLink(link string, tag string) string {
 
 			// Example: https://github.com/advisories/GHSA-fr26-qjc8-mvjx
 			// Example: https://github.com/dpgaspar/Flask-AppBuilder/security/advisories/GHSA-624f-cqvr-3qw4
-			if pathParts[len(pathParts)-2] == "advisories" {
+			if pathParts[len(pathParts)-2] == "advisories" && ghsaIDPattern.MatchString(pathParts[len(pathParts)-1]) {
 				return "ADVISORY"
 			}
 
+			// Other advisory pages are listings or forms rather than an advisory.
+			// Example: https://github.com/google/osv/security/advisories/new
+			if slices.Contains(pathParts, "advisories") {
+				return "WEB"
+			}
+
 			// Example: https://github.com/Netflix/lemur/issues/117
 			if pathParts[len(pathParts)-2] == "issues" {
 				return "REPORT"
@@ -227,7 +243,7 @@ func extractAliases(id string, cve cves.CVE) []string {
 					if pathParts[len(pathParts)-2] == "advisories" {
 						a := pathParts[len(pathParts)-1]
 
-						if id != a && strings.HasPrefix(a, "GHSA-") {
+						if id != a && ghsaIDPattern.MatchString(a) {
 							aliases = append(aliases, a)
 						}
 					}
@@ -22,6 +22,10 @@ func TestClassifyReferenceLink(t *testing.T) {
 		{"https://github.com/google/osv/commit/cd4e934d0527e5010e373e7fed54ef5daefba2f5", "", "FIX"},
 		{"https://github.com/advisories/GHSA-fr26-qjc8-mvjx", "", "ADVISORY"},
 		{"https://github.com/dpgaspar/Flask-AppBuilder/security/advisories/GHSA-624f-cqvr-3qw4", "", "ADVISORY"},
+		{"https://github.com/dpgaspar/Flask-AppBuilder/security/advisories/new", "", "WEB"},
+		{"https://github.com/dpgaspar/Flask-AppBuilder/security/advisories", "", "WEB"},
+		{"https://github.com/advisories/GHSA-0000-0000-0000", "", "WEB"},
+		{"https://github.com/dpgaspar/Flask-AppBuilder/security/advisories/new", "Vendor Advisory", "ADVISORY"},
 		{"https://github.com/Netflix/lemur/issues/117", "", "REPORT"},
 		{"https://snyk.io/vuln/SNYK-PYTHON-TRYTOND-1730329", "", "ADVISORY"},
 		{"https://nvd.nist.gov/vuln/detail/CVE-2021-23336", "", "ADVISORY"},
@@ -34,6 +38,8 @@ func TestClassifyReferenceLink(t *testing.T) {
 		{"https://security.gentoo.org/glsa/202003-45", "", "ADVISORY"},
 		{"https://pypi.org/project/flask", "", "PACKAGE"},
 		{"https://bugzilla.redhat.com/show_bug.cgi?id=684877", "", "REPORT"},
+		{"https://gitlab.com/gitlab-org/cves/-/blob/master/2022/CVE-2022-2501.json", "", "ADVISORY"},
+		{"https://gitlab.com/gitlab-org/gitlab/-/blob/master/app/models/user.rb", "", "WEB"},
 		{"https://github.com/log4js-node/log4js-node/pull/1141/commits/8042252861a1b65adb66931fdf702ead34fa9b76", "Patch", "FIX"},
 	}
 